@@ -22,7 +22,7 @@ func New(db *database.DB, cfg config.Config) *Api {
 			"http://localhost:3000", "http://localhost:8081",
 		},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-API-Key"},
 		AllowCredentials: true,
 	}
 	r.Use(cors.New(corsConfig))
@@ -31,6 +31,11 @@ func New(db *database.DB, cfg config.Config) *Api {
 	routes.RegisterAuth(apiGroup, cfg, db)
 	routes.RegisterDoctor(apiGroup, cfg, db)
 	routes.RegisterReceptionist(apiGroup, cfg, db)
+	routes.RegisterNurse(apiGroup, cfg, db)
+	routes.RegisterLab(apiGroup, cfg, db)
+
+	routes.RegisterVerify(&r.RouterGroup, cfg, db)
+	routes.RegisterFHIR(&r.RouterGroup, cfg, db)
 
 	return &Api{App: r}
 }
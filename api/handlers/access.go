@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"hospital/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// authorizePatient parses the patient ID and checks a doctor's access to the
+// patient; other roles may reach every patient. On failure it writes the error
+// response and returns false.
+func authorizePatient(c *gin.Context, doctorService services.DoctorService) (userID, patientID uuid.UUID, ok bool) {
+	patientID, err := uuid.Parse(c.Param("patient_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid patient ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	userID, ok = currentUserID(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	if role, _ := c.Get("user_role"); role == "doctor" {
+		if err := doctorService.AuthorizePatient(userID, patientID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "patient not found"})
+			} else {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			}
+			return uuid.Nil, uuid.Nil, false
+		}
+	}
+	return userID, patientID, true
+}
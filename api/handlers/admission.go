@@ -0,0 +1,425 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"hospital/internal/models"
+	"hospital/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AdmissionHandler serves wards, beds and admissions to receptionists, nurses
+// and doctors. Doctors only see the admissions they are attending.
+type AdmissionHandler struct {
+	admissionService services.AdmissionService
+}
+
+func NewAdmissionHandler(admissionService services.AdmissionService) *AdmissionHandler {
+	return &AdmissionHandler{
+		admissionService: admissionService,
+	}
+}
+
+type WardInput struct {
+	Code      string `json:"code" binding:"required"`
+	Name      string `json:"name" binding:"required"`
+	Specialty string `json:"specialty"`
+	Floor     string `json:"floor"`
+	Active    *bool  `json:"active"` // Only used on update, defaults to true
+}
+
+func (input WardInput) ward() models.Ward {
+	return models.Ward{
+		Code:      input.Code,
+		Name:      input.Name,
+		Specialty: input.Specialty,
+		Floor:     input.Floor,
+		Active:    input.Active == nil || *input.Active,
+	}
+}
+
+func (h *AdmissionHandler) GetWards(c *gin.Context) {
+	wards, err := h.admissionService.GetWards(c.Query("include_inactive") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve wards"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"wards": wards})
+}
+
+func (h *AdmissionHandler) CreateWard(c *gin.Context) {
+	var input WardInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	ward := input.ward()
+	if err := h.admissionService.CreateWard(&ward); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Ward created successfully", "ward": ward})
+}
+
+func (h *AdmissionHandler) UpdateWard(c *gin.Context) {
+	wardID, err := uuid.Parse(c.Param("ward_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ward ID format"})
+		return
+	}
+
+	var input WardInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	update := input.ward()
+	ward, err := h.admissionService.UpdateWard(wardID, &update)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Ward not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Ward updated successfully", "ward": ward})
+}
+
+func (h *AdmissionHandler) CreateRoom(c *gin.Context) {
+	wardID, err := uuid.Parse(c.Param("ward_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ward ID format"})
+		return
+	}
+
+	type RoomInput struct {
+		Number string `json:"number" binding:"required"`
+	}
+
+	var input RoomInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	room := models.Room{Number: input.Number}
+	if err := h.admissionService.CreateRoom(wardID, &room); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Ward not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Room created successfully", "room": room})
+}
+
+func (h *AdmissionHandler) CreateBed(c *gin.Context) {
+	roomID, err := uuid.Parse(c.Param("room_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid room ID format"})
+		return
+	}
+
+	type BedInput struct {
+		Label string `json:"label" binding:"required"`
+	}
+
+	var input BedInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	bed := models.Bed{Label: input.Label}
+	if err := h.admissionService.CreateBed(roomID, &bed); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Room not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Bed created successfully", "bed": bed})
+}
+
+func (h *AdmissionHandler) SetBedStatus(c *gin.Context) {
+	bedID, err := uuid.Parse(c.Param("bed_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bed ID format"})
+		return
+	}
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	type BedStatusInput struct {
+		Status string `json:"status" binding:"required"` // free, cleaning or blocked
+		Note   string `json:"note"`                      // Required when blocking
+	}
+
+	var input BedStatusInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	bed, err := h.admissionService.SetBedStatus(userID, bedID, input.Status, input.Note)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Bed not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Bed status updated", "bed": bed})
+}
+
+func (h *AdmissionHandler) GetBedBoard(c *gin.Context) {
+	var wardID *uuid.UUID
+	if value := c.Query("ward_id"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ward ID format"})
+			return
+		}
+		wardID = &id
+	}
+
+	var doctorID *uuid.UUID
+	if role, _ := c.Get("user_role"); role == "doctor" {
+		id, ok := currentUserID(c)
+		if !ok {
+			return
+		}
+		doctorID = &id
+	}
+
+	board, err := h.admissionService.GetBedBoard(wardID, doctorID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve bed board"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"wards": board, "generated_at": time.Now()})
+}
+
+func (h *AdmissionHandler) AdmitPatient(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	type AdmissionInput struct {
+		PatientID         uuid.UUID  `json:"patient_id" binding:"required"`
+		AttendingDoctorID uuid.UUID  `json:"attending_doctor_id" binding:"required"`
+		BedID             uuid.UUID  `json:"bed_id" binding:"required"`
+		Reason            string     `json:"reason" binding:"required"`
+		AdmittedAt        *time.Time `json:"admitted_at"` // Defaults to now
+	}
+
+	var input AdmissionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	admission := models.Admission{
+		PatientID:         input.PatientID,
+		AttendingDoctorID: input.AttendingDoctorID,
+		BedID:             input.BedID,
+		Reason:            input.Reason,
+	}
+	if input.AdmittedAt != nil {
+		admission.AdmittedAt = *input.AdmittedAt
+	}
+
+	if err := h.admissionService.Admit(userID, &admission); err != nil {
+		switch err.Error() {
+		case "patient not found", "doctor not found", "bed not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case "patient is already admitted":
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Patient admitted successfully", "admission": admission})
+}
+
+func (h *AdmissionHandler) GetAdmissions(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	filter := services.AdmissionFilter{Status: c.Query("status")}
+	for name, target := range map[string]**uuid.UUID{
+		"patient_id": &filter.PatientID,
+		"doctor_id":  &filter.DoctorID,
+		"ward_id":    &filter.WardID,
+	} {
+		if value := c.Query(name); value != "" {
+			id, err := uuid.Parse(value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name + " format"})
+				return
+			}
+			*target = &id
+		}
+	}
+	if role, _ := c.Get("user_role"); role == "doctor" {
+		doctorID, ok := currentUserID(c)
+		if !ok {
+			return
+		}
+		filter.DoctorID = &doctorID
+	}
+
+	admissions, total, err := h.admissionService.GetAdmissions(filter, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve admissions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"admissions": admissions,
+		"pagination": gin.H{
+			"current_page": page,
+			"total_pages":  (int(total) + limit - 1) / limit,
+			"total_count":  total,
+			"per_page":     limit,
+		},
+	})
+}
+
+func (h *AdmissionHandler) GetAdmission(c *gin.Context) {
+	admissionID, err := uuid.Parse(c.Param("admission_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid admission ID format"})
+		return
+	}
+
+	admission, err := h.admissionService.GetAdmission(admissionID)
+	if err == nil {
+		if role, _ := c.Get("user_role"); role == "doctor" {
+			doctorID, ok := currentUserID(c)
+			if !ok {
+				return
+			}
+			if admission.AttendingDoctorID != doctorID {
+				err = gorm.ErrRecordNotFound
+			}
+		}
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Admission not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve admission"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"admission": admission})
+}
+
+func (h *AdmissionHandler) TransferPatient(c *gin.Context) {
+	admissionID, err := uuid.Parse(c.Param("admission_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid admission ID format"})
+		return
+	}
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	type TransferInput struct {
+		BedID  uuid.UUID `json:"bed_id" binding:"required"`
+		Reason string    `json:"reason"`
+	}
+
+	var input TransferInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	admission, err := h.admissionService.Transfer(userID, admissionID, input.BedID, input.Reason)
+	if err != nil {
+		writeAdmissionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Patient transferred", "admission": admission})
+}
+
+func (h *AdmissionHandler) DischargePatient(c *gin.Context) {
+	admissionID, err := uuid.Parse(c.Param("admission_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid admission ID format"})
+		return
+	}
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	type DischargeInput struct {
+		DischargedAt *time.Time `json:"discharged_at"` // Defaults to now
+		Disposition  string     `json:"disposition"`
+		Notes        string     `json:"notes"`
+	}
+
+	var input DischargeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	admission, err := h.admissionService.Discharge(userID, admissionID, input.DischargedAt, input.Disposition, input.Notes)
+	if err != nil {
+		writeAdmissionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Patient discharged", "admission": admission})
+}
+
+// writeAdmissionError reports a failed transfer or discharge.
+func writeAdmissionError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Admission not found"})
+	case err.Error() == "bed not found":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err.Error() == "patient has already been discharged":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"hospital/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AllergyInput struct {
+	Substance string `json:"substance"`
+	Reaction  string `json:"reaction"`
+	Severity  string `json:"severity"`
+	Status    string `json:"status"`
+}
+
+func (h *DoctorHandler) CreateAllergy(c *gin.Context) {
+	patientID, err := uuid.Parse(c.Param("patient_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid patient ID"})
+		return
+	}
+	doctorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var input AllergyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	allergy := models.PatientAllergy{
+		Substance: input.Substance,
+		Reaction:  input.Reaction,
+		Severity:  input.Severity,
+		Status:    input.Status,
+	}
+	if err := h.doctorService.CreateAllergy(doctorID, patientID, &allergy); err != nil {
+		if err.Error() == "patient not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "allergy recorded successfully", "allergy": allergy})
+}
+
+func (h *DoctorHandler) GetAllergiesByPatient(c *gin.Context) {
+	patientID, err := uuid.Parse(c.Param("patient_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid patient ID format"})
+		return
+	}
+	doctorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	allergies, err := h.doctorService.GetAllergiesByPatient(doctorID, patientID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "patient not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"allergies": allergies})
+}
+
+func (h *DoctorHandler) UpdateAllergy(c *gin.Context) {
+	allergyID, err := uuid.Parse(c.Param("allergy_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid allergy ID"})
+		return
+	}
+	doctorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var input AllergyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	allergy, err := h.doctorService.UpdateAllergy(doctorID, allergyID, &models.PatientAllergy{
+		Substance: input.Substance,
+		Reaction:  input.Reaction,
+		Severity:  input.Severity,
+		Status:    input.Status,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "allergy not found"})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "allergy updated successfully", "allergy": allergy})
+}
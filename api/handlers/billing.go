@@ -0,0 +1,418 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"hospital/internal/models"
+	"hospital/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Amounts in requests and responses are in minor currency units (cents).
+
+type BillingHandler struct {
+	billingService services.BillingService
+}
+
+func NewBillingHandler(billingService services.BillingService) *BillingHandler {
+	return &BillingHandler{
+		billingService: billingService,
+	}
+}
+
+type PriceInput struct {
+	Code            string     `json:"code" binding:"required"`
+	Name            string     `json:"name" binding:"required"`
+	ProcedureCode   string     `json:"procedure_code"`
+	AppointmentType string     `json:"appointment_type"` // Charged automatically for completed appointments of this type
+	DoctorID        *uuid.UUID `json:"doctor_id"`        // Makes the price this doctor's fee
+	UnitPrice       int64      `json:"unit_price"`
+	TaxRate         float64    `json:"tax_rate"` // Percent
+	Active          *bool      `json:"active"`   // Only used on update, defaults to true
+}
+
+func (input PriceInput) price() models.ServicePrice {
+	return models.ServicePrice{
+		Code:            input.Code,
+		Name:            input.Name,
+		ProcedureCode:   input.ProcedureCode,
+		AppointmentType: input.AppointmentType,
+		DoctorID:        input.DoctorID,
+		UnitPrice:       input.UnitPrice,
+		TaxRate:         input.TaxRate,
+		Active:          input.Active == nil || *input.Active,
+	}
+}
+
+func (h *BillingHandler) GetPrices(c *gin.Context) {
+	prices, err := h.billingService.GetPrices(c.Query("include_inactive") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve prices"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"prices": prices})
+}
+
+func (h *BillingHandler) CreatePrice(c *gin.Context) {
+	var input PriceInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	price := input.price()
+	if err := h.billingService.CreatePrice(&price); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Price created successfully", "price": price})
+}
+
+func (h *BillingHandler) UpdatePrice(c *gin.Context) {
+	priceID, err := uuid.Parse(c.Param("price_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid price ID format"})
+		return
+	}
+
+	var input PriceInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	update := input.price()
+	price, err := h.billingService.UpdatePrice(priceID, &update)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Price not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Price updated successfully", "price": price})
+}
+
+func (h *BillingHandler) GetCharges(c *gin.Context) {
+	patientID, err := uuid.Parse(c.Param("patient_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID format"})
+		return
+	}
+
+	charges, err := h.billingService.GetCharges(patientID, c.Query("unbilled") == "true")
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Patient not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve charges"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"charges": charges})
+}
+
+func (h *BillingHandler) CreateCharge(c *gin.Context) {
+	patientID, err := uuid.Parse(c.Param("patient_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID format"})
+		return
+	}
+	receptionistID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	type ChargeInput struct {
+		ServicePriceID *uuid.UUID `json:"service_price_id"` // Takes description, price and tax from the price list
+		AppointmentID  *uuid.UUID `json:"appointment_id"`
+		Description    string     `json:"description"`
+		ProcedureCode  string     `json:"procedure_code"`
+		Quantity       int        `json:"quantity"`
+		UnitPrice      int64      `json:"unit_price"`
+		TaxRate        float64    `json:"tax_rate"`
+	}
+
+	var input ChargeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	charge := models.Charge{
+		ServicePriceID: input.ServicePriceID,
+		AppointmentID:  input.AppointmentID,
+		Description:    input.Description,
+		ProcedureCode:  input.ProcedureCode,
+		Quantity:       input.Quantity,
+		UnitPrice:      input.UnitPrice,
+		TaxRate:        input.TaxRate,
+	}
+	if err := h.billingService.CreateCharge(receptionistID, patientID, &charge); err != nil {
+		if err.Error() == "patient not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Patient not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Charge created successfully", "charge": charge})
+}
+
+func (h *BillingHandler) DeleteCharge(c *gin.Context) {
+	patientID, err := uuid.Parse(c.Param("patient_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID format"})
+		return
+	}
+	chargeID, err := uuid.Parse(c.Param("charge_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid charge ID format"})
+		return
+	}
+	receptionistID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.billingService.DeleteCharge(receptionistID, patientID, chargeID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Charge not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Charge deleted successfully"})
+}
+
+func (h *BillingHandler) CreateInvoice(c *gin.Context) {
+	patientID, err := uuid.Parse(c.Param("patient_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID format"})
+		return
+	}
+	receptionistID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	type InvoiceInput struct {
+		ChargeIDs []uuid.UUID `json:"charge_ids"` // Defaults to all unbilled charges
+		Notes     string      `json:"notes"`
+	}
+
+	var input InvoiceInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	invoice, err := h.billingService.CreateInvoice(receptionistID, patientID, input.ChargeIDs, input.Notes)
+	if err != nil {
+		if err.Error() == "patient not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Patient not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Invoice issued successfully", "invoice": invoice})
+}
+
+func (h *BillingHandler) GetInvoices(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	filter := services.InvoiceFilter{Status: c.Query("status")}
+	if value := c.Query("patient_id"); value != "" {
+		patientID, err := uuid.Parse(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID format"})
+			return
+		}
+		filter.PatientID = &patientID
+	}
+
+	invoices, total, err := h.billingService.GetInvoices(filter, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve invoices"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"invoices": invoices,
+		"pagination": gin.H{
+			"current_page": page,
+			"total_pages":  (int(total) + limit - 1) / limit,
+			"total_count":  total,
+			"per_page":     limit,
+		},
+	})
+}
+
+func (h *BillingHandler) GetInvoice(c *gin.Context) {
+	invoiceID, err := uuid.Parse(c.Param("invoice_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invoice ID format"})
+		return
+	}
+
+	invoice, err := h.billingService.GetInvoice(invoiceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Invoice not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve invoice"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"invoice": invoice, "balance": invoice.Balance()})
+}
+
+func (h *BillingHandler) VoidInvoice(c *gin.Context) {
+	invoiceID, err := uuid.Parse(c.Param("invoice_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invoice ID format"})
+		return
+	}
+	receptionistID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	type VoidInput struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+
+	var input VoidInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	invoice, err := h.billingService.VoidInvoice(receptionistID, invoiceID, input.Reason)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Invoice not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Invoice voided", "invoice": invoice})
+}
+
+func (h *BillingHandler) RecordPayment(c *gin.Context) {
+	invoiceID, err := uuid.Parse(c.Param("invoice_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invoice ID format"})
+		return
+	}
+	receptionistID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	type PaymentInput struct {
+		Method            string     `json:"method" binding:"required"` // cash, card or insurance
+		Amount            int64      `json:"amount" binding:"required"`
+		Reference         string     `json:"reference"`
+		InsurancePolicyID *uuid.UUID `json:"insurance_policy_id"` // Required for insurance payments
+		ReceivedAt        *time.Time `json:"received_at"`         // Defaults to now
+	}
+
+	var input PaymentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	payment := models.Payment{
+		Method:            input.Method,
+		Amount:            input.Amount,
+		Reference:         input.Reference,
+		InsurancePolicyID: input.InsurancePolicyID,
+	}
+	if input.ReceivedAt != nil {
+		payment.ReceivedAt = *input.ReceivedAt
+	}
+
+	invoice, err := h.billingService.RecordPayment(receptionistID, invoiceID, &payment)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Invoice not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Payment recorded successfully",
+		"payment": payment,
+		"invoice": invoice,
+		"balance": invoice.Balance(),
+	})
+}
+
+func (h *BillingHandler) RefundPayment(c *gin.Context) {
+	paymentID, err := uuid.Parse(c.Param("payment_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payment ID format"})
+		return
+	}
+	receptionistID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	type RefundInput struct {
+		Amount int64  `json:"amount" binding:"required"`
+		Reason string `json:"reason" binding:"required"`
+	}
+
+	var input RefundInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	invoice, err := h.billingService.RefundPayment(receptionistID, paymentID, input.Amount, input.Reason)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Payment not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Refund recorded successfully",
+		"invoice": invoice,
+		"balance": invoice.Balance(),
+	})
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"hospital/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func (h *ReceptionistHandler) GetCareTeam(c *gin.Context) {
+	patientID, err := uuid.Parse(c.Param("patient_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID format"})
+		return
+	}
+
+	members, err := h.receptionistService.GetCareTeam(patientID, c.Query("include_ended") == "true")
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Patient not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve care team"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"care_team": members})
+}
+
+func (h *ReceptionistHandler) AddCareTeamMember(c *gin.Context) {
+	patientID, err := uuid.Parse(c.Param("patient_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID format"})
+		return
+	}
+	receptionistID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	type CareTeamMemberInput struct {
+		DoctorID      uuid.UUID  `json:"doctor_id" binding:"required"`
+		Role          string     `json:"role" binding:"required"`
+		EffectiveFrom *time.Time `json:"effective_from"` // Defaults to now
+		EffectiveTo   *time.Time `json:"effective_to"`
+		Notes         string     `json:"notes"`
+	}
+
+	var input CareTeamMemberInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	member := models.CareTeamMember{
+		DoctorID:    input.DoctorID,
+		Role:        input.Role,
+		EffectiveTo: input.EffectiveTo,
+		Notes:       input.Notes,
+	}
+	if input.EffectiveFrom != nil {
+		member.EffectiveFrom = *input.EffectiveFrom
+	}
+
+	if err := h.receptionistService.AddCareTeamMember(receptionistID, patientID, &member); err != nil {
+		if err.Error() == "patient not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Care team member added", "member": member})
+}
+
+func (h *ReceptionistHandler) EndCareTeamMember(c *gin.Context) {
+	patientID, err := uuid.Parse(c.Param("patient_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID format"})
+		return
+	}
+	memberID, err := uuid.Parse(c.Param("member_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid member ID format"})
+		return
+	}
+	receptionistID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	type EndInput struct {
+		EffectiveTo *time.Time `json:"effective_to"` // Defaults to now
+	}
+
+	// The body is optional, the membership ends now by default
+	var input EndInput
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+	endAt := time.Now()
+	if input.EffectiveTo != nil {
+		endAt = *input.EffectiveTo
+	}
+
+	member, err := h.receptionistService.EndCareTeamMember(receptionistID, patientID, memberID, endAt)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Care team member not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Care team membership ended", "member": member})
+}
@@ -0,0 +1,279 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"hospital/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ClaimHandler struct {
+	claimService services.ClaimService
+}
+
+func NewClaimHandler(claimService services.ClaimService) *ClaimHandler {
+	return &ClaimHandler{
+		claimService: claimService,
+	}
+}
+
+func (h *ClaimHandler) CreateClaim(c *gin.Context) {
+	appointmentID, err := uuid.Parse(c.Param("appointment_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid appointment ID format"})
+		return
+	}
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	type ClaimInput struct {
+		InsurancePolicyID *uuid.UUID `json:"insurance_policy_id"` // Defaults to the primary policy on the encounter date
+	}
+
+	var input ClaimInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	claim, err := h.claimService.CreateClaim(userID, appointmentID, input.InsurancePolicyID)
+	if err != nil {
+		if err.Error() == "appointment not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Appointment not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Claim drafted successfully", "claim": claim})
+}
+
+func (h *ClaimHandler) GetClaims(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	filter := services.ClaimFilter{
+		Status:        c.Query("status"),
+		NeedsFollowUp: c.Query("follow_up") == "true",
+	}
+	if value := c.Query("patient_id"); value != "" {
+		patientID, err := uuid.Parse(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID format"})
+			return
+		}
+		filter.PatientID = &patientID
+	}
+
+	claims, total, err := h.claimService.GetClaims(filter, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve claims"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"claims": claims,
+		"pagination": gin.H{
+			"current_page": page,
+			"total_pages":  (int(total) + limit - 1) / limit,
+			"total_count":  total,
+			"per_page":     limit,
+		},
+	})
+}
+
+func (h *ClaimHandler) GetClaim(c *gin.Context) {
+	claimID, err := uuid.Parse(c.Param("claim_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid claim ID format"})
+		return
+	}
+
+	claim, err := h.claimService.GetClaim(claimID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Claim not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve claim"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"claim": claim})
+}
+
+func (h *ClaimHandler) DeleteClaim(c *gin.Context) {
+	claimID, err := uuid.Parse(c.Param("claim_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid claim ID format"})
+		return
+	}
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.claimService.DeleteClaim(userID, claimID); err != nil {
+		writeClaimError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Claim deleted successfully"})
+}
+
+func (h *ClaimHandler) SubmitClaim(c *gin.Context) {
+	claimID, err := uuid.Parse(c.Param("claim_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid claim ID format"})
+		return
+	}
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	claim, err := h.claimService.SubmitClaim(userID, claimID)
+	if err != nil {
+		writeClaimError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Claim submitted", "claim": claim})
+}
+
+func (h *ClaimHandler) RecordClaimResponse(c *gin.Context) {
+	claimID, err := uuid.Parse(c.Param("claim_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid claim ID format"})
+		return
+	}
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	type ClaimResponseInput struct {
+		Status string `json:"status" binding:"required,oneof=accepted denied"`
+		Reason string `json:"reason"` // Required for denials
+	}
+
+	var input ClaimResponseInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	claim, err := h.claimService.RecordClaimResponse(userID, claimID, input.Status, input.Reason)
+	if err != nil {
+		writeClaimError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Claim " + input.Status, "claim": claim})
+}
+
+func (h *ClaimHandler) ResolveFollowUp(c *gin.Context) {
+	claimID, err := uuid.Parse(c.Param("claim_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid claim ID format"})
+		return
+	}
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	type ResolveInput struct {
+		Note string `json:"note"`
+	}
+
+	var input ResolveInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	claim, err := h.claimService.ResolveFollowUp(userID, claimID, input.Note)
+	if err != nil {
+		writeClaimError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Follow-up resolved", "claim": claim})
+}
+
+// ImportRemittance takes a remittance CSV as the multipart field "file" and
+// returns the batch with the outcome of every row.
+func (h *ClaimHandler) ImportRemittance(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A remittance file is required", "details": err.Error()})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	batch, err := h.claimService.ImportRemittance(userID, fileHeader.Filename, file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to import remittance", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Remittance imported", "remittance": batch})
+}
+
+func (h *ClaimHandler) GetRemittance(c *gin.Context) {
+	batchID, err := uuid.Parse(c.Param("batch_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid remittance ID format"})
+		return
+	}
+
+	batch, err := h.claimService.GetRemittance(batchID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Remittance not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve remittance"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"remittance": batch})
+}
+
+// writeClaimError reports a failed claim update. Most failures are the claim
+// being in the wrong status for the change, which is a conflict.
+func writeClaimError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Claim not found"})
+	case err.Error() == "a reason is required to deny a claim":
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"hospital/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ContactInput is the request body for creating or replacing a patient contact.
+type ContactInput struct {
+	Name                   string `json:"name" binding:"required"`
+	Relationship           string `json:"relationship" binding:"required"`
+	Phone                  string `json:"phone"`
+	Email                  string `json:"email"`
+	Priority               int    `json:"priority"`
+	MayReceiveClinicalInfo bool   `json:"may_receive_clinical_info"`
+	LegalGuardian          bool   `json:"legal_guardian"`
+	Notes                  string `json:"notes"`
+}
+
+func (input ContactInput) contact() models.PatientContact {
+	return models.PatientContact{
+		Name:                   input.Name,
+		Relationship:           input.Relationship,
+		Phone:                  input.Phone,
+		Email:                  input.Email,
+		Priority:               input.Priority,
+		MayReceiveClinicalInfo: input.MayReceiveClinicalInfo,
+		LegalGuardian:          input.LegalGuardian,
+		Notes:                  input.Notes,
+	}
+}
+
+func (h *ReceptionistHandler) GetContacts(c *gin.Context) {
+	patientID, err := uuid.Parse(c.Param("patient_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID format"})
+		return
+	}
+
+	contacts, err := h.receptionistService.GetContacts(patientID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Patient not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve contacts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"contacts": contacts})
+}
+
+func (h *ReceptionistHandler) CreateContact(c *gin.Context) {
+	patientID, err := uuid.Parse(c.Param("patient_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID format"})
+		return
+	}
+
+	var input ContactInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	contact := input.contact()
+	if err := h.receptionistService.CreateContact(patientID, &contact); err != nil {
+		if err.Error() == "patient not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Patient not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Contact created successfully", "contact": contact})
+}
+
+func (h *ReceptionistHandler) UpdateContact(c *gin.Context) {
+	patientID, err := uuid.Parse(c.Param("patient_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID format"})
+		return
+	}
+	contactID, err := uuid.Parse(c.Param("contact_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact ID format"})
+		return
+	}
+
+	var input ContactInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	update := input.contact()
+	contact, err := h.receptionistService.UpdateContact(patientID, contactID, &update)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Contact not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Contact updated successfully", "contact": contact})
+}
+
+func (h *ReceptionistHandler) DeleteContact(c *gin.Context) {
+	patientID, err := uuid.Parse(c.Param("patient_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID format"})
+		return
+	}
+	contactID, err := uuid.Parse(c.Param("contact_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact ID format"})
+		return
+	}
+
+	if err := h.receptionistService.DeleteContact(patientID, contactID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Contact not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete contact"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Contact deleted successfully"})
+}
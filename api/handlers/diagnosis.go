@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"hospital/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// currentUserID returns the authenticated user's ID set by the auth middleware.
+// On failure it writes the error response and returns false.
+func currentUserID(c *gin.Context) (uuid.UUID, bool) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return uuid.Nil, false
+	}
+	id, err := uuid.Parse(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "error parsing user ID"})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func (h *DoctorHandler) SearchICD10Codes(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	codes, err := h.doctorService.SearchICD10Codes(c.Query("q"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"codes": codes})
+}
+
+func (h *DoctorHandler) CreateDiagnosis(c *gin.Context) {
+	patientID, err := uuid.Parse(c.Param("patient_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid patient ID"})
+		return
+	}
+	doctorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	type DiagnosisInput struct {
+		Code          string     `json:"code" binding:"required"`
+		AppointmentID *uuid.UUID `json:"appointment_id"`
+		Status        string     `json:"status"`
+		OnsetDate     string     `json:"onset_date"` // YYYY-MM-DD
+		Notes         string     `json:"notes"`
+	}
+
+	var input DiagnosisInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	diagnosis := models.Diagnosis{
+		Code:          input.Code,
+		AppointmentID: input.AppointmentID,
+		Status:        input.Status,
+		Notes:         input.Notes,
+	}
+	if input.OnsetDate != "" {
+		onset, err := time.Parse("2006-01-02", input.OnsetDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid onset_date format (expected: YYYY-MM-DD)"})
+			return
+		}
+		diagnosis.OnsetDate = &onset
+	}
+
+	if err := h.doctorService.CreateDiagnosis(doctorID, patientID, &diagnosis); err != nil {
+		writeDiagnosisError(c, err, "patient not found")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "diagnosis recorded successfully", "diagnosis": diagnosis})
+}
+
+func (h *DoctorHandler) GetDiagnosesByPatient(c *gin.Context) {
+	patientID, err := uuid.Parse(c.Param("patient_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid patient ID format"})
+		return
+	}
+	doctorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	diagnoses, err := h.doctorService.GetDiagnosesByPatient(doctorID, patientID, c.Query("status"))
+	if err != nil {
+		writeDiagnosisError(c, err, "patient not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"diagnoses": diagnoses})
+}
+
+func (h *DoctorHandler) UpdateDiagnosis(c *gin.Context) {
+	diagnosisID, err := uuid.Parse(c.Param("diagnosis_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid diagnosis ID"})
+		return
+	}
+	doctorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	type DiagnosisUpdateInput struct {
+		Status string `json:"status"`
+		Notes  string `json:"notes"`
+	}
+
+	var input DiagnosisUpdateInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	diagnosis, err := h.doctorService.UpdateDiagnosis(doctorID, diagnosisID, input.Status, input.Notes)
+	if err != nil {
+		writeDiagnosisError(c, err, "diagnosis not found")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "diagnosis updated successfully", "diagnosis": diagnosis})
+}
+
+// writeDiagnosisError reports a failed diagnosis request. notFound is the
+// message for gorm.ErrRecordNotFound. Rejected input is a bad request, and
+// anything else, such as a database failure, a server error.
+func writeDiagnosisError(c *gin.Context, err error, notFound string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": notFound})
+	case err.Error() == "patient not found", err.Error() == "appointment not found for this patient":
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err.Error() == "unknown ICD-10 code", strings.HasPrefix(err.Error(), "status must be one of"):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
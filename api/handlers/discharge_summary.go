@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"hospital/internal/fhir"
+	"hospital/internal/models"
+	"hospital/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DischargeSummaryHandler lets the attending doctor write and sign the
+// discharge summary of an admission. Other doctors with access to the patient
+// can read it.
+type DischargeSummaryHandler struct {
+	dischargeSummaryService services.DischargeSummaryService
+	documentService         services.DocumentService
+	doctorService           services.DoctorService
+}
+
+func NewDischargeSummaryHandler(dischargeSummaryService services.DischargeSummaryService, documentService services.DocumentService, doctorService services.DoctorService) *DischargeSummaryHandler {
+	return &DischargeSummaryHandler{
+		dischargeSummaryService: dischargeSummaryService,
+		documentService:         documentService,
+		doctorService:           doctorService,
+	}
+}
+
+func (h *DischargeSummaryHandler) CreateDischargeSummary(c *gin.Context) {
+	admissionID, err := uuid.Parse(c.Param("admission_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid admission ID format"})
+		return
+	}
+	doctorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	summary, err := h.dischargeSummaryService.CreateDischargeSummary(doctorID, admissionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Admission not found"})
+			return
+		}
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Discharge summary drafted", "discharge_summary": summary})
+}
+
+func (h *DischargeSummaryHandler) GetDischargeSummary(c *gin.Context) {
+	summary, ok := h.readableSummary(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"discharge_summary": summary})
+}
+
+func (h *DischargeSummaryHandler) UpdateDischargeSummary(c *gin.Context) {
+	admissionID, err := uuid.Parse(c.Param("admission_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid admission ID format"})
+		return
+	}
+	doctorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	// The whole content is replaced, so send back every list as last read
+	type DischargeSummaryInput struct {
+		AdmissionReason      string                     `json:"admission_reason"`
+		HospitalCourse       string                     `json:"hospital_course"`
+		DischargeCondition   string                     `json:"discharge_condition"`
+		Instructions         string                     `json:"instructions"`
+		Diagnoses            []models.SummaryDiagnosis  `json:"diagnoses"`
+		Procedures           []models.SummaryProcedure  `json:"procedures"`
+		MedicationsGiven     []models.SummaryMedication `json:"medications_given"`
+		DischargeMedications []models.SummaryMedication `json:"discharge_medications"`
+		FollowUps            []models.SummaryFollowUp   `json:"follow_ups"` // Booked when the summary is signed
+	}
+
+	var input DischargeSummaryInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	summary, err := h.dischargeSummaryService.UpdateDischargeSummary(doctorID, admissionID, &models.DischargeSummary{
+		AdmissionReason:      input.AdmissionReason,
+		HospitalCourse:       input.HospitalCourse,
+		DischargeCondition:   input.DischargeCondition,
+		Instructions:         input.Instructions,
+		Diagnoses:            input.Diagnoses,
+		Procedures:           input.Procedures,
+		MedicationsGiven:     input.MedicationsGiven,
+		DischargeMedications: input.DischargeMedications,
+		FollowUps:            input.FollowUps,
+	})
+	if err != nil {
+		writeDischargeSummaryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Discharge summary updated", "discharge_summary": summary})
+}
+
+func (h *DischargeSummaryHandler) SignDischargeSummary(c *gin.Context) {
+	admissionID, err := uuid.Parse(c.Param("admission_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid admission ID format"})
+		return
+	}
+	doctorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	summary, err := h.dischargeSummaryService.SignDischargeSummary(doctorID, admissionID)
+	if err != nil {
+		writeDischargeSummaryError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Discharge summary signed", "discharge_summary": summary})
+}
+
+func (h *DischargeSummaryHandler) GetDischargeSummaryPDF(c *gin.Context) {
+	summary, ok := h.readableSummary(c)
+	if !ok {
+		return
+	}
+
+	pdf, err := h.documentService.DischargeSummaryPDF(summary.AdmissionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	writePDF(c, "discharge-summary-"+summary.AdmissionID.String()+".pdf", pdf)
+}
+
+// GetDischargeSummaryFHIR returns the summary as a FHIR Composition.
+func (h *DischargeSummaryHandler) GetDischargeSummaryFHIR(c *gin.Context) {
+	summary, ok := h.readableSummary(c)
+	if !ok {
+		return
+	}
+
+	body, err := json.Marshal(fhir.DischargeComposition(summary))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, fhir.ContentType, body)
+}
+
+// readableSummary loads the summary of the admission in the path if the
+// doctor wrote it or may access the patient, and writes the error response
+// otherwise.
+func (h *DischargeSummaryHandler) readableSummary(c *gin.Context) (*models.DischargeSummary, bool) {
+	admissionID, err := uuid.Parse(c.Param("admission_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid admission ID format"})
+		return nil, false
+	}
+	doctorID, ok := currentUserID(c)
+	if !ok {
+		return nil, false
+	}
+
+	summary, err := h.dischargeSummaryService.GetDischargeSummary(admissionID)
+	if err == nil && summary.DoctorID != doctorID {
+		err = h.doctorService.AuthorizePatient(doctorID, summary.PatientID)
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Discharge summary not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve discharge summary"})
+		}
+		return nil, false
+	}
+	return summary, true
+}
+
+// writeDischargeSummaryError reports a failed update or signature. Changes to
+// a signed summary are conflicts; anything else wrong with the content is a
+// bad request.
+func writeDischargeSummaryError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Discharge summary not found"})
+	case err.Error() == "a signed discharge summary cannot be changed":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"errors"
-	"fmt"
 	"hospital/internal/models"
 	"hospital/internal/services"
 	"net/http"
@@ -14,12 +13,14 @@ import (
 )
 
 type DoctorHandler struct {
-	doctorService services.DoctorService
+	doctorService   services.DoctorService
+	documentService services.DocumentService
 }
 
-func NewDoctorHandler(doctorService services.DoctorService) *DoctorHandler {
+func NewDoctorHandler(doctorService services.DoctorService, documentService services.DocumentService) *DoctorHandler {
 	return &DoctorHandler{
-		doctorService: doctorService,
+		doctorService:   doctorService,
+		documentService: documentService,
 	}
 }
 
@@ -57,6 +58,25 @@ func (h *DoctorHandler) GetPatientByID(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"patient": patient})
 }
 
+func (h *DoctorHandler) GetPatientByMRN(c *gin.Context) {
+	doctorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	patient, err := h.doctorService.GetPatientByMRN(doctorID, c.Param("mrn"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "patient not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"patient": patient})
+}
+
 func (h *DoctorHandler) GetPatients(c *gin.Context) {
 	doctorID, exists := c.Get("user_id")
 
@@ -106,48 +126,17 @@ func (h *DoctorHandler) CreatePrescription(c *gin.Context) {
 	prescription.DoctorID = doctorId
 
 	if err := h.doctorService.CreatePrescription(patientID, &prescription); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if err.Error() == "patient not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		writePrescriptionError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{"message": "prescription created successfully", "prescription": prescription})
 }
 
-func (h *DoctorHandler) UpdatePrescription(c *gin.Context) {
-	patientIDStr := c.Param("patient_id")
-	patientID, err := uuid.Parse(patientIDStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid patient ID"})
-		return
-	}
-
-	doctorID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
-		return
-	}
-	doctorId, err := uuid.Parse(doctorID.(string))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "error parsing doctor ID"})
-		return
-	}
-
-	var prescription models.Prescription
-	if err := c.ShouldBindJSON(&prescription); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	prescription.DoctorID = doctorId
-	fmt.Println("this is line 106 prescription", prescription)
-	if err := h.doctorService.UpdatePrescription(patientID, &prescription); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "prescription updated successfully"})
-}
-
 func (h *DoctorHandler) GetAppointments(c *gin.Context) {
 	doctorID, exists := c.Get("user_id")
 	if !exists {
@@ -193,7 +182,7 @@ func (h *DoctorHandler) GetPrescriptionsByPatient(c *gin.Context) {
 	prescriptions, err := h.doctorService.GetPrescriptionsByPatient(id, patientID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "no prescriptions found for this patient"})
+			c.JSON(http.StatusNotFound, gin.H{"error": "patient not found"})
 		} else {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		}
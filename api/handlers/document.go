@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// writePDF sends a rendered document inline so browsers open their PDF viewer.
+func writePDF(c *gin.Context, filename string, pdf []byte) {
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", filename))
+	c.Data(http.StatusOK, "application/pdf", pdf)
+}
+
+func (h *DoctorHandler) GetPrescriptionPDF(c *gin.Context) {
+	prescriptionID, err := uuid.Parse(c.Param("prescription_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid prescription ID"})
+		return
+	}
+	doctorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if _, err := h.doctorService.GetPrescription(doctorID, prescriptionID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "prescription not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	pdf, err := h.documentService.PrescriptionPDF(prescriptionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	writePDF(c, "prescription-"+prescriptionID.String()+".pdf", pdf)
+}
+
+func (h *DoctorHandler) GetEncounterSummaryPDF(c *gin.Context) {
+	appointmentID, err := uuid.Parse(c.Param("appointment_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid appointment ID"})
+		return
+	}
+	doctorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	if _, err := h.doctorService.GetAppointment(doctorID, appointmentID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "appointment not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	pdf, err := h.documentService.EncounterSummaryPDF(appointmentID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	writePDF(c, "visit-summary-"+appointmentID.String()+".pdf", pdf)
+}
+
+// Receptionists reprint documents at the front desk without a patient ownership check.
+
+func (h *ReceptionistHandler) GetPrescriptionPDF(c *gin.Context) {
+	prescriptionID, err := uuid.Parse(c.Param("prescription_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid prescription ID format"})
+		return
+	}
+
+	pdf, err := h.documentService.PrescriptionPDF(prescriptionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Prescription not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate prescription"})
+		}
+		return
+	}
+	writePDF(c, "prescription-"+prescriptionID.String()+".pdf", pdf)
+}
+
+func (h *ReceptionistHandler) GetEncounterSummaryPDF(c *gin.Context) {
+	appointmentID, err := uuid.Parse(c.Param("appointment_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid appointment ID format"})
+		return
+	}
+
+	pdf, err := h.documentService.EncounterSummaryPDF(appointmentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Appointment not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate visit summary"})
+		}
+		return
+	}
+	writePDF(c, "visit-summary-"+appointmentID.String()+".pdf", pdf)
+}
+
+func (h *ReceptionistHandler) GetDischargeSummaryPDF(c *gin.Context) {
+	admissionID, err := uuid.Parse(c.Param("admission_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid admission ID format"})
+		return
+	}
+
+	pdf, err := h.documentService.DischargeSummaryPDF(admissionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Discharge summary not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate discharge summary"})
+		}
+		return
+	}
+	writePDF(c, "discharge-summary-"+admissionID.String()+".pdf", pdf)
+}
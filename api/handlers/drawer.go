@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"hospital/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type DrawerHandler struct {
+	drawerService services.DrawerService
+}
+
+func NewDrawerHandler(drawerService services.DrawerService) *DrawerHandler {
+	return &DrawerHandler{
+		drawerService: drawerService,
+	}
+}
+
+// GetOpenDrawer shows what the current receptionist has taken since their
+// last close.
+func (h *DrawerHandler) GetOpenDrawer(c *gin.Context) {
+	receptionistID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	summary, err := h.drawerService.GetOpenDrawer(receptionistID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve drawer"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"drawer": summary})
+}
+
+func (h *DrawerHandler) CloseDrawer(c *gin.Context) {
+	receptionistID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	type CloseInput struct {
+		OpeningFloat int64  `json:"opening_float"`
+		CountedCash  int64  `json:"counted_cash"`
+		Notes        string `json:"notes"`
+	}
+
+	var input CloseInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	closing, err := h.drawerService.CloseDrawer(receptionistID, input.OpeningFloat, input.CountedCash, input.Notes)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Drawer closed", "close": closing})
+}
+
+func (h *DrawerHandler) GetDrawerCloses(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	var receptionistID *uuid.UUID
+	if value := c.Query("receptionist_id"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid receptionist ID format"})
+			return
+		}
+		receptionistID = &id
+	}
+
+	closes, total, err := h.drawerService.GetDrawerCloses(receptionistID, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve drawer closes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"closes": closes,
+		"pagination": gin.H{
+			"current_page": page,
+			"total_pages":  (int(total) + limit - 1) / limit,
+			"total_count":  total,
+			"per_page":     limit,
+		},
+	})
+}
+
+func (h *DrawerHandler) GetDrawerClose(c *gin.Context) {
+	closeID, err := uuid.Parse(c.Param("close_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid drawer close ID format"})
+		return
+	}
+
+	closing, err := h.drawerService.GetDrawerClose(closeID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Drawer close not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve drawer close"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"close": closing})
+}
@@ -0,0 +1,526 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"hospital/internal/fhir"
+	"hospital/internal/models"
+	"hospital/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	fhirDefaultCount = 20
+	fhirMaxCount     = 100
+)
+
+// FHIRHandler serves the FHIR R4 API. Responses are FHIR JSON and errors are
+// OperationOutcomes.
+type FHIRHandler struct {
+	fhirService   services.FHIRService
+	exportService services.FHIRExportService
+	// Absolute URL of the FHIR API, taken from the request when not configured
+	baseURL   string
+	publisher string
+}
+
+func NewFHIRHandler(fhirService services.FHIRService, exportService services.FHIRExportService, baseURL, publisher string) *FHIRHandler {
+	return &FHIRHandler{
+		fhirService:   fhirService,
+		exportService: exportService,
+		baseURL:       baseURL,
+		publisher:     publisher,
+	}
+}
+
+// Metadata serves the CapabilityStatement. It needs no login.
+func (h *FHIRHandler) Metadata(c *gin.Context) {
+	writeFHIR(c, http.StatusOK, fhir.NewCapabilityStatement(h.base(c), h.publisher, time.Now()))
+}
+
+func (h *FHIRHandler) GetPatient(c *gin.Context) {
+	fhirRead(c, "Patient", h.fhirService.GetPatient, func(patient *models.Patient) fhir.Resource { return fhir.PatientResource(patient) })
+}
+
+func (h *FHIRHandler) SearchPatients(c *gin.Context) {
+	fhirSearch(h, c, "Patient", h.fhirService.SearchPatients, func(patient *models.Patient) fhir.Resource { return fhir.PatientResource(patient) })
+}
+
+// CreatePatient registers a patient. With If-None-Exist, an identifier search,
+// a patient already registered with the identifier is returned instead.
+func (h *FHIRHandler) CreatePatient(c *gin.Context) {
+	var resource fhir.Patient
+	fhirWrite(h, c, &resource, "", func(requester services.FHIRRequester, _ uuid.UUID, _ *int) (*services.FHIRWriteResult, error) {
+		return h.fhirService.CreatePatient(requester, &resource, c.GetHeader("If-None-Exist"))
+	})
+}
+
+func (h *FHIRHandler) UpdatePatient(c *gin.Context) {
+	var resource fhir.Patient
+	fhirWrite(h, c, &resource, "Patient", func(requester services.FHIRRequester, id uuid.UUID, ifMatch *int) (*services.FHIRWriteResult, error) {
+		if resource.ID != id.String() {
+			return nil, fhir.Invalid("Patient.id", "must be the id in the URL")
+		}
+		return h.fhirService.UpdatePatient(requester, id, &resource, ifMatch)
+	})
+}
+
+func (h *FHIRHandler) GetPractitioner(c *gin.Context) {
+	fhirRead(c, "Practitioner", h.fhirService.GetPractitioner, func(doctor *models.User) fhir.Resource { return fhir.PractitionerResource(doctor) })
+}
+
+func (h *FHIRHandler) SearchPractitioners(c *gin.Context) {
+	fhirSearch(h, c, "Practitioner", h.fhirService.SearchPractitioners, func(doctor *models.User) fhir.Resource { return fhir.PractitionerResource(doctor) })
+}
+
+func (h *FHIRHandler) GetAppointment(c *gin.Context) {
+	fhirRead(c, "Appointment", h.fhirService.GetAppointment, func(appointment *models.Appointment) fhir.Resource {
+		return fhir.AppointmentResource(appointment)
+	})
+}
+
+func (h *FHIRHandler) SearchAppointments(c *gin.Context) {
+	fhirSearch(h, c, "Appointment", h.fhirService.SearchAppointments, func(appointment *models.Appointment) fhir.Resource {
+		return fhir.AppointmentResource(appointment)
+	})
+}
+
+func (h *FHIRHandler) CreateAppointment(c *gin.Context) {
+	var resource fhir.Appointment
+	fhirWrite(h, c, &resource, "", func(requester services.FHIRRequester, _ uuid.UUID, _ *int) (*services.FHIRWriteResult, error) {
+		return h.fhirService.CreateAppointment(requester, &resource)
+	})
+}
+
+func (h *FHIRHandler) UpdateAppointment(c *gin.Context) {
+	var resource fhir.Appointment
+	fhirWrite(h, c, &resource, "Appointment", func(requester services.FHIRRequester, id uuid.UUID, ifMatch *int) (*services.FHIRWriteResult, error) {
+		if resource.ID != id.String() {
+			return nil, fhir.Invalid("Appointment.id", "must be the id in the URL")
+		}
+		return h.fhirService.UpdateAppointment(requester, id, &resource, ifMatch)
+	})
+}
+
+func (h *FHIRHandler) GetMedicationRequest(c *gin.Context) {
+	fhirRead(c, "MedicationRequest", h.fhirService.GetMedicationRequest, func(prescription *models.Prescription) fhir.Resource {
+		return fhir.MedicationRequestResource(prescription)
+	})
+}
+
+func (h *FHIRHandler) SearchMedicationRequests(c *gin.Context) {
+	fhirSearch(h, c, "MedicationRequest", h.fhirService.SearchMedicationRequests, func(prescription *models.Prescription) fhir.Resource {
+		return fhir.MedicationRequestResource(prescription)
+	})
+}
+
+// Transaction applies a transaction Bundle posted to the base URL, all of it
+// or nothing, and answers with a transaction-response Bundle.
+func (h *FHIRHandler) Transaction(c *gin.Context) {
+	requester, ok := fhirRequester(c)
+	if !ok {
+		return
+	}
+	var bundle fhir.TransactionBundle
+	if err := json.NewDecoder(c.Request.Body).Decode(&bundle); err != nil {
+		writeOutcome(c, http.StatusBadRequest, "structure", "invalid Bundle: "+err.Error())
+		return
+	}
+
+	results, err := h.fhirService.Transaction(requester, &bundle)
+	if err != nil {
+		writeFHIRError(c, err, err.Error())
+		return
+	}
+
+	base := h.base(c)
+	response := fhir.Bundle{
+		ResourceType: "Bundle",
+		Meta:         &fhir.Meta{LastUpdated: time.Now().UTC().Format(time.RFC3339)},
+		Type:         "transaction-response",
+	}
+	for _, result := range results {
+		status := "200 OK"
+		if result.Created {
+			status = "201 Created"
+		}
+		response.Entry = append(response.Entry, fhir.BundleEntry{
+			FullURL:  base + "/" + result.Resource.Path(),
+			Resource: result.Resource,
+			Response: &fhir.BundleEntryResponse{
+				Status:       status,
+				Location:     versionLocation(base, result.Resource),
+				Etag:         fhir.ETag(result.Resource.VersionID()),
+				LastModified: result.LastModified.UTC().Format(time.RFC3339),
+			},
+		})
+	}
+	writeFHIR(c, http.StatusOK, response)
+}
+
+// fhirWrite serves the create interaction, or the update interaction when
+// resourceType is set, for one resource type. The resource is read from the
+// body before write is called with the ID from the URL and the If-Match
+// version.
+func fhirWrite(h *FHIRHandler, c *gin.Context, resource any, resourceType string,
+	write func(services.FHIRRequester, uuid.UUID, *int) (*services.FHIRWriteResult, error)) {
+	requester, ok := fhirRequester(c)
+	if !ok {
+		return
+	}
+
+	var id uuid.UUID
+	var ifMatch *int
+	if resourceType != "" {
+		var err error
+		if id, err = uuid.Parse(c.Param("id")); err != nil {
+			writeOutcome(c, http.StatusNotFound, "not-found", fmt.Sprintf("%s/%s is not known", resourceType, c.Param("id")))
+			return
+		}
+		if value := c.GetHeader("If-Match"); value != "" {
+			version, err := fhir.ParseETag(value)
+			if err != nil {
+				writeOutcome(c, http.StatusBadRequest, "invalid", "If-Match: "+err.Error())
+				return
+			}
+			ifMatch = &version
+		}
+	}
+	if err := json.NewDecoder(c.Request.Body).Decode(resource); err != nil {
+		writeOutcome(c, http.StatusBadRequest, "structure", "invalid resource: "+err.Error())
+		return
+	}
+
+	result, err := write(requester, id, ifMatch)
+	if err != nil {
+		writeFHIRError(c, err, fmt.Sprintf("%s/%s is not known", resourceType, id))
+		return
+	}
+	status := http.StatusOK
+	if result.Created {
+		status = http.StatusCreated
+	}
+	c.Header("Location", versionLocation(h.base(c), result.Resource))
+	c.Header("ETag", fhir.ETag(result.Resource.VersionID()))
+	c.Header("Last-Modified", result.LastModified.UTC().Format(http.TimeFormat))
+	writeFHIR(c, status, result.Resource)
+}
+
+// versionLocation is the absolute URL of the version of a resource.
+func versionLocation(base string, resource fhir.Resource) string {
+	return base + "/" + resource.Path() + "/_history/" + resource.VersionID()
+}
+
+// fhirRead serves the read interaction for one resource type, and the vread
+// interaction when the URL names a version. Only the current version of a
+// resource is kept, so earlier versions are not found.
+func fhirRead[T any](c *gin.Context, resourceType string,
+	get func(services.FHIRRequester, uuid.UUID) (*T, error), resource func(*T) fhir.Resource) {
+	requester, ok := fhirRequester(c)
+	if !ok {
+		return
+	}
+	// FHIR IDs are any string, so an ID that is not a UUID is simply unknown
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		writeOutcome(c, http.StatusNotFound, "not-found", fmt.Sprintf("%s/%s is not known", resourceType, c.Param("id")))
+		return
+	}
+
+	record, err := get(requester, id)
+	if err != nil {
+		writeFHIRError(c, err, fmt.Sprintf("%s/%s is not known", resourceType, id))
+		return
+	}
+	result := resource(record)
+	if vid := c.Param("vid"); vid != "" && vid != result.VersionID() {
+		writeOutcome(c, http.StatusNotFound, "not-found", fmt.Sprintf("version %s of %s/%s is not kept", vid, resourceType, id))
+		return
+	}
+	if result.VersionID() != "" {
+		c.Header("ETag", fhir.ETag(result.VersionID()))
+	}
+	writeFHIR(c, http.StatusOK, result)
+}
+
+// fhirSearch serves the search-type interaction for one resource type as a
+// searchset Bundle with paging links.
+func fhirSearch[T any](h *FHIRHandler, c *gin.Context, resourceType string,
+	search func(services.FHIRRequester, services.FHIRSearch) ([]T, int64, error), resource func(*T) fhir.Resource) {
+	requester, ok := fhirRequester(c)
+	if !ok {
+		return
+	}
+	params, applied, err := parseFHIRSearch(c, resourceType)
+	if err != nil {
+		writeOutcome(c, http.StatusBadRequest, "invalid", err.Error())
+		return
+	}
+
+	records, total, err := search(requester, params)
+	if err != nil {
+		writeFHIRError(c, err, "")
+		return
+	}
+
+	base := h.base(c)
+	bundle := fhir.Bundle{
+		ResourceType: "Bundle",
+		Meta:         &fhir.Meta{LastUpdated: time.Now().UTC().Format(time.RFC3339)},
+		Type:         "searchset",
+		Total:        &total,
+		Link:         searchLinks(base+"/"+resourceType, applied, params, total),
+	}
+	for i := range records {
+		entry := resource(&records[i])
+		bundle.Entry = append(bundle.Entry, fhir.BundleEntry{
+			FullURL:  base + "/" + entry.Path(),
+			Resource: entry,
+			Search:   &fhir.BundleEntrySearch{Mode: "match"},
+		})
+	}
+	writeFHIR(c, http.StatusOK, bundle)
+}
+
+// parseFHIRSearch reads the search parameters the resource type supports, and
+// the paging parameters. It also returns the parameters it used, for the
+// Bundle links. Unsupported parameters are ignored unless the client asks for
+// strict handling.
+func parseFHIRSearch(c *gin.Context, resourceType string) (services.FHIRSearch, url.Values, error) {
+	search := services.FHIRSearch{Count: fhirDefaultCount}
+	applied := url.Values{}
+	types := make(map[string]string)
+	for _, param := range fhir.SearchParams[resourceType] {
+		types[param.Name] = param.Type
+	}
+	strict := strings.Contains(c.GetHeader("Prefer"), "handling=strict")
+
+	for name, values := range c.Request.URL.Query() {
+		switch name {
+		case "_count", "_offset":
+			n, err := strconv.Atoi(values[0])
+			if err != nil || n < 0 {
+				return search, nil, fmt.Errorf("%s must be a whole number", name)
+			}
+			if name == "_count" {
+				search.Count = min(n, fhirMaxCount)
+			} else {
+				search.Offset = n
+			}
+			continue
+		case "_format":
+			continue
+		}
+
+		paramType, ok := types[name]
+		if !ok {
+			if strict {
+				return search, nil, fmt.Errorf("search parameter %s is not supported for %s", name, resourceType)
+			}
+			continue
+		}
+		if paramType != "date" && len(values) > 1 {
+			return search, nil, fmt.Errorf("search parameter %s may only be given once", name)
+		}
+		applied[name] = values
+
+		value := values[0]
+		switch paramType {
+		case "date":
+			for _, value := range values {
+				dateRange, err := parseFHIRDate(value)
+				if err != nil {
+					return search, nil, fmt.Errorf("%s: %w", name, err)
+				}
+				search.Dates = append(search.Dates, dateRange)
+			}
+		case "reference":
+			target := "Patient"
+			if name != "patient" {
+				target = "Practitioner"
+			}
+			id, err := parseFHIRReference(value, target)
+			if err != nil {
+				return search, nil, fmt.Errorf("%s: %w", name, err)
+			}
+			if target == "Patient" {
+				search.PatientID = &id
+			} else {
+				search.PractitionerID = &id
+			}
+		case "token":
+			if name == "_id" {
+				search.IDs = []uuid.UUID{}
+				for _, part := range strings.Split(value, ",") {
+					// An ID that is not a UUID matches nothing
+					if id, err := uuid.Parse(strings.TrimSpace(part)); err == nil {
+						search.IDs = append(search.IDs, id)
+					}
+				}
+			} else if system, code, found := strings.Cut(value, "|"); found {
+				search.IdentifierSystem, search.IdentifierValue = system, code
+			} else {
+				search.IdentifierValue = value
+			}
+		case "string":
+			search.Name = value
+		}
+	}
+	return search, applied, nil
+}
+
+// parseFHIRDate reads a date search value, e.g. ge2026-01 or 2026-03-01, as
+// the range of times it matches. The eq, gt, ge, lt and le prefixes are
+// supported, and a value is as precise as it is written.
+func parseFHIRDate(value string) (services.FHIRDateRange, error) {
+	prefix := "eq"
+	if len(value) > 2 && value[0] >= 'a' && value[0] <= 'z' {
+		prefix, value = value[:2], value[2:]
+	}
+
+	var start, end time.Time
+	var err error
+	switch len(value) {
+	case 4:
+		start, err = time.Parse("2006", value)
+		end = start.AddDate(1, 0, 0)
+	case 7:
+		start, err = time.Parse("2006-01", value)
+		end = start.AddDate(0, 1, 0)
+	case 10:
+		start, err = time.Parse(models.DateLayout, value)
+		end = start.AddDate(0, 0, 1)
+	default:
+		start, err = time.Parse(time.RFC3339, value)
+		end = start.Add(time.Second)
+	}
+	if err != nil {
+		return services.FHIRDateRange{}, fmt.Errorf("invalid date %q", value)
+	}
+
+	switch prefix {
+	case "eq":
+		return services.FHIRDateRange{From: &start, To: &end}, nil
+	case "ge":
+		return services.FHIRDateRange{From: &start}, nil
+	case "gt":
+		return services.FHIRDateRange{From: &end}, nil
+	case "le":
+		return services.FHIRDateRange{To: &end}, nil
+	case "lt":
+		return services.FHIRDateRange{To: &start}, nil
+	}
+	return services.FHIRDateRange{}, fmt.Errorf("date prefix %q is not supported", prefix)
+}
+
+// parseFHIRReference reads a reference search value: an ID, Type/ID or an
+// absolute URL ending in Type/ID.
+func parseFHIRReference(value, resourceType string) (uuid.UUID, error) {
+	parts := strings.Split(strings.TrimRight(value, "/"), "/")
+	if len(parts) > 1 && parts[len(parts)-2] != resourceType {
+		return uuid.Nil, fmt.Errorf("must refer to a %s", resourceType)
+	}
+	id, err := uuid.Parse(parts[len(parts)-1])
+	if err != nil {
+		// Unknown IDs match nothing rather than being an error
+		return uuid.Nil, nil
+	}
+	return id, nil
+}
+
+// searchLinks builds the self and paging links of a searchset Bundle.
+func searchLinks(searchURL string, applied url.Values, search services.FHIRSearch, total int64) []fhir.BundleLink {
+	link := func(relation string, offset int) fhir.BundleLink {
+		query := url.Values{}
+		for name, values := range applied {
+			query[name] = values
+		}
+		query.Set("_count", strconv.Itoa(search.Count))
+		query.Set("_offset", strconv.Itoa(offset))
+		return fhir.BundleLink{Relation: relation, URL: searchURL + "?" + query.Encode()}
+	}
+
+	links := []fhir.BundleLink{link("self", search.Offset)}
+	if search.Count == 0 {
+		return links
+	}
+	last := 0
+	if total > 0 {
+		last = int((total - 1) / int64(search.Count) * int64(search.Count))
+	}
+	links = append(links, link("first", 0))
+	if search.Offset > 0 {
+		links = append(links, link("previous", max(search.Offset-search.Count, 0)))
+	}
+	if int64(search.Offset+search.Count) < total {
+		links = append(links, link("next", search.Offset+search.Count))
+	}
+	return append(links, link("last", last))
+}
+
+// base returns the absolute URL of the FHIR API.
+func (h *FHIRHandler) base(c *gin.Context) string {
+	if h.baseURL != "" {
+		return h.baseURL
+	}
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host + "/fhir/r4"
+}
+
+// fhirRequester reads the logged in user set by the auth middleware.
+func fhirRequester(c *gin.Context) (services.FHIRRequester, bool) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		writeOutcome(c, http.StatusUnauthorized, "login", "unauthorized")
+		return services.FHIRRequester{}, false
+	}
+	return services.FHIRRequester{UserID: userID, Role: c.GetString("user_role")}, true
+}
+
+// writeFHIRError maps a service error to an OperationOutcome. notFound is the
+// message for gorm.ErrRecordNotFound.
+func writeFHIRError(c *gin.Context, err error, notFound string) {
+	var invalid *fhir.InvalidResource
+	var precondition *services.FHIRPreconditionError
+	var rejected *services.FHIRRejectedError
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		writeOutcome(c, http.StatusNotFound, "not-found", notFound)
+	case errors.As(err, &invalid):
+		writeFHIR(c, http.StatusBadRequest, invalid.Outcome())
+	case errors.As(err, &precondition):
+		writeOutcome(c, http.StatusPreconditionFailed, "conflict", err.Error())
+	case errors.As(err, &rejected):
+		writeOutcome(c, http.StatusUnprocessableEntity, "business-rule", err.Error())
+	case err.Error() == "access denied":
+		writeOutcome(c, http.StatusForbidden, "forbidden", "this role may not do this through the FHIR API")
+	default:
+		writeOutcome(c, http.StatusInternalServerError, "exception", err.Error())
+	}
+}
+
+func writeOutcome(c *gin.Context, status int, code, diagnostics string) {
+	writeFHIR(c, status, fhir.Outcome(code, diagnostics))
+}
+
+func writeFHIR(c *gin.Context, status int, resource any) {
+	body, err := json.Marshal(resource)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Data(status, fhir.ContentType, body)
+}
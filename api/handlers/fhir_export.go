@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"hospital/internal/fhir"
+	"hospital/internal/services"
+	"hospital/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Export starts a system-level bulk data export of every resource type, or
+// those listed in _type, changed after _since. The export runs in the
+// background; its status URL is returned in Content-Location.
+func (h *FHIRHandler) Export(c *gin.Context) {
+	requester, ok := fhirRequester(c)
+	if !ok {
+		return
+	}
+	base := h.base(c)
+	request := services.FHIRExportRequest{Request: base + "/$export"}
+	if c.Request.URL.RawQuery != "" {
+		request.Request += "?" + c.Request.URL.RawQuery
+	}
+	strict := strings.Contains(c.GetHeader("Prefer"), "handling=strict")
+
+	for name, values := range c.Request.URL.Query() {
+		switch name {
+		case "_outputFormat":
+			if format := values[0]; format != fhir.NDJSONContentType && format != "application/ndjson" && format != "ndjson" {
+				writeOutcome(c, http.StatusBadRequest, "not-supported", "only NDJSON output is supported")
+				return
+			}
+		case "_since":
+			since, err := time.Parse(time.RFC3339, values[0])
+			if err != nil {
+				writeOutcome(c, http.StatusBadRequest, "invalid", "_since must be an instant such as 2026-01-01T00:00:00Z")
+				return
+			}
+			request.Since = &since
+		case "_type":
+			for _, resourceType := range strings.Split(strings.Join(values, ","), ",") {
+				resourceType = strings.TrimSpace(resourceType)
+				if !slices.Contains(fhir.ResourceTypes, resourceType) {
+					writeOutcome(c, http.StatusBadRequest, "not-supported", fmt.Sprintf("resource type %s cannot be exported", resourceType))
+					return
+				}
+				if !slices.Contains(request.Types, resourceType) {
+					request.Types = append(request.Types, resourceType)
+				}
+			}
+		default:
+			if strict {
+				writeOutcome(c, http.StatusBadRequest, "not-supported", fmt.Sprintf("parameter %s is not supported for $export", name))
+				return
+			}
+		}
+	}
+
+	export, err := h.exportService.StartExport(requester, request)
+	if err != nil {
+		writeFHIRError(c, err, "")
+		return
+	}
+	c.Header("Content-Location", base+"/$export-status/"+export.ID.String())
+	c.Status(http.StatusAccepted)
+}
+
+// ExportStatus reports on an export. A running export answers 202 with its
+// progress; a completed one gives the manifest of its files, with download
+// links that expire.
+func (h *FHIRHandler) ExportStatus(c *gin.Context) {
+	requester, ok := fhirRequester(c)
+	if !ok {
+		return
+	}
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		writeOutcome(c, http.StatusNotFound, "not-found", "export is not known")
+		return
+	}
+	export, err := h.exportService.GetExport(requester, id)
+	if err != nil {
+		writeFHIRError(c, err, "export is not known")
+		return
+	}
+
+	switch export.Status {
+	case "in_progress":
+		progress := "waiting for another export to finish"
+		if export.Progress != "" {
+			progress = "exporting " + export.Progress
+		}
+		c.Header("X-Progress", progress)
+		c.Header("Retry-After", "10")
+		c.Status(http.StatusAccepted)
+	case "failed":
+		writeOutcome(c, http.StatusInternalServerError, "exception", export.Error)
+	case "completed":
+		base := h.base(c)
+		manifest := fhir.ExportManifest{
+			TransactionTime: export.TransactionTime.UTC().Format(time.RFC3339),
+			Request:         export.Request,
+			Output:          []fhir.ExportOutput{},
+			Error:           []fhir.ExportOutput{},
+		}
+		for _, file := range export.Files {
+			query := h.exportService.DownloadQuery(export, file.Type)
+			manifest.Output = append(manifest.Output, fhir.ExportOutput{
+				Type:  file.Type,
+				URL:   fmt.Sprintf("%s/$export-file/%s/%s.ndjson?%s", base, export.ID, file.Type, query.Encode()),
+				Count: file.Count,
+			})
+		}
+		c.Header("Expires", export.ExpiresAt.UTC().Format(http.TimeFormat))
+		c.JSON(http.StatusOK, manifest)
+	default:
+		writeOutcome(c, http.StatusNotFound, "not-found", "the export was "+export.Status)
+	}
+}
+
+// CancelExport stops a running export or deletes the files of a completed one.
+func (h *FHIRHandler) CancelExport(c *gin.Context) {
+	requester, ok := fhirRequester(c)
+	if !ok {
+		return
+	}
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		writeOutcome(c, http.StatusNotFound, "not-found", "export is not known")
+		return
+	}
+	if err := h.exportService.CancelExport(requester, id); err != nil {
+		writeFHIRError(c, err, "export is not known or has already finished")
+		return
+	}
+	c.Status(http.StatusAccepted)
+}
+
+// DownloadExportFile serves an export file. It needs no login; the signed
+// link is the credential.
+func (h *FHIRHandler) DownloadExportFile(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	resourceType, isNDJSON := strings.CutSuffix(c.Param("file"), ".ndjson")
+	if err != nil || !isNDJSON {
+		writeOutcome(c, http.StatusNotFound, "not-found", "export file is not known")
+		return
+	}
+
+	content, err := h.exportService.OpenFile(id, resourceType, c.Request.URL.Query())
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrExportLinkInvalid):
+			writeOutcome(c, http.StatusForbidden, "forbidden", err.Error())
+		case errors.Is(err, storage.ErrNotFound):
+			writeOutcome(c, http.StatusNotFound, "not-found", "export file contents are missing")
+		default:
+			writeOutcome(c, http.StatusInternalServerError, "exception", err.Error())
+		}
+		return
+	}
+	defer content.Close()
+
+	c.DataFromReader(http.StatusOK, -1, fhir.NDJSONContentType, content, map[string]string{
+		"Content-Disposition":    fmt.Sprintf("attachment; filename=%q", c.Param("file")),
+		"X-Content-Type-Options": "nosniff",
+	})
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseFHIRDate(t *testing.T) {
+	at := func(value string) *time.Time {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			panic(err)
+		}
+		return &parsed
+	}
+	tests := []struct {
+		value    string
+		from, to *time.Time
+		wantErr  bool
+	}{
+		{value: "2026", from: at("2026-01-01T00:00:00Z"), to: at("2027-01-01T00:00:00Z")},
+		{value: "2026-02", from: at("2026-02-01T00:00:00Z"), to: at("2026-03-01T00:00:00Z")},
+		{value: "eq2026-02-28", from: at("2026-02-28T00:00:00Z"), to: at("2026-03-01T00:00:00Z")},
+		{value: "ge2026-01", from: at("2026-01-01T00:00:00Z")},
+		{value: "gt2026-01", from: at("2026-02-01T00:00:00Z")},
+		{value: "le2026-12-31", to: at("2027-01-01T00:00:00Z")},
+		{value: "lt2026-12-31", to: at("2026-12-31T00:00:00Z")},
+		{value: "2026-03-01T10:30:00Z", from: at("2026-03-01T10:30:00Z"), to: at("2026-03-01T10:30:01Z")},
+		{value: "ge2026-03-01T10:30:00+01:00", from: at("2026-03-01T09:30:00Z")},
+		{value: "ne2026-01-01", wantErr: true},
+		{value: "sa2026", wantErr: true},
+		{value: "2026-13", wantErr: true},
+		{value: "2026-02-30", wantErr: true},
+		{value: "yesterday", wantErr: true},
+		{value: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseFHIRDate(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFHIRDate(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !sameTime(got.From, tt.from) || !sameTime(got.To, tt.to) {
+				t.Errorf("parseFHIRDate(%q) = [%v, %v), want [%v, %v)", tt.value, got.From, got.To, tt.from, tt.to)
+			}
+		})
+	}
+}
+
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"hospital/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func (h *DoctorHandler) SearchFormulary(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	items, err := h.doctorService.SearchFormulary(c.Query("q"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"formulary": items})
+}
+
+func (h *DoctorHandler) CheckPrescription(c *gin.Context) {
+	patientID, err := uuid.Parse(c.Param("patient_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid patient ID"})
+		return
+	}
+	doctorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	type CheckInput struct {
+		FormularyItemID *uuid.UUID `json:"formulary_item_id"`
+		FreeText        bool       `json:"free_text"`
+		Medication      string     `json:"medication"`
+	}
+
+	var input CheckInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	check, err := h.doctorService.CheckPrescription(doctorID, patientID, &models.Prescription{
+		FormularyItemID: input.FormularyItemID,
+		FreeText:        input.FreeText,
+		Medication:      input.Medication,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "patient not found"})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"check": check})
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"hospital/internal/models"
+	"hospital/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ImmunizationHandler serves vaccination records to doctors and nurses, and
+// the overdue outreach list to receptionists. Doctors are limited to their own
+// patients.
+type ImmunizationHandler struct {
+	immunizationService services.ImmunizationService
+	doctorService       services.DoctorService
+}
+
+func NewImmunizationHandler(immunizationService services.ImmunizationService, doctorService services.DoctorService) *ImmunizationHandler {
+	return &ImmunizationHandler{
+		immunizationService: immunizationService,
+		doctorService:       doctorService,
+	}
+}
+
+// GetVaccines lists the active vaccines with their schedules.
+func (h *ImmunizationHandler) GetVaccines(c *gin.Context) {
+	vaccines, err := h.immunizationService.GetVaccines(c.Query("include_inactive") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch vaccines"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"vaccines": vaccines})
+}
+
+func (h *ImmunizationHandler) RecordImmunization(c *gin.Context) {
+	userID, patientID, ok := authorizePatient(c, h.doctorService)
+	if !ok {
+		return
+	}
+
+	var immunization models.Immunization
+	if err := c.ShouldBindJSON(&immunization); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.immunizationService.RecordImmunization(userID, patientID, &immunization); err != nil {
+		switch err.Error() {
+		case "patient not found", "vaccine not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "immunization recorded successfully", "immunization": immunization})
+}
+
+// GetImmunizations returns the patient's history with the doses that are due,
+// overdue and upcoming as of today, or as of ?as_of=YYYY-MM-DD.
+func (h *ImmunizationHandler) GetImmunizations(c *gin.Context) {
+	_, patientID, ok := authorizePatient(c, h.doctorService)
+	if !ok {
+		return
+	}
+	asOf, ok := queryAsOf(c)
+	if !ok {
+		return
+	}
+
+	record, err := h.immunizationService.GetImmunizations(patientID, asOf)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "patient not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch immunizations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, record)
+}
+
+// GetOverdueReport lists overdue doses across the clinic for outreach,
+// optionally for one ?vaccine code. Like the other reports it downloads as CSV
+// with ?format=csv.
+func (h *ImmunizationHandler) GetOverdueReport(c *gin.Context) {
+	asOf, ok := queryAsOf(c)
+	if !ok {
+		return
+	}
+
+	rows, err := h.immunizationService.GetOverdueReport(asOf, c.Query("vaccine"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate overdue immunization report"})
+		return
+	}
+
+	writeReport(c, "immunizations-overdue-"+asOf.String(), rows, gin.H{"as_of": asOf})
+}
+
+// queryAsOf reads the as_of query parameter, defaulting to today.
+func queryAsOf(c *gin.Context) (models.Date, bool) {
+	value := c.Query("as_of")
+	if value == "" {
+		return models.NewDate(time.Now()), true
+	}
+	date, err := models.ParseDate(value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return models.Date{}, false
+	}
+	return date, true
+}
@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"hospital/internal/models"
+	"hospital/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type InsuranceHandler struct {
+	insuranceService services.InsuranceService
+}
+
+func NewInsuranceHandler(insuranceService services.InsuranceService) *InsuranceHandler {
+	return &InsuranceHandler{
+		insuranceService: insuranceService,
+	}
+}
+
+type PayerInput struct {
+	Name      string `json:"name" binding:"required"`
+	PayerCode string `json:"payer_code" binding:"required"`
+	Phone     string `json:"phone"`
+	Email     string `json:"email"`
+	Address   string `json:"address"`
+	Active    *bool  `json:"active"` // Only used on update, defaults to true
+}
+
+type PolicyInput struct {
+	PayerID                uuid.UUID    `json:"payer_id" binding:"required"`
+	MemberID               string       `json:"member_id" binding:"required"`
+	GroupNumber            string       `json:"group_number"`
+	PlanName               string       `json:"plan_name"`
+	SubscriberName         string       `json:"subscriber_name"`
+	SubscriberRelationship string       `json:"subscriber_relationship"`
+	Priority               int          `json:"priority"`
+	ValidFrom              models.Date  `json:"valid_from" binding:"required"`
+	ValidTo                *models.Date `json:"valid_to"`
+	CardFrontDocumentID    *uuid.UUID   `json:"card_front_document_id"`
+	CardBackDocumentID     *uuid.UUID   `json:"card_back_document_id"`
+}
+
+func (input PolicyInput) policy() models.InsurancePolicy {
+	return models.InsurancePolicy{
+		PayerID:                input.PayerID,
+		MemberID:               input.MemberID,
+		GroupNumber:            input.GroupNumber,
+		PlanName:               input.PlanName,
+		SubscriberName:         input.SubscriberName,
+		SubscriberRelationship: input.SubscriberRelationship,
+		Priority:               input.Priority,
+		ValidFrom:              input.ValidFrom,
+		ValidTo:                input.ValidTo,
+		CardFrontDocumentID:    input.CardFrontDocumentID,
+		CardBackDocumentID:     input.CardBackDocumentID,
+	}
+}
+
+func (h *InsuranceHandler) GetPayers(c *gin.Context) {
+	payers, err := h.insuranceService.GetPayers(c.Query("include_inactive") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve payers"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"payers": payers})
+}
+
+func (h *InsuranceHandler) CreatePayer(c *gin.Context) {
+	var input PayerInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	payer := models.InsurancePayer{
+		Name:      input.Name,
+		PayerCode: input.PayerCode,
+		Phone:     input.Phone,
+		Email:     input.Email,
+		Address:   input.Address,
+	}
+	if err := h.insuranceService.CreatePayer(&payer); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Payer created successfully", "payer": payer})
+}
+
+func (h *InsuranceHandler) UpdatePayer(c *gin.Context) {
+	payerID, err := uuid.Parse(c.Param("payer_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid payer ID format"})
+		return
+	}
+
+	var input PayerInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	update := models.InsurancePayer{
+		Name:      input.Name,
+		PayerCode: input.PayerCode,
+		Phone:     input.Phone,
+		Email:     input.Email,
+		Address:   input.Address,
+		Active:    input.Active == nil || *input.Active,
+	}
+	payer, err := h.insuranceService.UpdatePayer(payerID, &update)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Payer not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Payer updated successfully", "payer": payer})
+}
+
+func (h *InsuranceHandler) GetPolicies(c *gin.Context) {
+	patientID, err := uuid.Parse(c.Param("patient_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID format"})
+		return
+	}
+
+	policies, err := h.insuranceService.GetPolicies(patientID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Patient not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve insurance policies"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"policies": policies})
+}
+
+func (h *InsuranceHandler) CreatePolicy(c *gin.Context) {
+	patientID, err := uuid.Parse(c.Param("patient_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID format"})
+		return
+	}
+
+	var input PolicyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	policy := input.policy()
+	if err := h.insuranceService.CreatePolicy(patientID, &policy); err != nil {
+		if err.Error() == "patient not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Patient not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Insurance policy created successfully", "policy": policy})
+}
+
+func (h *InsuranceHandler) UpdatePolicy(c *gin.Context) {
+	patientID, policyID, ok := policyRequest(c)
+	if !ok {
+		return
+	}
+
+	var input PolicyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	update := input.policy()
+	policy, err := h.insuranceService.UpdatePolicy(patientID, policyID, &update)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Insurance policy not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Insurance policy updated successfully", "policy": policy})
+}
+
+func (h *InsuranceHandler) DeletePolicy(c *gin.Context) {
+	patientID, policyID, ok := policyRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := h.insuranceService.DeletePolicy(patientID, policyID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Insurance policy not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete insurance policy"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Insurance policy deleted successfully"})
+}
+
+func (h *InsuranceHandler) CheckEligibility(c *gin.Context) {
+	patientID, policyID, ok := policyRequest(c)
+	if !ok {
+		return
+	}
+
+	serviceDate := models.NewDate(time.Now())
+	if value := c.Query("date"); value != "" {
+		date, err := models.ParseDate(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		serviceDate = date
+	}
+
+	policy, err := h.insuranceService.CheckEligibility(patientID, policyID, serviceDate)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Insurance policy not found"})
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Eligibility check failed", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"policy": policy})
+}
+
+func policyRequest(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	patientID, err := uuid.Parse(c.Param("patient_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID format"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	policyID, err := uuid.Parse(c.Param("policy_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid policy ID format"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	return patientID, policyID, true
+}
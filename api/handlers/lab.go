@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"hospital/internal/models"
+	"hospital/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Doctor handlers
+
+func (h *DoctorHandler) SearchLabTests(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	tests, err := h.doctorService.SearchLabTests(c.Query("q"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"lab_tests": tests})
+}
+
+func (h *DoctorHandler) CreateLabOrder(c *gin.Context) {
+	patientID, err := uuid.Parse(c.Param("patient_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid patient ID format"})
+		return
+	}
+	doctorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	type LabOrderInput struct {
+		LabTestID     uuid.UUID  `json:"lab_test_id" binding:"required"`
+		AppointmentID *uuid.UUID `json:"appointment_id"`
+		Priority      string     `json:"priority"`
+		ClinicalNotes string     `json:"clinical_notes"`
+	}
+
+	var input LabOrderInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	order := models.LabOrder{
+		LabTestID:     input.LabTestID,
+		AppointmentID: input.AppointmentID,
+		Priority:      input.Priority,
+		ClinicalNotes: input.ClinicalNotes,
+	}
+	if err := h.doctorService.CreateLabOrder(doctorID, patientID, &order); err != nil {
+		if err.Error() == "patient not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "lab order created successfully", "lab_order": order})
+}
+
+func (h *DoctorHandler) GetLabOrdersByPatient(c *gin.Context) {
+	patientID, err := uuid.Parse(c.Param("patient_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid patient ID format"})
+		return
+	}
+	doctorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	orders, err := h.doctorService.GetLabOrdersByPatient(doctorID, patientID, c.Query("status"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "patient not found"})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"lab_orders": orders})
+}
+
+func (h *DoctorHandler) GetUnreviewedLabResults(c *gin.Context) {
+	doctorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	orders, err := h.doctorService.GetUnreviewedLabResults(doctorID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"lab_orders": orders})
+}
+
+func (h *DoctorHandler) ReviewLabOrder(c *gin.Context) {
+	orderID, err := uuid.Parse(c.Param("order_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid lab order ID"})
+		return
+	}
+	doctorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	order, err := h.doctorService.ReviewLabOrder(doctorID, orderID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "lab order not found"})
+		} else {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "lab result reviewed", "lab_order": order})
+}
+
+// Lab system handlers
+
+type LabHandler struct {
+	labService services.LabService
+}
+
+func NewLabHandler(labService services.LabService) *LabHandler {
+	return &LabHandler{
+		labService: labService,
+	}
+}
+
+func (h *LabHandler) GetWorklist(c *gin.Context) {
+	orders, err := h.labService.GetWorklist(c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"lab_orders": orders})
+}
+
+func (h *LabHandler) CollectSpecimen(c *gin.Context) {
+	orderID, apiKeyID, ok := labOrderRequest(c)
+	if !ok {
+		return
+	}
+
+	type CollectInput struct {
+		CollectedAt *time.Time `json:"collected_at"`
+	}
+
+	// The body is optional, collection defaults to now
+	var input CollectInput
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	order, err := h.labService.CollectSpecimen(orderID, apiKeyID, input.CollectedAt)
+	if err != nil {
+		writeLabOrderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "specimen collected", "lab_order": order})
+}
+
+func (h *LabHandler) RecordResult(c *gin.Context) {
+	orderID, apiKeyID, ok := labOrderRequest(c)
+	if !ok {
+		return
+	}
+
+	type ResultInput struct {
+		Value     *float64 `json:"value"`
+		ValueText string   `json:"value_text"`
+		Unit      string   `json:"unit"`
+		Comment   string   `json:"comment"`
+	}
+
+	var input ResultInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := models.LabResult{
+		Value:     input.Value,
+		ValueText: input.ValueText,
+		Unit:      input.Unit,
+		Comment:   input.Comment,
+	}
+	order, err := h.labService.RecordResult(orderID, apiKeyID, &result)
+	if err != nil {
+		writeLabOrderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "lab result recorded", "lab_order": order})
+}
+
+func labOrderRequest(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	orderID, err := uuid.Parse(c.Param("order_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid lab order ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	apiKeyID, exists := c.Get("api_key_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	return orderID, apiKeyID.(uuid.UUID), true
+}
+
+func writeLabOrderError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "lab order not found"})
+	case err.Error() == "specimen has already been collected", err.Error() == "lab order already has a result":
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"hospital/internal/services"
+	"hospital/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PatientDocumentHandler serves files attached to patients to doctors and
+// receptionists, who scan insurance cards and letters at the front desk.
+// Doctors are limited to their own patients, as on every other doctor endpoint.
+type PatientDocumentHandler struct {
+	patientDocumentService services.PatientDocumentService
+	doctorService          services.DoctorService
+}
+
+func NewPatientDocumentHandler(patientDocumentService services.PatientDocumentService, doctorService services.DoctorService) *PatientDocumentHandler {
+	return &PatientDocumentHandler{
+		patientDocumentService: patientDocumentService,
+		doctorService:          doctorService,
+	}
+}
+
+func (h *PatientDocumentHandler) UploadDocument(c *gin.Context) {
+	userID, patientID, ok := authorizePatient(c, h.doctorService)
+	if !ok {
+		return
+	}
+
+	// Leave room for the multipart framing and the other form fields
+	limit := h.patientDocumentService.MaxUploadBytes()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit+1<<20)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": services.ErrDocumentTooLarge.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+
+	document, err := h.patientDocumentService.UploadDocument(userID, patientID, &services.DocumentUpload{
+		Category: c.PostForm("category"),
+		Title:    c.PostForm("title"),
+		FileName: fileHeader.Filename,
+		Size:     fileHeader.Size,
+		Content:  file,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrDocumentTooLarge):
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "patient not found"})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "document uploaded successfully", "document": document})
+}
+
+func (h *PatientDocumentHandler) GetDocuments(c *gin.Context) {
+	_, patientID, ok := authorizePatient(c, h.doctorService)
+	if !ok {
+		return
+	}
+
+	documents, err := h.patientDocumentService.GetDocuments(patientID, c.Query("category"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"documents": documents})
+}
+
+func (h *PatientDocumentHandler) DownloadDocument(c *gin.Context) {
+	_, patientID, ok := authorizePatient(c, h.doctorService)
+	if !ok {
+		return
+	}
+	documentID, err := uuid.Parse(c.Param("document_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid document ID"})
+		return
+	}
+
+	document, err := h.patientDocumentService.GetDocument(patientID, documentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "document not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	content, err := h.patientDocumentService.OpenDocument(document)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "document contents are missing"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	defer content.Close()
+
+	c.DataFromReader(http.StatusOK, document.Size, document.ContentType, content, map[string]string{
+		"Content-Disposition":    fmt.Sprintf("attachment; filename=%q", document.FileName),
+		"X-Content-Type-Options": "nosniff",
+		"X-Checksum-Sha256":      document.SHA256,
+	})
+}
+
+func (h *PatientDocumentHandler) DeleteDocument(c *gin.Context) {
+	userID, patientID, ok := authorizePatient(c, h.doctorService)
+	if !ok {
+		return
+	}
+	documentID, err := uuid.Parse(c.Param("document_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid document ID"})
+		return
+	}
+
+	if err := h.patientDocumentService.DeleteDocument(userID, patientID, documentID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "document not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "document deleted successfully"})
+}
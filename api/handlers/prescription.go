@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"hospital/internal/models"
+	"hospital/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// writePrescriptionError maps errors from saving a prescription to a response.
+// Safety check failures are reported as conflicts with their findings so the
+// doctor can review them and resend with an override.
+func writePrescriptionError(c *gin.Context, err error) {
+	var allergyErr *services.AllergyConflictError
+	var interactionErr *services.InteractionConflictError
+	switch {
+	case errors.As(err, &allergyErr):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":     err.Error(),
+			"conflicts": allergyErr.Conflicts,
+			"details":   "resend with allergy_override set and an allergy_override_reason to prescribe anyway",
+		})
+	case errors.As(err, &interactionErr):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":        err.Error(),
+			"interactions": interactionErr.Interactions,
+			"details":      "resend with interaction_override set and an interaction_override_reason to prescribe anyway",
+		})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "prescription not found"})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
+
+func (h *DoctorHandler) GetPrescription(c *gin.Context) {
+	prescriptionID, err := uuid.Parse(c.Param("prescription_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid prescription ID"})
+		return
+	}
+	doctorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	prescription, err := h.doctorService.GetPrescription(doctorID, prescriptionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "prescription not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"prescription": prescription})
+}
+
+func (h *DoctorHandler) UpdatePrescription(c *gin.Context) {
+	prescriptionID, err := uuid.Parse(c.Param("prescription_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid prescription ID"})
+		return
+	}
+	doctorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	var input models.Prescription
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	prescription, err := h.doctorService.UpdatePrescription(doctorID, prescriptionID, &input)
+	if err != nil {
+		writePrescriptionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "prescription updated successfully", "prescription": prescription})
+}
+
+func (h *DoctorHandler) DiscontinuePrescription(c *gin.Context) {
+	prescriptionID, err := uuid.Parse(c.Param("prescription_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid prescription ID"})
+		return
+	}
+	doctorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	type DiscontinueInput struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+
+	var input DiscontinueInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	prescription, err := h.doctorService.DiscontinuePrescription(doctorID, prescriptionID, input.Reason)
+	if err != nil {
+		writePrescriptionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "prescription discontinued successfully", "prescription": prescription})
+}
+
+func (h *DoctorHandler) CompletePrescription(c *gin.Context) {
+	prescriptionID, err := uuid.Parse(c.Param("prescription_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid prescription ID"})
+		return
+	}
+	doctorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	prescription, err := h.doctorService.CompletePrescription(doctorID, prescriptionID)
+	if err != nil {
+		writePrescriptionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "prescription completed successfully", "prescription": prescription})
+}
+
+func (h *DoctorHandler) GetPrescriptionVersions(c *gin.Context) {
+	prescriptionID, err := uuid.Parse(c.Param("prescription_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid prescription ID"})
+		return
+	}
+	doctorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	versions, err := h.doctorService.GetPrescriptionVersions(doctorID, prescriptionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "prescription not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"versions": versions})
+}
@@ -15,11 +15,15 @@ import (
 
 type ReceptionistHandler struct {
 	receptionistService services.ReceptionistServiceInterface
+	documentService     services.DocumentService
+	insuranceService    services.InsuranceService
 }
 
-func NewReceptionistHandler(receptionistService services.ReceptionistServiceInterface) *ReceptionistHandler {
+func NewReceptionistHandler(receptionistService services.ReceptionistServiceInterface, documentService services.DocumentService, insuranceService services.InsuranceService) *ReceptionistHandler {
 	return &ReceptionistHandler{
 		receptionistService: receptionistService,
+		documentService:     documentService,
+		insuranceService:    insuranceService,
 	}
 }
 
@@ -44,10 +48,7 @@ func (h *ReceptionistHandler) CreatePatient(c *gin.Context) {
 	}
 	fmt.Println("this is the doctor ", doctor)
 
-	patient.UserID = doctor.ID
-	fmt.Println(patient.UserID)
-
-	if err := h.receptionistService.CreatePatient(&patient); err != nil {
+	if err := h.receptionistService.CreatePatient(&patient, doctor.ID); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error at creating patient": err.Error(),
 		})
@@ -124,6 +125,26 @@ func (h *ReceptionistHandler) GetPatient(c *gin.Context) {
 	})
 }
 
+func (h *ReceptionistHandler) GetPatientByMRN(c *gin.Context) {
+	patient, err := h.receptionistService.GetPatientByMRN(c.Param("mrn"))
+	if err != nil {
+		if err.Error() == "patient not found" {
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": "Patient not found",
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Failed to retrieve patient",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"patient": patient,
+	})
+}
+
 func (h *ReceptionistHandler) UpdatePatient(c *gin.Context) {
 	patientIDStr := c.Param("patient_id")
 	patientID, err := uuid.Parse(patientIDStr)
@@ -268,6 +289,7 @@ func (h *ReceptionistHandler) CreateAppointment(c *gin.Context) {
 	type AppointmentInput struct {
 		AppointmentDate string `json:"appointment_date"` // e.g. "03/07/2026 12:00"
 		Status          string `json:"status"`
+		Type            string `json:"type"` // consultation (default), follow_up, procedure or emergency
 		Notes           string `json:"notes"`
 	}
 
@@ -317,12 +339,21 @@ func (h *ReceptionistHandler) CreateAppointment(c *gin.Context) {
 		return
 	}
 
+	doctorID, ok := patient.PrimaryDoctorID()
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Patient has no primary doctor",
+		})
+		return
+	}
+
 	// Prepare appointment
 	appointment := models.Appointment{
 		PatientID:       patientUUID,
-		DoctorID:        patient.UserID,
+		DoctorID:        doctorID,
 		AppointmentDate: parsedTime,
 		Status:          input.Status,
+		Type:            input.Type,
 		Notes:           input.Notes,
 	}
 
@@ -335,10 +366,27 @@ func (h *ReceptionistHandler) CreateAppointment(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
+	response := gin.H{
 		"message":     "Appointment created successfully",
 		"appointment": appointment,
-	})
+	}
+	if warnings := h.coverageWarnings(patientUUID, parsedTime); len(warnings) > 0 {
+		response["warnings"] = warnings
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+// coverageWarnings tells the front desk when a booked patient has no active
+// insurance on the appointment date. The booking itself is never refused.
+func (h *ReceptionistHandler) coverageWarnings(patientID uuid.UUID, date time.Time) []string {
+	policies, err := h.insuranceService.ActivePolicies(patientID, models.NewDate(date))
+	if err != nil {
+		return []string{"Insurance coverage could not be checked"}
+	}
+	if len(policies) == 0 {
+		return []string{"Patient has no active insurance coverage on " + date.Format("02/01/2006")}
+	}
+	return nil
 }
 
 func (h *ReceptionistHandler) GetAppointments(c *gin.Context) {
@@ -478,13 +526,18 @@ func (h *ReceptionistHandler) UpdateAppointment(c *gin.Context) {
 		})
 		return
 	}
-	if parsedTime.Before(time.Now()) {
+	// Completing or cancelling an appointment happens after its date has passed
+	if input.Status == "scheduled" && parsedTime.Before(time.Now()) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Appointment date must be in the future"})
 		return
 	}
+	receptionistID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
 
 	// Call service to update appointment safely
-	updatedAppointment, err := h.receptionistService.UpdateAppointment(patientID, appointmentID, parsedTime, input.Status, input.Notes)
+	updatedAppointment, err := h.receptionistService.UpdateAppointment(receptionistID, patientID, appointmentID, parsedTime, input.Status, input.Notes)
 	if err != nil {
 		if err.Error() == "appointment not found" {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Appointment not found"})
@@ -0,0 +1,226 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"hospital/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func (h *DoctorHandler) CreateReferral(c *gin.Context) {
+	patientID, err := uuid.Parse(c.Param("patient_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid patient ID format"})
+		return
+	}
+	doctorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	type ExternalProviderInput struct {
+		Name         string `json:"name" binding:"required"`
+		Organization string `json:"organization"`
+		Specialty    string `json:"specialty"`
+		Phone        string `json:"phone"`
+		Email        string `json:"email"`
+	}
+	type ReferralInput struct {
+		TargetDoctorID   *uuid.UUID             `json:"target_doctor_id"`
+		ExternalProvider *ExternalProviderInput `json:"external_provider"`
+		Reason           string                 `json:"reason" binding:"required"`
+		Urgency          string                 `json:"urgency"`
+		DocumentIDs      []uuid.UUID            `json:"document_ids"`
+	}
+
+	var input ReferralInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if (input.TargetDoctorID == nil) == (input.ExternalProvider == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "exactly one of target_doctor_id or external_provider is required"})
+		return
+	}
+
+	referral := models.Referral{
+		TargetDoctorID: input.TargetDoctorID,
+		Reason:         input.Reason,
+		Urgency:        input.Urgency,
+	}
+	if provider := input.ExternalProvider; provider != nil {
+		referral.ExternalProvider = provider.Name
+		referral.ExternalOrganization = provider.Organization
+		referral.ExternalSpecialty = provider.Specialty
+		referral.ExternalPhone = provider.Phone
+		referral.ExternalEmail = provider.Email
+	}
+
+	if err := h.doctorService.CreateReferral(doctorID, patientID, &referral, input.DocumentIDs); err != nil {
+		if err.Error() == "patient not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "referral created successfully", "referral": referral})
+}
+
+func (h *DoctorHandler) GetReferrals(c *gin.Context) {
+	doctorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	referrals, err := h.doctorService.GetReferrals(doctorID, c.Query("direction"), c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"referrals": referrals})
+}
+
+func (h *DoctorHandler) GetReferral(c *gin.Context) {
+	doctorID, referralID, ok := referralRequest(c)
+	if !ok {
+		return
+	}
+
+	referral, err := h.doctorService.GetReferral(doctorID, referralID)
+	if err != nil {
+		writeReferralError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"referral": referral})
+}
+
+func (h *DoctorHandler) AcceptReferral(c *gin.Context) {
+	doctorID, referralID, ok := referralRequest(c)
+	if !ok {
+		return
+	}
+
+	referral, err := h.doctorService.AcceptReferral(doctorID, referralID)
+	if err != nil {
+		writeReferralError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "referral accepted", "referral": referral})
+}
+
+func (h *DoctorHandler) DeclineReferral(c *gin.Context) {
+	doctorID, referralID, ok := referralRequest(c)
+	if !ok {
+		return
+	}
+
+	type DeclineInput struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+
+	var input DeclineInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	referral, err := h.doctorService.DeclineReferral(doctorID, referralID, input.Reason)
+	if err != nil {
+		writeReferralError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "referral declined", "referral": referral})
+}
+
+func (h *DoctorHandler) CancelReferral(c *gin.Context) {
+	doctorID, referralID, ok := referralRequest(c)
+	if !ok {
+		return
+	}
+
+	referral, err := h.doctorService.CancelReferral(doctorID, referralID)
+	if err != nil {
+		writeReferralError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "referral cancelled", "referral": referral})
+}
+
+func (h *DoctorHandler) CompleteReferral(c *gin.Context) {
+	doctorID, referralID, ok := referralRequest(c)
+	if !ok {
+		return
+	}
+
+	referral, err := h.doctorService.CompleteReferral(doctorID, referralID)
+	if err != nil {
+		writeReferralError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "referral completed", "referral": referral})
+}
+
+func (h *DoctorHandler) BookReferralAppointment(c *gin.Context) {
+	doctorID, referralID, ok := referralRequest(c)
+	if !ok {
+		return
+	}
+
+	type BookingInput struct {
+		AppointmentDate string `json:"appointment_date" binding:"required"` // e.g. "03/07/2026 12:00"
+		Notes           string `json:"notes"`
+	}
+
+	var input BookingInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	date, err := time.Parse("02/01/2006 15:04", input.AppointmentDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid appointment_date format (expected: DD/MM/YYYY HH:MM)"})
+		return
+	}
+
+	appointment, err := h.doctorService.BookReferralAppointment(doctorID, referralID, date, input.Notes)
+	if err != nil {
+		writeReferralError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "appointment booked successfully", "appointment": appointment})
+}
+
+func referralRequest(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	referralID, err := uuid.Parse(c.Param("referral_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid referral ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	doctorID, ok := currentUserID(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+	return doctorID, referralID, true
+}
+
+func writeReferralError(c *gin.Context, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "referral not found"})
+		return
+	}
+	c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Doctor handlers
+
+func (h *DoctorHandler) GetPendingRefills(c *gin.Context) {
+	doctorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	refills, err := h.doctorService.GetPendingRefills(doctorID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"refills": refills})
+}
+
+func (h *DoctorHandler) ApproveRefill(c *gin.Context) {
+	refillID, err := uuid.Parse(c.Param("refill_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid refill ID"})
+		return
+	}
+	doctorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	refill, err := h.doctorService.ApproveRefill(doctorID, refillID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "refill request not found"})
+			return
+		}
+		writePrescriptionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "refill approved", "refill": refill})
+}
+
+func (h *DoctorHandler) DenyRefill(c *gin.Context) {
+	refillID, err := uuid.Parse(c.Param("refill_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid refill ID"})
+		return
+	}
+	doctorID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	type DenyInput struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+
+	var input DenyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	refill, err := h.doctorService.DenyRefill(doctorID, refillID, input.Reason)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "refill request not found"})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "refill denied", "refill": refill})
+}
+
+// Receptionist handlers
+
+func (h *ReceptionistHandler) GetPatientPrescriptions(c *gin.Context) {
+	patientID, err := uuid.Parse(c.Param("patient_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID format"})
+		return
+	}
+
+	prescriptions, err := h.receptionistService.GetPatientPrescriptions(patientID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve prescriptions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"prescriptions": prescriptions})
+}
+
+func (h *ReceptionistHandler) CreateRefillRequest(c *gin.Context) {
+	patientID, err := uuid.Parse(c.Param("patient_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID format"})
+		return
+	}
+	prescriptionID, err := uuid.Parse(c.Param("prescription_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid prescription ID format"})
+		return
+	}
+	receptionistID, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+
+	type RefillRequestInput struct {
+		Notes string `json:"notes"`
+	}
+
+	// The body is optional, notes are the only field
+	var input RefillRequestInput
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body", "details": err.Error()})
+		return
+	}
+
+	request, err := h.receptionistService.CreateRefillRequest(receptionistID, patientID, prescriptionID, input.Notes)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Prescription not found"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Refill request logged successfully",
+		"refill":  request,
+	})
+}
+
+func (h *ReceptionistHandler) GetRefillRequests(c *gin.Context) {
+	patientID, err := uuid.Parse(c.Param("patient_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid patient ID format"})
+		return
+	}
+
+	requests, err := h.receptionistService.GetRefillRequests(patientID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve refill requests"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"refills": requests})
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"time"
+
+	"hospital/internal/models"
+	"hospital/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Reports are JSON by default; ?format=csv downloads the rows as CSV instead.
+
+type ReportHandler struct {
+	reportService services.ReportService
+}
+
+func NewReportHandler(reportService services.ReportService) *ReportHandler {
+	return &ReportHandler{
+		reportService: reportService,
+	}
+}
+
+// DailyRevenue defaults to the current month up to today.
+func (h *ReportHandler) DailyRevenue(c *gin.Context) {
+	today := time.Now()
+	from, to, ok := reportRange(c, today.AddDate(0, 0, 1-today.Day()), today)
+	if !ok {
+		return
+	}
+
+	rows, err := h.reportService.Revenue("day", from, to)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	writeReport(c, fmt.Sprintf("revenue-daily-%s-%s", from, to), rows, gin.H{"from": from, "to": to})
+}
+
+// MonthlyRevenue defaults to the current year up to today.
+func (h *ReportHandler) MonthlyRevenue(c *gin.Context) {
+	today := time.Now()
+	from, to, ok := reportRange(c, today.AddDate(0, 1-int(today.Month()), 1-today.Day()), today)
+	if !ok {
+		return
+	}
+
+	rows, err := h.reportService.Revenue("month", from, to)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	writeReport(c, fmt.Sprintf("revenue-monthly-%s-%s", from, to), rows, gin.H{"from": from, "to": to})
+}
+
+// DoctorRevenue defaults to the current month up to today.
+func (h *ReportHandler) DoctorRevenue(c *gin.Context) {
+	today := time.Now()
+	from, to, ok := reportRange(c, today.AddDate(0, 0, 1-today.Day()), today)
+	if !ok {
+		return
+	}
+
+	rows, err := h.reportService.RevenueByDoctor(from, to)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	writeReport(c, fmt.Sprintf("revenue-doctors-%s-%s", from, to), rows, gin.H{"from": from, "to": to})
+}
+
+// ReceivablesAging defaults to ageing balances as of today.
+func (h *ReportHandler) ReceivablesAging(c *gin.Context) {
+	asOf, ok := queryAsOf(c)
+	if !ok {
+		return
+	}
+
+	rows, err := h.reportService.ReceivablesAging(asOf)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate aging report"})
+		return
+	}
+
+	writeReport(c, fmt.Sprintf("receivables-aging-%s", asOf), rows, gin.H{"as_of": asOf})
+}
+
+// reportRange reads the from and to query parameters, falling back to the
+// given defaults.
+func reportRange(c *gin.Context, defaultFrom, defaultTo time.Time) (models.Date, models.Date, bool) {
+	from, to := models.NewDate(defaultFrom), models.NewDate(defaultTo)
+	for name, date := range map[string]*models.Date{"from": &from, "to": &to} {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := models.ParseDate(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid %s date", name), "details": err.Error()})
+			return models.Date{}, models.Date{}, false
+		}
+		*date = parsed
+	}
+	return from, to, true
+}
+
+type reportRow interface {
+	CSVHeader() []string
+	CSVRecord() []string
+}
+
+// writeReport writes the rows as a CSV attachment named filename.csv when
+// ?format=csv is given and as JSON, along with extra, otherwise.
+func writeReport[T reportRow](c *gin.Context, filename string, rows []T, extra gin.H) {
+	if c.Query("format") != "csv" {
+		response := gin.H{"rows": rows}
+		for key, value := range extra {
+			response[key] = value
+		}
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	var zero T
+	w.Write(zero.CSVHeader())
+	for _, row := range rows {
+		w.Write(row.CSVRecord())
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to write report"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".csv"))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"hospital/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type VerificationHandler struct {
+	verificationService services.VerificationService
+}
+
+func NewVerificationHandler(verificationService services.VerificationService) *VerificationHandler {
+	return &VerificationHandler{
+		verificationService: verificationService,
+	}
+}
+
+func (h *VerificationHandler) VerifyPrescription(c *gin.Context) {
+	verification, err := h.verificationService.VerifyPrescription(c.Param("code"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"valid": false, "error": "No prescription matches this code"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify prescription"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"valid": true, "prescription": verification})
+}
+
+func (h *VerificationHandler) DispensePrescription(c *gin.Context) {
+	apiKeyID, exists := c.Get("api_key_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	verification, err := h.verificationService.DispensePrescription(c.Param("code"), apiKeyID.(uuid.UUID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No prescription matches this code"})
+			return
+		}
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Prescription marked as dispensed", "prescription": verification})
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"hospital/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VitalsHandler serves vital signs to both nurses and doctors. Doctors are
+// limited to their own patients.
+type VitalsHandler struct {
+	vitalsService services.VitalsService
+	doctorService services.DoctorService
+}
+
+func NewVitalsHandler(vitalsService services.VitalsService, doctorService services.DoctorService) *VitalsHandler {
+	return &VitalsHandler{
+		vitalsService: vitalsService,
+		doctorService: doctorService,
+	}
+}
+
+func (h *VitalsHandler) RecordVitals(c *gin.Context) {
+	userID, patientID, ok := authorizePatient(c, h.doctorService)
+	if !ok {
+		return
+	}
+
+	var input services.VitalsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	vitals, flags, err := h.vitalsService.RecordVitals(userID, patientID, &input)
+	if err != nil {
+		if err.Error() == "patient not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "vitals recorded successfully", "vitals": vitals, "flags": flags})
+}
+
+// GetVitals returns time series for charts. from and to are YYYY-MM-DD and
+// default to the last 90 days.
+func (h *VitalsHandler) GetVitals(c *gin.Context) {
+	_, patientID, ok := authorizePatient(c, h.doctorService)
+	if !ok {
+		return
+	}
+
+	to := time.Now()
+	if toStr := c.Query("to"); toStr != "" {
+		date, err := time.Parse("2006-01-02", toStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to date format (expected: YYYY-MM-DD)"})
+			return
+		}
+		to = date.Add(24*time.Hour - time.Nanosecond)
+	}
+	from := to.AddDate(0, 0, -90)
+	if fromStr := c.Query("from"); fromStr != "" {
+		date, err := time.Parse("2006-01-02", fromStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from date format (expected: YYYY-MM-DD)"})
+			return
+		}
+		from = date
+	}
+	if from.After(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must not be after to"})
+		return
+	}
+
+	series, err := h.vitalsService.GetVitalSeries(patientID, c.Query("type"), from, to)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"patient_id": patientID, "from": from, "to": to, "series": series})
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"hospital/internal/database"
+	"hospital/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyMiddleware authenticates external systems by the X-API-Key header.
+// The key must be active and issued for the given scope.
+func APIKeyMiddleware(db *database.DB, scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("X-API-Key")
+		if key == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "X-API-Key header required"})
+			c.Abort()
+			return
+		}
+
+		sum := sha256.Sum256([]byte(key))
+		var apiKey models.APIKey
+		if err := db.Conn.Where("key_hash = ? AND scope = ? AND active = ?", hex.EncodeToString(sum[:]), scope, true).
+			First(&apiKey).Error; err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+			c.Abort()
+			return
+		}
+		db.Conn.Model(&apiKey).UpdateColumn("last_used_at", time.Now())
+
+		c.Set("api_key_id", apiKey.ID)
+		c.Set("api_key_name", apiKey.Name)
+
+		c.Next()
+	}
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitMiddleware allows each client IP up to limit requests per window,
+// refilled continuously (token bucket). State is kept in memory per process.
+func RateLimitMiddleware(limit int, window time.Duration) gin.HandlerFunc {
+	type bucket struct {
+		tokens float64
+		last   time.Time
+	}
+
+	var mu sync.Mutex
+	buckets := make(map[string]*bucket)
+	rate := float64(limit) / window.Seconds()
+
+	return func(c *gin.Context) {
+		now := time.Now()
+		// RemoteIP, not ClientIP: X-Forwarded-For is client-controlled and
+		// would let a caller pick a fresh bucket for every request.
+		ip := c.RemoteIP()
+
+		mu.Lock()
+		b, ok := buckets[ip]
+		if !ok {
+			// Forget idle clients so the map doesn't grow without bound
+			if len(buckets) >= 10000 {
+				for key, old := range buckets {
+					if now.Sub(old.last) > window {
+						delete(buckets, key)
+					}
+				}
+			}
+			b = &bucket{tokens: float64(limit), last: now}
+			buckets[ip] = b
+		}
+		b.tokens = math.Min(float64(limit), b.tokens+now.Sub(b.last).Seconds()*rate)
+		b.last = now
+		allowed := b.tokens >= 1
+		if allowed {
+			b.tokens--
+		}
+		wait := (1 - b.tokens) / rate
+		mu.Unlock()
+
+		if !allowed {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait))))
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
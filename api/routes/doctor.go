@@ -1,6 +1,8 @@
 package routes
 
 import (
+	"fmt"
+
 	"hospital/api/handlers"
 	"hospital/api/middleware"
 	"hospital/internal/config"
@@ -12,7 +14,15 @@ import (
 
 func RegisterDoctor(apiGroup *gin.RouterGroup, cfg config.Config, db *database.DB) {
 	doctorService := services.NewDoctorService(db, cfg)
-	doctorHandler := handlers.NewDoctorHandler(doctorService)
+	documentService := services.NewDocumentService(db, cfg)
+	doctorHandler := handlers.NewDoctorHandler(doctorService, documentService)
+	vitalsHandler := handlers.NewVitalsHandler(services.NewVitalsService(db, cfg), doctorService)
+	immunizationHandler := handlers.NewImmunizationHandler(services.NewImmunizationService(db, cfg), doctorService)
+
+	patientDocumentService := services.NewPatientDocumentService(db, cfg, newBlobStore(cfg))
+	patientDocumentHandler := handlers.NewPatientDocumentHandler(patientDocumentService, doctorService)
+	admissionHandler := handlers.NewAdmissionHandler(services.NewAdmissionService(db, cfg))
+	dischargeSummaryHandler := handlers.NewDischargeSummaryHandler(services.NewDischargeSummaryService(db, cfg), documentService, doctorService)
 
 	authGroup := apiGroup.Group("/doctor")
 	authGroup.Use(middleware.AuthMiddleware(cfg))
@@ -22,14 +32,102 @@ func RegisterDoctor(apiGroup *gin.RouterGroup, cfg config.Config, db *database.D
 	authGroup.GET("/patients", doctorHandler.GetPatients) //done
 	// New endpoint to fetch patient by ID
 	authGroup.GET("/patients/:patient_id", doctorHandler.GetPatientByID)
+	authGroup.GET("/patients/by-mrn/:mrn", doctorHandler.GetPatientByMRN)
 
 	// Prescription routes
-	authGroup.POST("/prescriptions/:patient_id", doctorHandler.CreatePrescription) //done
-	authGroup.PUT("/prescriptions/:patient_id", doctorHandler.UpdatePrescription)  //done
+	authGroup.POST("/patients/:patient_id/prescriptions", doctorHandler.CreatePrescription)
+	authGroup.GET("/patients/:patient_id/prescriptions", doctorHandler.GetPrescriptionsByPatient)
+	authGroup.GET("/prescriptions/:prescription_id", doctorHandler.GetPrescription)
+	authGroup.PUT("/prescriptions/:prescription_id", doctorHandler.UpdatePrescription)
+	authGroup.POST("/prescriptions/:prescription_id/discontinue", doctorHandler.DiscontinuePrescription)
+	authGroup.POST("/prescriptions/:prescription_id/complete", doctorHandler.CompletePrescription)
+	authGroup.GET("/prescriptions/:prescription_id/versions", doctorHandler.GetPrescriptionVersions)
+	authGroup.GET("/prescriptions/:prescription_id/pdf", doctorHandler.GetPrescriptionPDF)
+	// Deprecated: the path from before prescriptions were addressed by ID, kept
+	// for one release. The old GET and PUT /prescriptions/:patient_id can't be
+	// kept, since the new routes by prescription ID took their paths.
+	authGroup.POST("/prescriptions/:prescription_id", patientPathAlias("/api/doctor/patients/%s/prescriptions", doctorHandler.CreatePrescription))
+
+	// Vital sign routes
+	authGroup.POST("/patients/:patient_id/vitals", vitalsHandler.RecordVitals)
+	authGroup.GET("/patients/:patient_id/vitals", vitalsHandler.GetVitals)
+
+	// Immunization routes
+	authGroup.GET("/vaccines", immunizationHandler.GetVaccines)
+	authGroup.POST("/patients/:patient_id/immunizations", immunizationHandler.RecordImmunization)
+	authGroup.GET("/patients/:patient_id/immunizations", immunizationHandler.GetImmunizations)
+
+	// Refill inbox
+	authGroup.GET("/refills", doctorHandler.GetPendingRefills)
+	authGroup.POST("/refills/:refill_id/approve", doctorHandler.ApproveRefill)
+	authGroup.POST("/refills/:refill_id/deny", doctorHandler.DenyRefill)
+
+	// Patient document routes
+	authGroup.POST("/patients/:patient_id/documents", patientDocumentHandler.UploadDocument)
+	authGroup.GET("/patients/:patient_id/documents", patientDocumentHandler.GetDocuments)
+	authGroup.GET("/patients/:patient_id/documents/:document_id", patientDocumentHandler.DownloadDocument)
+	authGroup.DELETE("/patients/:patient_id/documents/:document_id", patientDocumentHandler.DeleteDocument)
+
+	// Referral routes
+	authGroup.POST("/patients/:patient_id/referrals", doctorHandler.CreateReferral)
+	authGroup.GET("/referrals", doctorHandler.GetReferrals)
+	authGroup.GET("/referrals/:referral_id", doctorHandler.GetReferral)
+	authGroup.POST("/referrals/:referral_id/accept", doctorHandler.AcceptReferral)
+	authGroup.POST("/referrals/:referral_id/decline", doctorHandler.DeclineReferral)
+	authGroup.POST("/referrals/:referral_id/cancel", doctorHandler.CancelReferral)
+	authGroup.POST("/referrals/:referral_id/complete", doctorHandler.CompleteReferral)
+	authGroup.POST("/referrals/:referral_id/appointment", doctorHandler.BookReferralAppointment)
+
+	// Lab routes
+	authGroup.GET("/lab-tests", doctorHandler.SearchLabTests)
+	authGroup.POST("/patients/:patient_id/lab-orders", doctorHandler.CreateLabOrder)
+	authGroup.GET("/patients/:patient_id/lab-orders", doctorHandler.GetLabOrdersByPatient)
+	authGroup.GET("/lab-results/unreviewed", doctorHandler.GetUnreviewedLabResults)
+	authGroup.POST("/lab-orders/:order_id/review", doctorHandler.ReviewLabOrder)
+
+	// Formulary routes
+	authGroup.GET("/formulary", doctorHandler.SearchFormulary)
+	// Runs the allergy and interaction checks without saving, for the UI to call before submitting
+	authGroup.POST("/patients/:patient_id/prescriptions/check", doctorHandler.CheckPrescription)
 
 	// Appointment routes
 	authGroup.GET("/appointments", doctorHandler.GetAppointments)               //done
 	authGroup.GET("/appointments/by-date", doctorHandler.GetAppointmentsByDate) //done
-	// New endpoint to fetch prescriptions by patient ID
-	authGroup.GET("/prescriptions/:patient_id", doctorHandler.GetPrescriptionsByPatient)
+	authGroup.GET("/appointments/:appointment_id/summary/pdf", doctorHandler.GetEncounterSummaryPDF)
+
+	// Diagnosis routes
+	authGroup.GET("/icd10", doctorHandler.SearchICD10Codes)
+	authGroup.POST("/patients/:patient_id/diagnoses", doctorHandler.CreateDiagnosis)
+	authGroup.GET("/patients/:patient_id/diagnoses", doctorHandler.GetDiagnosesByPatient)
+	authGroup.PUT("/diagnoses/:diagnosis_id", doctorHandler.UpdateDiagnosis)
+
+	// Allergy routes
+	authGroup.POST("/patients/:patient_id/allergies", doctorHandler.CreateAllergy)
+	authGroup.GET("/patients/:patient_id/allergies", doctorHandler.GetAllergiesByPatient)
+	authGroup.PUT("/allergies/:allergy_id", doctorHandler.UpdateAllergy)
+
+	// Inpatient routes; doctors see the admissions they are attending
+	authGroup.GET("/bed-board", admissionHandler.GetBedBoard)
+	authGroup.GET("/admissions", admissionHandler.GetAdmissions)
+	authGroup.GET("/admissions/:admission_id", admissionHandler.GetAdmission)
+
+	// Discharge summary routes
+	authGroup.POST("/admissions/:admission_id/discharge-summary", dischargeSummaryHandler.CreateDischargeSummary)
+	authGroup.GET("/admissions/:admission_id/discharge-summary", dischargeSummaryHandler.GetDischargeSummary)
+	authGroup.PUT("/admissions/:admission_id/discharge-summary", dischargeSummaryHandler.UpdateDischargeSummary)
+	authGroup.POST("/admissions/:admission_id/discharge-summary/sign", dischargeSummaryHandler.SignDischargeSummary)
+	authGroup.GET("/admissions/:admission_id/discharge-summary/pdf", dischargeSummaryHandler.GetDischargeSummaryPDF)
+	authGroup.GET("/admissions/:admission_id/discharge-summary/fhir", dischargeSummaryHandler.GetDischargeSummaryFHIR)
+}
+
+// patientPathAlias serves a deprecated route whose :prescription_id segment
+// holds a patient ID, pointing clients at the successor path.
+func patientPathAlias(successor string, handler gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		patientID := c.Param("prescription_id")
+		c.Params = append(c.Params, gin.Param{Key: "patient_id", Value: patientID})
+		c.Header("Deprecation", "true")
+		c.Header("Link", fmt.Sprintf("<"+successor+">; rel=\"successor-version\"", patientID))
+		handler(c)
+	}
 }
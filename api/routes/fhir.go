@@ -0,0 +1,52 @@
+package routes
+
+import (
+	"strings"
+
+	"hospital/api/handlers"
+	"hospital/api/middleware"
+	"hospital/internal/config"
+	"hospital/internal/database"
+	"hospital/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterFHIR registers the FHIR R4 API for other systems in the hospital
+// group. It sits outside /api at the base URL FHIR clients expect and uses the
+// same logins and access rules as the role APIs.
+func RegisterFHIR(rootGroup *gin.RouterGroup, cfg config.Config, db *database.DB) {
+	baseURL := ""
+	if cfg.ClinicConfig.PublicURL != "" {
+		baseURL = strings.TrimRight(cfg.ClinicConfig.PublicURL, "/") + "/fhir/r4"
+	}
+	exportService := services.NewFHIRExportService(db, cfg, newBlobStore(cfg))
+	fhirHandler := handlers.NewFHIRHandler(services.NewFHIRService(db, cfg), exportService, baseURL, cfg.ClinicConfig.Name)
+
+	fhirGroup := rootGroup.Group("/fhir/r4")
+	fhirGroup.GET("/metadata", fhirHandler.Metadata)
+	// Export files are fetched with signed links instead of a login
+	fhirGroup.GET("/$export-file/:id/:file", fhirHandler.DownloadExportFile)
+
+	authGroup := fhirGroup.Group("")
+	authGroup.Use(middleware.AuthMiddleware(cfg))
+
+	authGroup.POST("", fhirHandler.Transaction)
+	authGroup.GET("/$export", fhirHandler.Export)
+	authGroup.GET("/$export-status/:id", fhirHandler.ExportStatus)
+	authGroup.DELETE("/$export-status/:id", fhirHandler.CancelExport)
+	authGroup.GET("/Patient", fhirHandler.SearchPatients)
+	authGroup.POST("/Patient", fhirHandler.CreatePatient)
+	authGroup.GET("/Patient/:id", fhirHandler.GetPatient)
+	authGroup.PUT("/Patient/:id", fhirHandler.UpdatePatient)
+	authGroup.GET("/Patient/:id/_history/:vid", fhirHandler.GetPatient)
+	authGroup.GET("/Practitioner", fhirHandler.SearchPractitioners)
+	authGroup.GET("/Practitioner/:id", fhirHandler.GetPractitioner)
+	authGroup.GET("/Appointment", fhirHandler.SearchAppointments)
+	authGroup.POST("/Appointment", fhirHandler.CreateAppointment)
+	authGroup.GET("/Appointment/:id", fhirHandler.GetAppointment)
+	authGroup.PUT("/Appointment/:id", fhirHandler.UpdateAppointment)
+	authGroup.GET("/Appointment/:id/_history/:vid", fhirHandler.GetAppointment)
+	authGroup.GET("/MedicationRequest", fhirHandler.SearchMedicationRequests)
+	authGroup.GET("/MedicationRequest/:id", fhirHandler.GetMedicationRequest)
+}
@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"hospital/api/handlers"
+	"hospital/api/middleware"
+	"hospital/internal/config"
+	"hospital/internal/database"
+	"hospital/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterLab registers the routes the lab system uses to collect specimens and
+// report results. They are authenticated with a lab API key instead of a user
+// login.
+func RegisterLab(apiGroup *gin.RouterGroup, cfg config.Config, db *database.DB) {
+	labService := services.NewLabService(db, cfg)
+	labHandler := handlers.NewLabHandler(labService)
+
+	labGroup := apiGroup.Group("/lab")
+	labGroup.Use(middleware.APIKeyMiddleware(db, "lab"))
+
+	labGroup.GET("/orders", labHandler.GetWorklist)
+	labGroup.POST("/orders/:order_id/collect", labHandler.CollectSpecimen)
+	labGroup.POST("/orders/:order_id/result", labHandler.RecordResult)
+}
@@ -0,0 +1,37 @@
+package routes
+
+import (
+	"hospital/api/handlers"
+	"hospital/api/middleware"
+	"hospital/internal/config"
+	"hospital/internal/database"
+	"hospital/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterNurse(apiGroup *gin.RouterGroup, cfg config.Config, db *database.DB) {
+	doctorService := services.NewDoctorService(db, cfg)
+	vitalsHandler := handlers.NewVitalsHandler(services.NewVitalsService(db, cfg), doctorService)
+	immunizationHandler := handlers.NewImmunizationHandler(services.NewImmunizationService(db, cfg), doctorService)
+	admissionHandler := handlers.NewAdmissionHandler(services.NewAdmissionService(db, cfg))
+
+	authGroup := apiGroup.Group("/nurse")
+	authGroup.Use(middleware.AuthMiddleware(cfg))
+	authGroup.Use(middleware.RoleMiddleware("nurse"))
+
+	// Vital sign routes
+	authGroup.POST("/patients/:patient_id/vitals", vitalsHandler.RecordVitals)
+	authGroup.GET("/patients/:patient_id/vitals", vitalsHandler.GetVitals)
+
+	// Immunization routes
+	authGroup.GET("/vaccines", immunizationHandler.GetVaccines)
+	authGroup.POST("/patients/:patient_id/immunizations", immunizationHandler.RecordImmunization)
+	authGroup.GET("/patients/:patient_id/immunizations", immunizationHandler.GetImmunizations)
+
+	// Ward routes; nurses turn beds around after cleaning
+	authGroup.GET("/bed-board", admissionHandler.GetBedBoard)
+	authGroup.PUT("/beds/:bed_id/status", admissionHandler.SetBedStatus)
+	authGroup.GET("/admissions", admissionHandler.GetAdmissions)
+	authGroup.GET("/admissions/:admission_id", admissionHandler.GetAdmission)
+}
@@ -1,6 +1,8 @@
 package routes
 
 import (
+	"log"
+
 	"hospital/api/handlers"
 	"hospital/api/middleware"
 	"hospital/internal/config"
@@ -13,7 +15,22 @@ import (
 func RegisterReceptionist(apiGroup *gin.RouterGroup, cfg config.Config, db *database.DB) {
 	// Create service interface - this returns the interface, not concrete type
 	receptionistService := services.NewReceptionistService(db, cfg)
-	receptionistHandler := handlers.NewReceptionistHandler(receptionistService)
+	documentService := services.NewDocumentService(db, cfg)
+	eligibilityChecker, err := services.NewEligibilityChecker(cfg)
+	if err != nil {
+		log.Fatal("Failed to set up eligibility checks:", err)
+	}
+	insuranceService := services.NewInsuranceService(db, cfg, eligibilityChecker)
+	receptionistHandler := handlers.NewReceptionistHandler(receptionistService, documentService, insuranceService)
+	insuranceHandler := handlers.NewInsuranceHandler(insuranceService)
+	patientDocumentService := services.NewPatientDocumentService(db, cfg, newBlobStore(cfg))
+	patientDocumentHandler := handlers.NewPatientDocumentHandler(patientDocumentService, services.NewDoctorService(db, cfg))
+	billingHandler := handlers.NewBillingHandler(services.NewBillingService(db, cfg))
+	claimHandler := handlers.NewClaimHandler(services.NewClaimService(db, cfg))
+	drawerHandler := handlers.NewDrawerHandler(services.NewDrawerService(db, cfg))
+	reportHandler := handlers.NewReportHandler(services.NewReportService(db, cfg))
+	admissionHandler := handlers.NewAdmissionHandler(services.NewAdmissionService(db, cfg))
+	immunizationHandler := handlers.NewImmunizationHandler(services.NewImmunizationService(db, cfg), services.NewDoctorService(db, cfg))
 
 	authGroup := apiGroup.Group("/receptionist")
 	authGroup.Use(middleware.AuthMiddleware(cfg))
@@ -23,9 +40,36 @@ func RegisterReceptionist(apiGroup *gin.RouterGroup, cfg config.Config, db *data
 	authGroup.POST("/patients", receptionistHandler.CreatePatient)
 	authGroup.GET("/patients", receptionistHandler.GetPatients)
 	authGroup.GET("/patients/:patient_id", receptionistHandler.GetPatient)
+	authGroup.GET("/patients/by-mrn/:mrn", receptionistHandler.GetPatientByMRN)
 	authGroup.PUT("/patients/:patient_id", receptionistHandler.UpdatePatient)
 	authGroup.DELETE("/patients/:patient_id", receptionistHandler.DeletePatient)
 
+	// Care team routes
+	authGroup.GET("/patients/:patient_id/care-team", receptionistHandler.GetCareTeam)
+	authGroup.POST("/patients/:patient_id/care-team", receptionistHandler.AddCareTeamMember)
+	authGroup.POST("/patients/:patient_id/care-team/:member_id/end", receptionistHandler.EndCareTeamMember)
+
+	// Contact routes
+	authGroup.GET("/patients/:patient_id/contacts", receptionistHandler.GetContacts)
+	authGroup.POST("/patients/:patient_id/contacts", receptionistHandler.CreateContact)
+	authGroup.PUT("/patients/:patient_id/contacts/:contact_id", receptionistHandler.UpdateContact)
+	authGroup.DELETE("/patients/:patient_id/contacts/:contact_id", receptionistHandler.DeleteContact)
+
+	// Insurance routes
+	authGroup.GET("/insurance/payers", insuranceHandler.GetPayers)
+	authGroup.POST("/insurance/payers", insuranceHandler.CreatePayer)
+	authGroup.PUT("/insurance/payers/:payer_id", insuranceHandler.UpdatePayer)
+	authGroup.GET("/patients/:patient_id/insurance", insuranceHandler.GetPolicies)
+	authGroup.POST("/patients/:patient_id/insurance", insuranceHandler.CreatePolicy)
+	authGroup.PUT("/patients/:patient_id/insurance/:policy_id", insuranceHandler.UpdatePolicy)
+	authGroup.DELETE("/patients/:patient_id/insurance/:policy_id", insuranceHandler.DeletePolicy)
+	authGroup.POST("/patients/:patient_id/insurance/:policy_id/eligibility", insuranceHandler.CheckEligibility)
+
+	// Patient documents, e.g. scanned insurance cards
+	authGroup.POST("/patients/:patient_id/documents", patientDocumentHandler.UploadDocument)
+	authGroup.GET("/patients/:patient_id/documents", patientDocumentHandler.GetDocuments)
+	authGroup.GET("/patients/:patient_id/documents/:document_id", patientDocumentHandler.DownloadDocument)
+
 	// Appointment routes -
 	authGroup.POST("/patients/:patient_id/appointments", receptionistHandler.CreateAppointment)                //done
 	authGroup.GET("/patients/:patient_id/appointments", receptionistHandler.GetAppointments)                   //done
@@ -34,4 +78,71 @@ func RegisterReceptionist(apiGroup *gin.RouterGroup, cfg config.Config, db *data
 	authGroup.DELETE("/patients/:patient_id/appointments/:appointment_id", receptionistHandler.DeleteAppointment)
 	// New endpoint to fetch all appointments
 	authGroup.GET("/appointments", receptionistHandler.GetAllAppointments)
+	authGroup.GET("/appointments/:appointment_id/summary/pdf", receptionistHandler.GetEncounterSummaryPDF)
+
+	// Prescription and refill routes
+	authGroup.GET("/patients/:patient_id/prescriptions", receptionistHandler.GetPatientPrescriptions)
+	authGroup.POST("/patients/:patient_id/prescriptions/:prescription_id/refill-requests", receptionistHandler.CreateRefillRequest)
+	authGroup.GET("/patients/:patient_id/refill-requests", receptionistHandler.GetRefillRequests)
+
+	// Document reprints
+	authGroup.GET("/prescriptions/:prescription_id/pdf", receptionistHandler.GetPrescriptionPDF)
+
+	// Ward and bed routes
+	authGroup.GET("/wards", admissionHandler.GetWards)
+	authGroup.POST("/wards", admissionHandler.CreateWard)
+	authGroup.PUT("/wards/:ward_id", admissionHandler.UpdateWard)
+	authGroup.POST("/wards/:ward_id/rooms", admissionHandler.CreateRoom)
+	authGroup.POST("/rooms/:room_id/beds", admissionHandler.CreateBed)
+	authGroup.PUT("/beds/:bed_id/status", admissionHandler.SetBedStatus)
+	authGroup.GET("/bed-board", admissionHandler.GetBedBoard)
+
+	// Admission routes
+	authGroup.POST("/admissions", admissionHandler.AdmitPatient)
+	authGroup.GET("/admissions", admissionHandler.GetAdmissions)
+	authGroup.GET("/admissions/:admission_id", admissionHandler.GetAdmission)
+	authGroup.POST("/admissions/:admission_id/transfer", admissionHandler.TransferPatient)
+	authGroup.POST("/admissions/:admission_id/discharge", admissionHandler.DischargePatient)
+	authGroup.GET("/admissions/:admission_id/discharge-summary/pdf", receptionistHandler.GetDischargeSummaryPDF)
+
+	// Immunization outreach
+	authGroup.GET("/immunizations/overdue", immunizationHandler.GetOverdueReport)
+
+	// Billing routes
+	billingGroup := authGroup.Group("/billing")
+	billingGroup.GET("/prices", billingHandler.GetPrices)
+	billingGroup.POST("/prices", billingHandler.CreatePrice)
+	billingGroup.PUT("/prices/:price_id", billingHandler.UpdatePrice)
+	billingGroup.GET("/patients/:patient_id/charges", billingHandler.GetCharges)
+	billingGroup.POST("/patients/:patient_id/charges", billingHandler.CreateCharge)
+	billingGroup.DELETE("/patients/:patient_id/charges/:charge_id", billingHandler.DeleteCharge)
+	billingGroup.POST("/patients/:patient_id/invoices", billingHandler.CreateInvoice)
+	billingGroup.GET("/invoices", billingHandler.GetInvoices)
+	billingGroup.GET("/invoices/:invoice_id", billingHandler.GetInvoice)
+	billingGroup.POST("/invoices/:invoice_id/void", billingHandler.VoidInvoice)
+	billingGroup.POST("/invoices/:invoice_id/payments", billingHandler.RecordPayment)
+	billingGroup.POST("/payments/:payment_id/refunds", billingHandler.RefundPayment)
+
+	// Insurance claims and remittances
+	billingGroup.POST("/appointments/:appointment_id/claims", claimHandler.CreateClaim)
+	billingGroup.GET("/claims", claimHandler.GetClaims)
+	billingGroup.GET("/claims/:claim_id", claimHandler.GetClaim)
+	billingGroup.DELETE("/claims/:claim_id", claimHandler.DeleteClaim)
+	billingGroup.POST("/claims/:claim_id/submit", claimHandler.SubmitClaim)
+	billingGroup.POST("/claims/:claim_id/response", claimHandler.RecordClaimResponse)
+	billingGroup.POST("/claims/:claim_id/resolve", claimHandler.ResolveFollowUp)
+	billingGroup.POST("/remittances", claimHandler.ImportRemittance)
+	billingGroup.GET("/remittances/:batch_id", claimHandler.GetRemittance)
+
+	// Cash drawer closes
+	billingGroup.GET("/drawer", drawerHandler.GetOpenDrawer)
+	billingGroup.POST("/drawer/close", drawerHandler.CloseDrawer)
+	billingGroup.GET("/drawer/closes", drawerHandler.GetDrawerCloses)
+	billingGroup.GET("/drawer/closes/:close_id", drawerHandler.GetDrawerClose)
+
+	// Financial reports
+	billingGroup.GET("/reports/revenue/daily", reportHandler.DailyRevenue)
+	billingGroup.GET("/reports/revenue/monthly", reportHandler.MonthlyRevenue)
+	billingGroup.GET("/reports/revenue/doctors", reportHandler.DoctorRevenue)
+	billingGroup.GET("/reports/aging", reportHandler.ReceivablesAging)
 }
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"log"
+
+	"hospital/internal/config"
+	"hospital/internal/storage"
+)
+
+// newBlobStore opens the configured blob store or stops the server, since
+// uploads cannot work without it.
+func newBlobStore(cfg config.Config) storage.BlobStore {
+	blobStore, err := storage.New(cfg.StorageConfig)
+	if err != nil {
+		log.Fatal("Failed to open blob storage:", err)
+	}
+	return blobStore
+}
@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"time"
+
+	"hospital/api/handlers"
+	"hospital/api/middleware"
+	"hospital/internal/config"
+	"hospital/internal/database"
+	"hospital/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterVerify registers the public prescription verification routes used by
+// pharmacies. They sit outside /api since they need no user login.
+func RegisterVerify(rootGroup *gin.RouterGroup, cfg config.Config, db *database.DB) {
+	verificationService := services.NewVerificationService(db, cfg)
+	verificationHandler := handlers.NewVerificationHandler(verificationService)
+
+	limit := cfg.APIConfig.VerifyRateLimit
+	if limit <= 0 {
+		limit = 30
+	}
+
+	verifyGroup := rootGroup.Group("/verify")
+	verifyGroup.Use(middleware.RateLimitMiddleware(limit, time.Minute))
+
+	verifyGroup.GET("/prescription/:code", verificationHandler.VerifyPrescription)
+	verifyGroup.POST("/prescription/:code/dispense", middleware.APIKeyMiddleware(db, "pharmacy"), verificationHandler.DispensePrescription)
+}
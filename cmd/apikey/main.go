@@ -0,0 +1,82 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+
+	"hospital/internal/config"
+	"hospital/internal/database"
+	"hospital/internal/models"
+)
+
+// Usage:
+//
+//	apikey create <name> <scope>   issue a key, printed once
+//	apikey revoke <id>             deactivate a key
+//	apikey list                    show issued keys
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	cfg := config.New()
+	db := database.Connect(cfg.DatabaseConfig)
+
+	switch os.Args[1] {
+	case "create":
+		if len(os.Args) != 4 {
+			usage()
+		}
+		if !models.APIKeyScopes[os.Args[3]] {
+			log.Fatalf("Unknown scope %q", os.Args[3])
+		}
+		raw := make([]byte, 32)
+		if _, err := rand.Read(raw); err != nil {
+			log.Fatal("Failed to generate key:", err)
+		}
+		key := "hk_" + base64.RawURLEncoding.EncodeToString(raw)
+		sum := sha256.Sum256([]byte(key))
+
+		apiKey := models.APIKey{Name: os.Args[2], Scope: os.Args[3], KeyHash: hex.EncodeToString(sum[:]), Active: true}
+		if err := db.Conn.Create(&apiKey).Error; err != nil {
+			log.Fatal("Failed to create key:", err)
+		}
+		fmt.Printf("id:  %s\nkey: %s\n", apiKey.ID, key)
+		fmt.Println("Store the key now, it cannot be shown again.")
+
+	case "revoke":
+		if len(os.Args) != 3 {
+			usage()
+		}
+		result := db.Conn.Model(&models.APIKey{}).Where("id = ?", os.Args[2]).Update("active", false)
+		if result.Error != nil {
+			log.Fatal("Failed to revoke key:", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			log.Fatal("API key not found")
+		}
+		fmt.Println("Key revoked")
+
+	case "list":
+		var keys []models.APIKey
+		if err := db.Conn.Order("created_at").Find(&keys).Error; err != nil {
+			log.Fatal("Failed to list keys:", err)
+		}
+		for _, k := range keys {
+			fmt.Printf("%s  %-10s %-8v %s\n", k.ID, k.Scope, k.Active, k.Name)
+		}
+
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: apikey create <name> <scope> | apikey revoke <id> | apikey list")
+	os.Exit(2)
+}
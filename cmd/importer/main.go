@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"hospital/internal/config"
+	"hospital/internal/database"
+	"hospital/internal/importer"
+)
+
+// catalogs maps the catalog name given on the command line to its CSV importer.
+var catalogs = map[string]func(db *gorm.DB, r io.Reader) (int, error){
+	"icd10":        importer.ImportICD10,
+	"formulary":    importer.ImportFormulary,
+	"interactions": importer.ImportInteractions,
+	"lab_tests":    importer.ImportLabTests,
+	"vaccines":     importer.ImportVaccines,
+}
+
+func main() {
+	if len(os.Args) != 3 {
+		usage()
+	}
+
+	run, ok := catalogs[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown catalog %q\n", os.Args[1])
+		usage()
+	}
+
+	f, err := os.Open(os.Args[2])
+	if err != nil {
+		log.Fatal("Failed to open file:", err)
+	}
+	defer f.Close()
+
+	cfg := config.New()
+	db := database.Connect(cfg.DatabaseConfig)
+
+	count, err := run(db.Conn, f)
+	if err != nil {
+		log.Fatalf("Import of %s stopped after %d rows: %v", os.Args[1], count, err)
+	}
+	log.Printf("Imported %d %s rows", count, os.Args[1])
+}
+
+func usage() {
+	names := make([]string, 0, len(catalogs))
+	for name := range catalogs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintln(os.Stderr, "usage: importer <catalog> <file.csv>")
+	fmt.Fprintln(os.Stderr, "catalogs:", strings.Join(names, ", "))
+	os.Exit(2)
+}
@@ -1,10 +1,13 @@
 package main
 
 import (
+	"log"
+
 	"hospital/api"
 	"hospital/internal/config"
 	"hospital/internal/database"
 	"hospital/internal/seeder"
+	"hospital/internal/services"
 )
 
 func main() {
@@ -12,6 +15,15 @@ func main() {
 	cfg := config.New()
 	db := database.Connect(cfg.DatabaseConfig)
 	seeder.SeedUsers(db.Conn)
+	if err := services.AssignMissingMRNs(db, cfg); err != nil {
+		log.Fatal("Failed to assign medical record numbers:", err)
+	}
+	if err := services.AssignMissingVerificationCodes(db); err != nil {
+		log.Fatal("Failed to assign prescription verification codes:", err)
+	}
+	if err := services.FailInterruptedExports(db); err != nil {
+		log.Fatal("Failed to clean up interrupted FHIR exports:", err)
+	}
 
 	api := api.New(db, cfg)
 	api.Run(cfg.APIConfig.Port)
@@ -10,13 +10,100 @@ import (
 )
 
 type Config struct {
-	DatabaseConfig DatabaseConfig `mapstructure:"database"`
-	JwtConfig      JwtConfig      `mapstructure:"auth"`
-	APIConfig      APIConfig      `mapstructure:"api"`
+	DatabaseConfig  DatabaseConfig  `mapstructure:"database"`
+	JwtConfig       JwtConfig       `mapstructure:"auth"`
+	APIConfig       APIConfig       `mapstructure:"api"`
+	ClinicalConfig  ClinicalConfig  `mapstructure:"clinical"`
+	ClinicConfig    ClinicConfig    `mapstructure:"clinic"`
+	StorageConfig   StorageConfig   `mapstructure:"storage"`
+	DocumentConfig  DocumentConfig  `mapstructure:"documents"`
+	PatientConfig   PatientConfig   `mapstructure:"patients"`
+	InsuranceConfig InsuranceConfig `mapstructure:"insurance"`
+	BillingConfig   BillingConfig   `mapstructure:"billing"`
+	ExportConfig    ExportConfig    `mapstructure:"export"`
+}
+
+// ExportConfig controls FHIR bulk data exports.
+type ExportConfig struct {
+	// LinkTTLMinutes is how long a download link handed out by the export
+	// status endpoint works. Polling the status again gives fresh links.
+	LinkTTLMinutes int `mapstructure:"link_ttl_minutes"`
+	// RetentionHours is how long the files of a completed export are kept.
+	RetentionHours int `mapstructure:"retention_hours"`
+}
+
+type BillingConfig struct {
+	Currency string `mapstructure:"currency"`
+	// FiscalYearStartMonth is the month (1-12) the fiscal year starts in.
+	// A fiscal year is named after the calendar year it starts in, and
+	// invoice numbers restart at 1 every fiscal year.
+	FiscalYearStartMonth int    `mapstructure:"fiscal_year_start_month"`
+	InvoicePrefix        string `mapstructure:"invoice_prefix"`
+	PaymentTermsDays     int    `mapstructure:"payment_terms_days"`
+}
+
+type InsuranceConfig struct {
+	// EligibilityProvider selects the eligibility checker. Only "stub" exists
+	// until a clearinghouse is integrated.
+	EligibilityProvider string `mapstructure:"eligibility_provider"`
+}
+
+type PatientConfig struct {
+	// MRNFormat is the template for new medical record numbers. {YYYY} and {YY}
+	// are replaced by the registration year and {SEQ:n} by a sequence number
+	// padded to n digits, e.g. "HOSP-{YYYY}-{SEQ:6}" gives HOSP-2026-000123.
+	// The sequence restarts for every distinct prefix.
+	MRNFormat string `mapstructure:"mrn_format"`
+}
+
+// StorageConfig selects where uploaded files are kept. Driver is "local" or "s3".
+type StorageConfig struct {
+	Driver    string   `mapstructure:"driver"`
+	LocalPath string   `mapstructure:"local_path"`
+	S3        S3Config `mapstructure:"s3"`
+}
+
+// S3Config points at an S3-compatible service; MinIO works for local setups.
+type S3Config struct {
+	Endpoint  string `mapstructure:"endpoint"`
+	Region    string `mapstructure:"region"`
+	Bucket    string `mapstructure:"bucket"`
+	AccessKey string `mapstructure:"access_key"`
+	SecretKey string `mapstructure:"secret_key"`
+	UseSSL    bool   `mapstructure:"use_ssl"`
+}
+
+// DocumentConfig limits the patient documents that may be uploaded.
+type DocumentConfig struct {
+	MaxUploadBytes int64 `mapstructure:"max_upload_bytes"`
+	// AllowedTypes lists the accepted MIME types, checked against the sniffed
+	// content rather than the type the client claims. Defaults to PDF, JPEG,
+	// PNG and TIFF when unset.
+	AllowedTypes []string `mapstructure:"allowed_types"`
+}
+
+// ClinicConfig holds the letterhead printed on generated documents.
+type ClinicConfig struct {
+	Name    string `mapstructure:"name"`
+	Address string `mapstructure:"address"`
+	Phone   string `mapstructure:"phone"`
+	Email   string `mapstructure:"email"`
+	// PublicURL is the externally reachable base URL of this service, used for
+	// the verification links printed on prescriptions.
+	PublicURL string `mapstructure:"public_url"`
+}
+
+type ClinicalConfig struct {
+	// DrugClasses maps a drug class to its member drugs, e.g. penicillins -> amoxicillin.
+	// An allergy to a class or to any member is treated as an allergy to every member.
+	DrugClasses map[string][]string `mapstructure:"drug_classes"`
 }
 
 type APIConfig struct {
 	Port int `mapstructure:"port"`
+	// VerifyRateLimit is the number of public prescription verification
+	// requests allowed per client IP per minute.
+	VerifyRateLimit int `mapstructure:"verify_rate_limit"`
 }
 
 type JwtConfig struct {
@@ -91,6 +178,23 @@ func (c *Config) PopulateEnv() {
 		}
 	}
 
+	if env := os.Getenv("CLINIC_PUBLIC_URL"); env != "" {
+		c.ClinicConfig.PublicURL = env
+	}
+
+	if env := os.Getenv("STORAGE_DRIVER"); env != "" {
+		c.StorageConfig.Driver = env
+	}
+	if env := os.Getenv("S3_ENDPOINT"); env != "" {
+		c.StorageConfig.S3.Endpoint = env
+	}
+	if env := os.Getenv("S3_ACCESS_KEY"); env != "" {
+		c.StorageConfig.S3.AccessKey = env
+	}
+	if env := os.Getenv("S3_SECRET_KEY"); env != "" {
+		c.StorageConfig.S3.SecretKey = env
+	}
+
 	if env := os.Getenv("DB_HOST"); env != "" {
 		c.DatabaseConfig.Host = env
 	}
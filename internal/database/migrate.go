@@ -0,0 +1,129 @@
+package database
+
+import (
+	"hospital/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// migrate brings the schema up to date. AutoMigrate creates tables and columns;
+// the statements after it cover what AutoMigrate can't express, such as
+// changed CHECK constraints, expression indexes and data backfills. Every
+// statement must be safe to run on each start. It stops at the first
+// statement that fails and returns its error.
+func migrate(db *gorm.DB) error {
+	// Once a statement fails the rest are skipped, since they build on it
+	var err error
+	exec := func(sql string) {
+		if err == nil {
+			err = db.Exec(sql).Error
+		}
+	}
+
+	// Existing patients get their MRN from services.AssignMissingMRNs, which
+	// needs the configured format, so the column starts out nullable
+	exec("ALTER TABLE IF EXISTS patients ADD COLUMN IF NOT EXISTS mrn text")
+	if err == nil {
+		err = db.AutoMigrate(&models.User{}, &models.Patient{}, &models.Appointment{}, &models.Prescription{},
+			&models.ICD10Code{}, &models.Diagnosis{}, &models.PatientAllergy{},
+			&models.FormularyItem{}, &models.DrugInteraction{}, &models.AuditEvent{},
+			&models.PrescriptionVersion{}, &models.APIKey{},
+			&models.RefillRequest{}, &models.Vitals{},
+			&models.LabTest{}, &models.LabReferenceRange{}, &models.LabOrder{}, &models.LabResult{},
+			&models.PatientDocument{}, &models.Referral{}, &models.CareTeamMember{},
+			&models.MRNSequence{}, &models.PatientContact{},
+			&models.InsurancePayer{}, &models.InsurancePolicy{},
+			&models.ServicePrice{}, &models.Charge{}, &models.Invoice{}, &models.InvoiceLine{}, &models.Payment{},
+			&models.InvoiceSequence{}, &models.DrawerClose{}, &models.Refund{},
+			&models.Claim{}, &models.ClaimDiagnosis{}, &models.ClaimLine{}, &models.RemittanceBatch{}, &models.RemittanceLine{},
+			&models.Ward{}, &models.Room{}, &models.Bed{}, &models.Admission{}, &models.BedTransfer{},
+			&models.DischargeSummary{},
+			&models.Vaccine{}, &models.VaccineScheduleRule{}, &models.Immunization{},
+			&models.FHIRExport{})
+	}
+	// AutoMigrate doesn't touch existing CHECK constraints, so widen the role check by hand
+	exec("ALTER TABLE users DROP CONSTRAINT IF EXISTS users_role_check")
+	exec("ALTER TABLE users ADD CONSTRAINT users_role_check CHECK (role IN ('receptionist','doctor','nurse'))")
+	// Full-text index backing the ICD-10 description search
+	exec("CREATE INDEX IF NOT EXISTS idx_icd10_codes_description_fts ON icd10_codes USING gin (to_tsvector('english', description))")
+	// Prescriptions written before versioning get their current state as version 1
+	exec(`INSERT INTO prescription_versions (prescription_id, version, change_type, changed_by, medication, dosage,
+		instructions, formulary_item_id, free_text, dose, dose_unit, frequency, route, duration_days, quantity, status, created_at)
+		SELECT p.id, p.version, 'created', p.doctor_id, p.medication, p.dosage, p.instructions, p.formulary_item_id, p.free_text,
+		p.dose, p.dose_unit, p.frequency, p.route, p.duration_days, p.quantity, p.status, p.created_at
+		FROM prescriptions p WHERE NOT EXISTS (SELECT 1 FROM prescription_versions v WHERE v.prescription_id = p.id)`)
+	// A prescription has one pending refill request, and each refill number is
+	// issued once
+	exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_refill_requests_pending ON refill_requests (prescription_id)
+		WHERE status = 'pending'`)
+	exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_prescriptions_refill_number ON prescriptions (refill_of_id, refill_number)
+		WHERE refill_of_id IS NOT NULL`)
+	// The single doctor link on patients is replaced by care teams: the old
+	// doctor becomes the primary member from the day the patient was registered
+	exec(`DO $$ BEGIN
+		IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name = 'patients' AND column_name = 'user_id') THEN
+			INSERT INTO care_team_members (patient_id, doctor_id, role, effective_from, created_at, updated_at)
+			SELECT p.id, p.user_id, 'primary', p.created_at, now(), now() FROM patients p
+			WHERE NOT EXISTS (SELECT 1 FROM care_team_members m WHERE m.patient_id = p.id AND m.role = 'primary');
+			ALTER TABLE patients DROP COLUMN user_id;
+		END IF;
+	END $$`)
+	exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_care_team_members_open_primary ON care_team_members (patient_id)
+		WHERE role = 'primary' AND effective_to IS NULL`)
+	// Free-text addresses become the first line of the structured address
+	exec("UPDATE patients SET address_line1 = address WHERE (address_line1 IS NULL OR address_line1 = '') AND address <> ''")
+	// A completed appointment's fee is captured once, and only one active
+	// price applies to an appointment type and doctor
+	exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_charges_appointment_price ON charges (appointment_id, service_price_id)
+		WHERE appointment_id IS NOT NULL AND service_price_id IS NOT NULL`)
+	exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_service_prices_active_fee
+		ON service_prices (appointment_type, COALESCE(doctor_id, '00000000-0000-0000-0000-000000000000'))
+		WHERE active AND appointment_type <> ''`)
+	// Claim numbers are the claim control numbers payers echo back in remittances
+	exec("CREATE SEQUENCE IF NOT EXISTS claim_number_seq")
+	// An encounter is claimed once per policy unless the claim was denied
+	exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_claims_open_per_policy ON claims (appointment_id, insurance_policy_id)
+		WHERE status <> 'denied'`)
+	// Payments and refunds in a closed drawer are final
+	exec(`CREATE OR REPLACE FUNCTION prevent_closed_drawer_change() RETURNS trigger AS $$
+	BEGIN
+		IF OLD.drawer_close_id IS NOT NULL THEN
+			RAISE EXCEPTION '% % is in a closed cash drawer', TG_TABLE_NAME, OLD.id;
+		END IF;
+		RETURN CASE WHEN TG_OP = 'DELETE' THEN OLD ELSE NEW END;
+	END $$ LANGUAGE plpgsql`)
+	for _, table := range []string{"payments", "refunds"} {
+		exec("DROP TRIGGER IF EXISTS " + table + "_closed_drawer ON " + table)
+		exec("CREATE TRIGGER " + table + "_closed_drawer BEFORE UPDATE OR DELETE ON " + table +
+			" FOR EACH ROW EXECUTE FUNCTION prevent_closed_drawer_change()")
+	}
+	// A patient has one admission in progress and a bed holds one patient
+	exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_admissions_active_patient ON admissions (patient_id)
+		WHERE status = 'admitted'`)
+	exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_admissions_active_bed ON admissions (bed_id)
+		WHERE status = 'admitted'`)
+	// Signed discharge summaries are final
+	exec(`CREATE OR REPLACE FUNCTION prevent_signed_summary_change() RETURNS trigger AS $$
+	BEGIN
+		IF OLD.status = 'signed' THEN
+			RAISE EXCEPTION 'discharge summary % is signed', OLD.id;
+		END IF;
+		RETURN CASE WHEN TG_OP = 'DELETE' THEN OLD ELSE NEW END;
+	END $$ LANGUAGE plpgsql`)
+	exec("DROP TRIGGER IF EXISTS discharge_summaries_signed ON discharge_summaries")
+	exec(`CREATE TRIGGER discharge_summaries_signed BEFORE UPDATE OR DELETE ON discharge_summaries
+		FOR EACH ROW EXECUTE FUNCTION prevent_signed_summary_change()`)
+	// Every change to a patient or appointment, through any API, gets a new
+	// version so FHIR clients can detect conflicting updates
+	exec(`CREATE OR REPLACE FUNCTION bump_version() RETURNS trigger AS $$
+	BEGIN
+		NEW.version := OLD.version + 1;
+		RETURN NEW;
+	END $$ LANGUAGE plpgsql`)
+	for _, table := range []string{"patients", "appointments"} {
+		exec("DROP TRIGGER IF EXISTS " + table + "_version ON " + table)
+		exec("CREATE TRIGGER " + table + "_version BEFORE UPDATE ON " + table +
+			" FOR EACH ROW EXECUTE FUNCTION bump_version()")
+	}
+	return err
+}
@@ -3,7 +3,6 @@ package database
 import (
 	"fmt"
 	"hospital/internal/config"
-	"hospital/internal/models"
 	"log"
 
 	"gorm.io/driver/postgres"
@@ -24,7 +23,9 @@ func Connect(cfg config.DatabaseConfig) *DB {
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
-	db.Conn.AutoMigrate(&models.User{}, &models.Patient{}, &models.Appointment{}, &models.Prescription{}) //  User and Patient models are migrated
+	if err := migrate(db.Conn); err != nil {
+		log.Fatal("Failed to migrate database:", err)
+	}
 	log.Println("Connected to database successfully")
 	return db
 }
@@ -0,0 +1,125 @@
+package documents
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"hospital/internal/config"
+	"hospital/internal/models"
+)
+
+// DischargeSummaryDocument is the content of a discharge summary given to the
+// patient and their doctors when an admission ends.
+type DischargeSummaryDocument struct {
+	Clinic  config.ClinicConfig
+	Summary models.DischargeSummary // With its admission, patient and doctor loaded
+}
+
+// WriteDischargeSummary renders a discharge summary. Drafts are marked as such
+// and carry no signature line.
+func WriteDischargeSummary(w io.Writer, doc DischargeSummaryDocument) error {
+	summary := doc.Summary
+	title := "Discharge summary"
+	if summary.Status != "signed" {
+		title = "DRAFT discharge summary"
+	}
+	d := newDocument(doc.Clinic, title)
+
+	d.pdf.SetFont("Helvetica", "B", 14)
+	d.pdf.CellFormat(0, 8, d.tr(title), "", 1, "C", false, 0, "")
+	if admission := summary.Admission; admission != nil {
+		d.field("Admitted", admission.AdmittedAt.Format("02/01/2006 15:04"))
+		if admission.DischargedAt != nil {
+			d.field("Discharged", admission.DischargedAt.Format("02/01/2006 15:04"))
+		}
+		if admission.Bed != nil && admission.Bed.Ward != nil {
+			d.field("Ward", admission.Bed.Ward.Name)
+		}
+		d.field("Disposition", admission.Disposition)
+	}
+
+	if summary.Patient != nil {
+		d.patient(*summary.Patient)
+	}
+	if summary.Doctor != nil {
+		d.doctor(*summary.Doctor)
+	}
+
+	d.section("Reason for admission", summary.AdmissionReason)
+	d.section("Hospital course", summary.HospitalCourse)
+
+	if len(summary.Diagnoses) > 0 {
+		d.heading("Diagnoses")
+		for _, diagnosis := range summary.Diagnoses {
+			line := diagnosis.Code
+			if diagnosis.Description != "" {
+				line += " - " + diagnosis.Description
+			}
+			if diagnosis.Status != "" {
+				line += " (" + diagnosis.Status + ")"
+			}
+			d.text(line)
+		}
+	}
+	if len(summary.Procedures) > 0 {
+		d.heading("Procedures")
+		for _, procedure := range summary.Procedures {
+			line := procedure.Description
+			if procedure.Code != "" {
+				line = procedure.Code + " - " + line
+			}
+			if procedure.PerformedAt != nil {
+				line += " (" + procedure.PerformedAt.Format("02/01/2006") + ")"
+			}
+			d.text(line)
+		}
+	}
+	d.summaryMedications("Medications given", summary.MedicationsGiven)
+	d.summaryMedications("Discharge medications", summary.DischargeMedications)
+
+	d.section("Condition at discharge", summary.DischargeCondition)
+	d.section("Instructions", summary.Instructions)
+
+	if len(summary.FollowUps) > 0 {
+		d.heading("Follow-up appointments")
+		for _, followUp := range summary.FollowUps {
+			line := followUp.Date.Format("02/01/2006 15:04") + " - " + strings.ReplaceAll(followUp.Type, "_", " ")
+			if followUp.Notes != "" {
+				line += ": " + followUp.Notes
+			}
+			d.text(line)
+		}
+	}
+
+	if summary.Status == "signed" && summary.Doctor != nil {
+		d.field("Signed", summary.SignedAt.Format("02/01/2006 15:04"))
+		if err := d.signature(*summary.Doctor, ""); err != nil {
+			return err
+		}
+	}
+	return d.output(w)
+}
+
+// section writes a heading and its text, skipping empty sections.
+func (d *document) section(heading, text string) {
+	if text == "" {
+		return
+	}
+	d.heading(heading)
+	d.text(text)
+}
+
+func (d *document) summaryMedications(heading string, medications []models.SummaryMedication) {
+	if len(medications) == 0 {
+		return
+	}
+	d.heading(heading)
+	for i, medication := range medications {
+		d.pdf.SetFont("Helvetica", "B", 10)
+		d.pdf.MultiCell(0, lineHeight, d.tr(fmt.Sprintf("%d. %s", i+1, medication.Medication)), "", "L", false)
+		d.field("Dosage", medication.Dosage)
+		d.field("Instructions", medication.Instructions)
+		d.pdf.Ln(1.5)
+	}
+}
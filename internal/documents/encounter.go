@@ -0,0 +1,58 @@
+package documents
+
+import (
+	"io"
+
+	"hospital/internal/config"
+	"hospital/internal/models"
+)
+
+// EncounterSummaryDocument is the content of a visit summary handed to the
+// patient after an appointment.
+type EncounterSummaryDocument struct {
+	Clinic        config.ClinicConfig
+	Doctor        models.User
+	Patient       models.Patient
+	Appointment   models.Appointment
+	Diagnoses     []models.Diagnosis
+	Prescriptions []models.Prescription
+}
+
+// WriteEncounterSummary renders the summary of a single appointment.
+func WriteEncounterSummary(w io.Writer, doc EncounterSummaryDocument) error {
+	d := newDocument(doc.Clinic, "Visit summary")
+
+	d.pdf.SetFont("Helvetica", "B", 14)
+	d.pdf.CellFormat(0, 8, "Visit summary", "", 1, "C", false, 0, "")
+	d.field("Visit date", doc.Appointment.AppointmentDate.Format("02/01/2006 15:04"))
+	d.field("Status", doc.Appointment.Status)
+
+	d.patient(doc.Patient)
+	d.doctor(doc.Doctor)
+
+	if len(doc.Diagnoses) > 0 {
+		d.heading("Diagnoses")
+		for _, diagnosis := range doc.Diagnoses {
+			line := diagnosis.Code
+			if diagnosis.ICD10.Description != "" {
+				line += " - " + diagnosis.ICD10.Description
+			}
+			d.text(line + " (" + diagnosis.Status + ")")
+		}
+	}
+
+	if doc.Appointment.Notes != "" {
+		d.heading("Notes")
+		d.text(doc.Appointment.Notes)
+	}
+
+	if len(doc.Prescriptions) > 0 {
+		d.heading("Medications")
+		d.medications(doc.Prescriptions)
+	}
+
+	if err := d.signature(doc.Doctor, ""); err != nil {
+		return err
+	}
+	return d.output(w)
+}
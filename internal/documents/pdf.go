@@ -0,0 +1,178 @@
+// Package documents renders printable documents such as prescriptions and
+// visit summaries as PDF.
+package documents
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+	qrcode "github.com/skip2/go-qrcode"
+
+	"hospital/internal/config"
+	"hospital/internal/models"
+)
+
+const (
+	pageMargin = 15.0
+	lineHeight = 5.5
+	qrSize     = 30.0
+)
+
+// document wraps fpdf with the letterhead, footer and text helpers shared by
+// every printout.
+type document struct {
+	pdf *fpdf.Fpdf
+	tr  func(string) string
+}
+
+func newDocument(clinic config.ClinicConfig, title string) *document {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(pageMargin, pageMargin, pageMargin)
+	pdf.SetAutoPageBreak(true, 20)
+	pdf.SetTitle(title, true)
+	pdf.SetCreator(clinic.Name, true)
+	pdf.AliasNbPages("")
+
+	d := &document{pdf: pdf, tr: pdf.UnicodeTranslatorFromDescriptor("")}
+	generated := time.Now().Format("02/01/2006 15:04")
+
+	pdf.SetHeaderFunc(func() {
+		pdf.SetFont("Helvetica", "B", 16)
+		pdf.CellFormat(0, 8, d.tr(clinic.Name), "", 1, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 9)
+		for _, line := range []string{clinic.Address, contactLine(clinic)} {
+			if line != "" {
+				pdf.CellFormat(0, 4.5, d.tr(line), "", 1, "L", false, 0, "")
+			}
+		}
+		pdf.Ln(2)
+		x, y := pdf.GetXY()
+		pw, _ := pdf.GetPageSize()
+		pdf.Line(x, y, pw-pageMargin, y)
+		pdf.Ln(4)
+	})
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-15)
+		pdf.SetFont("Helvetica", "I", 8)
+		pdf.CellFormat(0, 4, d.tr(fmt.Sprintf("%s - generated %s", title, generated)), "", 0, "L", false, 0, "")
+		pdf.CellFormat(0, 4, fmt.Sprintf("Page %d of {nb}", pdf.PageNo()), "", 0, "R", false, 0, "")
+	})
+
+	pdf.AddPage()
+	return d
+}
+
+func contactLine(clinic config.ClinicConfig) string {
+	switch {
+	case clinic.Phone != "" && clinic.Email != "":
+		return "Tel: " + clinic.Phone + "   Email: " + clinic.Email
+	case clinic.Phone != "":
+		return "Tel: " + clinic.Phone
+	case clinic.Email != "":
+		return "Email: " + clinic.Email
+	}
+	return ""
+}
+
+func (d *document) heading(text string) {
+	d.pdf.Ln(2)
+	d.pdf.SetFont("Helvetica", "B", 12)
+	d.pdf.CellFormat(0, 7, d.tr(text), "B", 1, "L", false, 0, "")
+	d.pdf.Ln(1)
+}
+
+// field writes a "Label: value" line, skipping empty values.
+func (d *document) field(label, value string) {
+	if value == "" {
+		return
+	}
+	d.pdf.SetFont("Helvetica", "B", 10)
+	d.pdf.CellFormat(40, lineHeight, d.tr(label+":"), "", 0, "L", false, 0, "")
+	d.pdf.SetFont("Helvetica", "", 10)
+	d.pdf.MultiCell(0, lineHeight, d.tr(value), "", "L", false)
+}
+
+func (d *document) text(value string) {
+	d.pdf.SetFont("Helvetica", "", 10)
+	d.pdf.MultiCell(0, lineHeight, d.tr(value), "", "L", false)
+}
+
+func (d *document) patient(patient models.Patient) {
+	d.heading("Patient")
+	d.field("Name", patient.Name)
+	d.field("MRN", patient.MRN)
+	if patient.DateOfBirth != nil {
+		dob := patient.DateOfBirth.Format("02 Jan 2006")
+		if age, ok := patient.AgeAt(time.Now()); ok {
+			dob += fmt.Sprintf(" (%d years)", age)
+		}
+		d.field("Date of birth", dob)
+	}
+	if patient.Sex != "" && patient.Sex != "unknown" {
+		d.field("Sex", patient.Sex)
+	}
+	d.field("Phone", patient.Phone)
+	d.field("Email", patient.Email)
+	d.field("Address", patient.Address)
+}
+
+func (d *document) doctor(doctor models.User) {
+	d.heading("Doctor")
+	d.field("Name", doctor.Name)
+	d.field("License no.", doctor.LicenseNumber)
+}
+
+// medications writes one block per prescription with its structured dosing.
+func (d *document) medications(prescriptions []models.Prescription) {
+	for i, p := range prescriptions {
+		d.pdf.SetFont("Helvetica", "B", 10)
+		d.pdf.MultiCell(0, lineHeight, d.tr(fmt.Sprintf("%d. %s", i+1, p.Medication)), "", "L", false)
+		d.field("Dosage", p.Dosage)
+		if p.Quantity > 0 {
+			d.field("Quantity", strconv.FormatFloat(p.Quantity, 'f', -1, 64))
+		}
+		d.field("Instructions", p.Instructions)
+		d.pdf.Ln(1.5)
+	}
+}
+
+// signature leaves room for the doctor's signature and prints the
+// verification QR code next to it.
+func (d *document) signature(doctor models.User, verificationURL string) error {
+	d.pdf.Ln(4)
+	if _, y := d.pdf.GetXY(); y > 240 {
+		d.pdf.AddPage()
+	}
+	x, y := d.pdf.GetXY()
+
+	if verificationURL != "" {
+		png, err := qrcode.Encode(verificationURL, qrcode.Medium, 256)
+		if err != nil {
+			return err
+		}
+		opts := fpdf.ImageOptions{ImageType: "PNG"}
+		d.pdf.RegisterImageOptionsReader("verification-qr", opts, bytes.NewReader(png))
+		pw, _ := d.pdf.GetPageSize()
+		d.pdf.ImageOptions("verification-qr", pw-pageMargin-qrSize, y, qrSize, qrSize, false, opts, 0, "")
+		d.pdf.SetXY(pw-pageMargin-qrSize-5, y+qrSize)
+		d.pdf.SetFont("Helvetica", "", 7)
+		d.pdf.CellFormat(qrSize+5, 4, "Scan to verify", "", 0, "C", false, 0, "")
+	}
+
+	d.pdf.SetXY(x, y+18)
+	d.pdf.Line(x, y+18, x+70, y+18)
+	d.pdf.SetFont("Helvetica", "", 9)
+	d.pdf.CellFormat(70, 5, d.tr(doctor.Name), "", 1, "L", false, 0, "")
+	if doctor.LicenseNumber != "" {
+		d.pdf.CellFormat(70, 5, d.tr("License no. "+doctor.LicenseNumber), "", 1, "L", false, 0, "")
+	}
+	return nil
+}
+
+func (d *document) output(w io.Writer) error {
+	return d.pdf.Output(w)
+}
@@ -0,0 +1,41 @@
+package documents
+
+import (
+	"io"
+	"time"
+
+	"hospital/internal/config"
+	"hospital/internal/models"
+)
+
+// PrescriptionDocument is the content of a printed prescription.
+type PrescriptionDocument struct {
+	Clinic           config.ClinicConfig
+	Doctor           models.User
+	Patient          models.Patient
+	Prescriptions    []models.Prescription
+	IssuedAt         time.Time
+	VerificationCode string
+	VerificationURL  string
+}
+
+// WritePrescription renders a signed prescription for the pharmacy.
+func WritePrescription(w io.Writer, doc PrescriptionDocument) error {
+	d := newDocument(doc.Clinic, "Prescription")
+
+	d.pdf.SetFont("Helvetica", "B", 14)
+	d.pdf.CellFormat(0, 8, "Prescription", "", 1, "C", false, 0, "")
+	d.field("Date", doc.IssuedAt.Format("02/01/2006"))
+	d.field("Verification code", doc.VerificationCode)
+
+	d.patient(doc.Patient)
+	d.doctor(doc.Doctor)
+
+	d.heading("Medications")
+	d.medications(doc.Prescriptions)
+
+	if err := d.signature(doc.Doctor, doc.VerificationURL); err != nil {
+		return err
+	}
+	return d.output(w)
+}
@@ -0,0 +1,150 @@
+package fhir
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"hospital/internal/models"
+)
+
+// AppointmentStatuses maps appointment statuses to FHIR appointment statuses.
+var AppointmentStatuses = map[string]string{
+	"scheduled": "booked",
+	"completed": "fulfilled",
+	"cancelled": "cancelled",
+}
+
+// appointmentTypes maps appointment types to the HL7 v2 appointment reason
+// codes. Procedures have no code and are sent as text.
+var appointmentTypes = map[string]Coding{
+	"consultation": {System: SystemAppointmentType, Code: "ROUTINE", Display: "Routine appointment - default if not valued"},
+	"follow_up":    {System: SystemAppointmentType, Code: "FOLLOWUP", Display: "A follow up visit from a previous appointment"},
+	"emergency":    {System: SystemAppointmentType, Code: "EMERGENCY", Display: "Emergency appointment"},
+}
+
+type Appointment struct {
+	ResourceType    string                   `json:"resourceType"`
+	ID              string                   `json:"id,omitempty"`
+	Meta            *Meta                    `json:"meta,omitempty"`
+	Status          string                   `json:"status"`
+	AppointmentType *CodeableConcept         `json:"appointmentType,omitempty"`
+	Start           string                   `json:"start,omitempty"`
+	Created         string                   `json:"created,omitempty"`
+	Comment         string                   `json:"comment,omitempty"`
+	Participant     []AppointmentParticipant `json:"participant"`
+}
+
+func (r Appointment) Path() string { return "Appointment/" + r.ID }
+
+func (r Appointment) VersionID() string { return r.Meta.versionID() }
+
+type AppointmentParticipant struct {
+	Actor    *Reference `json:"actor,omitempty"`
+	Required string     `json:"required,omitempty"`
+	Status   string     `json:"status"`
+}
+
+// AppointmentResource maps an appointment to an Appointment resource, naming
+// the patient and doctor when they are loaded. Appointments have no length,
+// so only the start is given.
+func AppointmentResource(appointment *models.Appointment) Appointment {
+	resource := Appointment{
+		ResourceType: "Appointment",
+		ID:           appointment.ID.String(),
+		Meta:         &Meta{VersionID: strconv.Itoa(appointment.Version), LastUpdated: instant(appointment.UpdatedAt)},
+		Status:       AppointmentStatuses[appointment.Status],
+		Start:        instant(appointment.AppointmentDate),
+		Created:      instant(appointment.CreatedAt),
+		Comment:      appointment.Notes,
+		Participant: []AppointmentParticipant{
+			{
+				Actor:    &Reference{Reference: "Patient/" + appointment.PatientID.String(), Display: appointment.Patient.Name},
+				Required: "required",
+				Status:   "accepted",
+			},
+			{
+				Actor:    &Reference{Reference: "Practitioner/" + appointment.DoctorID.String(), Display: appointment.Doctor.Name},
+				Required: "required",
+				Status:   "accepted",
+			},
+		},
+	}
+	if appointment.Type != "" {
+		resource.AppointmentType = &CodeableConcept{Text: appointment.Type}
+		if coding, ok := appointmentTypes[appointment.Type]; ok {
+			resource.AppointmentType.Coding = []Coding{coding}
+		}
+	}
+	return resource
+}
+
+// ApplyAppointment copies an Appointment resource sent by a client onto
+// appointment, which is either new or the stored record being updated. The
+// start and one patient and one practitioner participant are required; the
+// type is only changed when one is given.
+func ApplyAppointment(resource *Appointment, appointment *models.Appointment) error {
+	invalid := &InvalidResource{}
+	if resource.ResourceType != "Appointment" {
+		invalid.add("invalid", "Appointment.resourceType", "must be Appointment")
+	}
+
+	status := ""
+	for s, fhirStatus := range AppointmentStatuses {
+		if fhirStatus == resource.Status {
+			status = s
+		}
+	}
+	if status == "" {
+		invalid.add("code-invalid", "Appointment.status", "must be booked, fulfilled or cancelled")
+	}
+	appointment.Status = status
+
+	if resource.Start == "" {
+		invalid.add("required", "Appointment.start", "the start is required")
+	} else if start, err := time.Parse(time.RFC3339, resource.Start); err != nil {
+		invalid.add("value", "Appointment.start", "must be a date and time with a time zone")
+	} else {
+		appointment.AppointmentDate = start
+	}
+
+	var patient, practitioner bool
+	for i, participant := range resource.Participant {
+		if participant.Actor == nil {
+			continue
+		}
+		if id, ok := referenceID(participant.Actor.Reference, "Patient"); ok && !patient {
+			appointment.PatientID, patient = id, true
+		} else if id, ok := referenceID(participant.Actor.Reference, "Practitioner"); ok && !practitioner {
+			appointment.DoctorID, practitioner = id, true
+		} else {
+			invalid.add("value", fmt.Sprintf("Appointment.participant[%d].actor", i), "must be the only Patient or Practitioner")
+		}
+	}
+	if !patient {
+		invalid.add("required", "Appointment.participant", "a Patient participant is required")
+	}
+	if !practitioner {
+		invalid.add("required", "Appointment.participant", "a Practitioner participant is required")
+	}
+
+	if resource.AppointmentType != nil {
+		appointmentType := strings.ToLower(resource.AppointmentType.Text)
+		for _, coding := range resource.AppointmentType.Coding {
+			for t, known := range appointmentTypes {
+				if coding.Code == known.Code {
+					appointmentType = t
+				}
+			}
+		}
+		if !models.AppointmentTypes[appointmentType] {
+			invalid.add("code-invalid", "Appointment.appointmentType", "must be ROUTINE, FOLLOWUP, EMERGENCY or the text procedure")
+		} else {
+			appointment.Type = appointmentType
+		}
+	}
+
+	appointment.Notes = resource.Comment
+	return invalid.err()
+}
@@ -0,0 +1,21 @@
+package fhir
+
+// NDJSONContentType is the media type of bulk data export files: one FHIR JSON
+// resource per line.
+const NDJSONContentType = "application/fhir+ndjson"
+
+// ExportManifest is the body of the status response of a completed bulk data
+// export. It is not a FHIR resource.
+type ExportManifest struct {
+	TransactionTime     string         `json:"transactionTime"`
+	Request             string         `json:"request"`
+	RequiresAccessToken bool           `json:"requiresAccessToken"`
+	Output              []ExportOutput `json:"output"`
+	Error               []ExportOutput `json:"error"`
+}
+
+type ExportOutput struct {
+	Type  string `json:"type"`
+	URL   string `json:"url"`
+	Count int64  `json:"count,omitempty"`
+}
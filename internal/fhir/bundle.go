@@ -0,0 +1,127 @@
+package fhir
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+type Bundle struct {
+	ResourceType string        `json:"resourceType"`
+	Meta         *Meta         `json:"meta,omitempty"`
+	Type         string        `json:"type"`
+	Total        *int64        `json:"total,omitempty"`
+	Link         []BundleLink  `json:"link,omitempty"`
+	Entry        []BundleEntry `json:"entry,omitempty"`
+}
+
+type BundleLink struct {
+	Relation string `json:"relation"`
+	URL      string `json:"url"`
+}
+
+type BundleEntry struct {
+	FullURL  string               `json:"fullUrl,omitempty"`
+	Resource any                  `json:"resource,omitempty"`
+	Search   *BundleEntrySearch   `json:"search,omitempty"`
+	Response *BundleEntryResponse `json:"response,omitempty"`
+}
+
+type BundleEntrySearch struct {
+	Mode string `json:"mode"`
+}
+
+type BundleEntryRequest struct {
+	Method      string `json:"method"`
+	URL         string `json:"url"`
+	IfMatch     string `json:"ifMatch,omitempty"`
+	IfNoneExist string `json:"ifNoneExist,omitempty"`
+}
+
+type BundleEntryResponse struct {
+	Status       string `json:"status"`
+	Location     string `json:"location,omitempty"`
+	Etag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+}
+
+// TransactionBundle is a transaction Bundle sent by a client. The resources
+// are decoded once the request says what type they are.
+type TransactionBundle struct {
+	ResourceType string             `json:"resourceType"`
+	Type         string             `json:"type"`
+	Entry        []TransactionEntry `json:"entry"`
+}
+
+type TransactionEntry struct {
+	FullURL  string              `json:"fullUrl,omitempty"`
+	Resource json.RawMessage     `json:"resource,omitempty"`
+	Request  *BundleEntryRequest `json:"request,omitempty"`
+}
+
+// OperationOutcome reports errors; the FHIR API returns one instead of the
+// {"error": ...} body of the other APIs.
+type OperationOutcome struct {
+	ResourceType string                  `json:"resourceType"`
+	Issue        []OperationOutcomeIssue `json:"issue"`
+}
+
+type OperationOutcomeIssue struct {
+	Severity    string   `json:"severity"`
+	Code        string   `json:"code"`
+	Diagnostics string   `json:"diagnostics,omitempty"`
+	Expression  []string `json:"expression,omitempty"`
+}
+
+// Outcome returns an OperationOutcome with a single error issue. code is
+// from the FHIR issue type value set, e.g. not-found or invalid.
+func Outcome(code, diagnostics string) OperationOutcome {
+	return OperationOutcome{
+		ResourceType: "OperationOutcome",
+		Issue:        []OperationOutcomeIssue{{Severity: "error", Code: code, Diagnostics: diagnostics}},
+	}
+}
+
+// InvalidResource lists the problems found reading a resource sent by a
+// client, with the FHIRPath of each element at fault.
+type InvalidResource struct {
+	Issues []OperationOutcomeIssue
+}
+
+// Invalid returns an InvalidResource with one issue.
+func Invalid(expression, diagnostics string) *InvalidResource {
+	invalid := &InvalidResource{}
+	invalid.add("invalid", expression, diagnostics)
+	return invalid
+}
+
+func (e *InvalidResource) Error() string {
+	messages := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		messages[i] = issue.Diagnostics
+		if len(issue.Expression) > 0 {
+			messages[i] = issue.Expression[0] + ": " + issue.Diagnostics
+		}
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Outcome returns the problems as an OperationOutcome.
+func (e *InvalidResource) Outcome() OperationOutcome {
+	return OperationOutcome{ResourceType: "OperationOutcome", Issue: e.Issues}
+}
+
+func (e *InvalidResource) add(code, expression, diagnostics string) {
+	issue := OperationOutcomeIssue{Severity: "error", Code: code, Diagnostics: diagnostics}
+	if expression != "" {
+		issue.Expression = []string{expression}
+	}
+	e.Issues = append(e.Issues, issue)
+}
+
+// err returns e if any problems were found.
+func (e *InvalidResource) err() error {
+	if len(e.Issues) == 0 {
+		return nil
+	}
+	return e
+}
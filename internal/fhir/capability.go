@@ -0,0 +1,144 @@
+package fhir
+
+import (
+	"time"
+)
+
+// SearchParam is a search parameter the server supports.
+type SearchParam struct {
+	Name          string `json:"name"`
+	Type          string `json:"type"`
+	Documentation string `json:"documentation,omitempty"`
+}
+
+// ResourceTypes lists the resource types served, in the order they are
+// listed in the CapabilityStatement.
+var ResourceTypes = []string{"Patient", "Practitioner", "Appointment", "MedicationRequest"}
+
+// Interactions lists the interactions supported for each resource type.
+var Interactions = map[string][]string{
+	"Patient":           {"read", "vread", "search-type", "create", "update"},
+	"Practitioner":      {"read", "search-type"},
+	"Appointment":       {"read", "vread", "search-type", "create", "update"},
+	"MedicationRequest": {"read", "search-type"},
+}
+
+// Versioned lists the resource types that keep a version, which updates can
+// be made conditional on with If-Match.
+var Versioned = map[string]bool{"Patient": true, "Appointment": true}
+
+// ConditionalCreate lists the resource types that can be created with
+// If-None-Exist, searching by identifier.
+var ConditionalCreate = map[string]bool{"Patient": true}
+
+// SearchParams lists the search parameters supported for each resource type.
+// Every type also supports _count and _offset for paging.
+var SearchParams = map[string][]SearchParam{
+	"Patient": {
+		{Name: "_id", Type: "token"},
+		{Name: "name", Type: "string", Documentation: "Matches the start of any word of the name"},
+		{Name: "identifier", Type: "token", Documentation: "MRN, national ID or insurance number"},
+		{Name: "birthdate", Type: "date"},
+	},
+	"Practitioner": {
+		{Name: "_id", Type: "token"},
+		{Name: "name", Type: "string", Documentation: "Matches the start of any word of the name"},
+		{Name: "identifier", Type: "token", Documentation: "Medical council registration number"},
+	},
+	"Appointment": {
+		{Name: "_id", Type: "token"},
+		{Name: "date", Type: "date"},
+		{Name: "patient", Type: "reference"},
+		{Name: "practitioner", Type: "reference"},
+	},
+	"MedicationRequest": {
+		{Name: "_id", Type: "token"},
+		{Name: "identifier", Type: "token", Documentation: "Verification code printed on the prescription"},
+		{Name: "authoredon", Type: "date"},
+		{Name: "patient", Type: "reference"},
+		{Name: "requester", Type: "reference"},
+	},
+}
+
+type CapabilityStatement struct {
+	ResourceType   string                    `json:"resourceType"`
+	Status         string                    `json:"status"`
+	Date           string                    `json:"date"`
+	Publisher      string                    `json:"publisher,omitempty"`
+	Kind           string                    `json:"kind"`
+	Implementation *CapabilityImplementation `json:"implementation,omitempty"`
+	FHIRVersion    string                    `json:"fhirVersion"`
+	Format         []string                  `json:"format"`
+	Rest           []CapabilityRest          `json:"rest"`
+}
+
+type CapabilityImplementation struct {
+	Description string `json:"description"`
+	URL         string `json:"url,omitempty"`
+}
+
+type CapabilityRest struct {
+	Mode        string                  `json:"mode"`
+	Security    *CapabilitySecurity     `json:"security,omitempty"`
+	Resource    []CapabilityResource    `json:"resource"`
+	Interaction []CapabilityInteraction `json:"interaction,omitempty"`
+	Operation   []CapabilityOperation   `json:"operation,omitempty"`
+}
+
+type CapabilityOperation struct {
+	Name       string `json:"name"`
+	Definition string `json:"definition"`
+}
+
+type CapabilitySecurity struct {
+	Description string `json:"description"`
+}
+
+type CapabilityResource struct {
+	Type              string                  `json:"type"`
+	Interaction       []CapabilityInteraction `json:"interaction"`
+	Versioning        string                  `json:"versioning,omitempty"`
+	ConditionalCreate bool                    `json:"conditionalCreate,omitempty"`
+	SearchParam       []SearchParam           `json:"searchParam,omitempty"`
+}
+
+type CapabilityInteraction struct {
+	Code string `json:"code"`
+}
+
+// NewCapabilityStatement describes the server at baseURL, run by publisher.
+func NewCapabilityStatement(baseURL, publisher string, date time.Time) CapabilityStatement {
+	rest := CapabilityRest{
+		Mode:        "server",
+		Security:    &CapabilitySecurity{Description: "Send the bearer token from /api/auth/login in the Authorization header. Receptionists can read every record, create and update patients and appointments, and run bulk exports; doctors can read their own patients and appointments."},
+		Interaction: []CapabilityInteraction{{Code: "transaction"}},
+		Operation:   []CapabilityOperation{{Name: "export", Definition: "http://hl7.org/fhir/uv/bulkdata/OperationDefinition/export"}},
+	}
+	for _, resourceType := range ResourceTypes {
+		resource := CapabilityResource{
+			Type:              resourceType,
+			Versioning:        "no-version",
+			ConditionalCreate: ConditionalCreate[resourceType],
+			SearchParam:       SearchParams[resourceType],
+		}
+		if Versioned[resourceType] {
+			resource.Versioning = "versioned-update"
+		}
+		for _, code := range Interactions[resourceType] {
+			resource.Interaction = append(resource.Interaction, CapabilityInteraction{Code: code})
+		}
+		rest.Resource = append(rest.Resource, resource)
+	}
+
+	return CapabilityStatement{
+		ResourceType:   "CapabilityStatement",
+		Status:         "active",
+		Date:           instant(date),
+		Publisher:      publisher,
+		Kind:           "instance",
+		Implementation: &CapabilityImplementation{Description: publisher + " FHIR API", URL: baseURL},
+		FHIRVersion:    Version,
+		Format:         []string{"json"},
+		Rest:           []CapabilityRest{rest},
+	}
+}
@@ -0,0 +1,123 @@
+package fhir
+
+import (
+	"hospital/internal/models"
+)
+
+// Composition is a clinical document made of narrative sections.
+type Composition struct {
+	ResourceType string                `json:"resourceType"`
+	ID           string                `json:"id"`
+	Meta         *Meta                 `json:"meta,omitempty"`
+	Identifier   *Identifier           `json:"identifier,omitempty"`
+	Status       string                `json:"status"`
+	Type         CodeableConcept       `json:"type"`
+	Subject      Reference             `json:"subject"`
+	Encounter    *Reference            `json:"encounter,omitempty"`
+	Date         string                `json:"date"`
+	Author       []Reference           `json:"author"`
+	Title        string                `json:"title"`
+	Attester     []CompositionAttester `json:"attester,omitempty"`
+	Section      []CompositionSection  `json:"section"`
+}
+
+type CompositionAttester struct {
+	Mode  string     `json:"mode"`
+	Time  string     `json:"time,omitempty"`
+	Party *Reference `json:"party,omitempty"`
+}
+
+type CompositionSection struct {
+	Title string           `json:"title"`
+	Code  *CodeableConcept `json:"code,omitempty"`
+	Text  *Narrative       `json:"text"`
+}
+
+// DischargeComposition maps a discharge summary, with its patient and doctor
+// loaded, to a discharge summary Composition. Drafts are preliminary and
+// signed summaries final, attested by the doctor.
+func DischargeComposition(summary *models.DischargeSummary) Composition {
+	composition := Composition{
+		ResourceType: "Composition",
+		ID:           summary.ID.String(),
+		Meta:         &Meta{LastUpdated: instant(summary.UpdatedAt)},
+		Identifier:   &Identifier{System: "urn:ietf:rfc:3986", Value: "urn:uuid:" + summary.ID.String()},
+		Status:       "preliminary",
+		Type: CodeableConcept{
+			Coding: []Coding{{System: SystemLOINC, Code: "18842-5", Display: "Discharge summary"}},
+			Text:   "Discharge summary",
+		},
+		Subject:   Reference{Reference: "Patient/" + summary.PatientID.String()},
+		Encounter: &Reference{Reference: "Encounter/" + summary.AdmissionID.String()},
+		Date:      instant(summary.UpdatedAt),
+		Author:    []Reference{{Reference: "Practitioner/" + summary.DoctorID.String()}},
+		Title:     "Discharge summary",
+	}
+	if summary.Patient != nil {
+		composition.Subject.Display = summary.Patient.Name
+	}
+	if summary.Doctor != nil {
+		composition.Author[0].Display = summary.Doctor.Name
+	}
+	if summary.Status == "signed" && summary.SignedAt != nil {
+		composition.Status = "final"
+		composition.Date = instant(*summary.SignedAt)
+		composition.Attester = []CompositionAttester{{Mode: "legal", Time: instant(*summary.SignedAt), Party: &composition.Author[0]}}
+	}
+
+	var diagnoses, procedures, given, discharge, followUps []string
+	for _, diagnosis := range summary.Diagnoses {
+		diagnoses = append(diagnoses, joinNonEmpty(" - ", diagnosis.Code, diagnosis.Description))
+	}
+	for _, procedure := range summary.Procedures {
+		line := joinNonEmpty(" - ", procedure.Code, procedure.Description)
+		if procedure.PerformedAt != nil {
+			line += " (" + procedure.PerformedAt.Format("2006-01-02") + ")"
+		}
+		procedures = append(procedures, line)
+	}
+	for _, medication := range summary.MedicationsGiven {
+		given = append(given, joinNonEmpty(", ", medication.Medication, medication.Dosage, medication.Instructions))
+	}
+	for _, medication := range summary.DischargeMedications {
+		discharge = append(discharge, joinNonEmpty(", ", medication.Medication, medication.Dosage, medication.Instructions))
+	}
+	for _, followUp := range summary.FollowUps {
+		followUps = append(followUps, joinNonEmpty(", ", followUp.Date.Format("2006-01-02 15:04"), followUp.Type, followUp.Notes))
+	}
+
+	composition.Section = []CompositionSection{
+		section("Reason for admission", "46241-6", "Hospital admission diagnosis", narrative(summary.AdmissionReason)),
+		section("Hospital course", "8648-8", "Hospital course", narrative(summary.HospitalCourse)),
+		section("Discharge diagnoses", "11535-2", "Hospital discharge diagnosis", list(diagnoses, "None recorded")),
+		section("Procedures", "47519-4", "History of procedures", list(procedures, "None recorded")),
+		section("Medications given", "29549-3", "Medication administered", list(given, "None recorded")),
+		section("Discharge medications", "10183-2", "Hospital discharge medications", list(discharge, "None")),
+		section("Condition at discharge", "8651-2", "Hospital discharge condition", narrative(summary.DischargeCondition)),
+		section("Instructions", "8653-8", "Hospital discharge instructions", narrative(summary.Instructions)),
+		section("Follow-up", "18776-5", "Plan of care", list(followUps, "No follow-up planned")),
+	}
+	return composition
+}
+
+func section(title, code, display string, text *Narrative) CompositionSection {
+	return CompositionSection{
+		Title: title,
+		Code:  &CodeableConcept{Coding: []Coding{{System: SystemLOINC, Code: code, Display: display}}},
+		Text:  text,
+	}
+}
+
+func joinNonEmpty(sep string, values ...string) string {
+	result := ""
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+		if result != "" {
+			result += sep
+		}
+		result += value
+	}
+	return result
+}
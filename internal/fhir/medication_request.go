@@ -0,0 +1,117 @@
+package fhir
+
+import (
+	"hospital/internal/models"
+)
+
+// MedicationRequestStatuses maps prescription statuses to FHIR medication
+// request statuses.
+var MedicationRequestStatuses = map[string]string{
+	"active":       "active",
+	"discontinued": "stopped",
+	"completed":    "completed",
+}
+
+type MedicationRequest struct {
+	ResourceType              string           `json:"resourceType"`
+	ID                        string           `json:"id,omitempty"`
+	Meta                      *Meta            `json:"meta,omitempty"`
+	Identifier                []Identifier     `json:"identifier,omitempty"`
+	Status                    string           `json:"status"`
+	StatusReason              *CodeableConcept `json:"statusReason,omitempty"`
+	Intent                    string           `json:"intent"`
+	MedicationCodeableConcept CodeableConcept  `json:"medicationCodeableConcept"`
+	Subject                   Reference        `json:"subject"`
+	AuthoredOn                string           `json:"authoredOn,omitempty"`
+	Requester                 *Reference       `json:"requester,omitempty"`
+	DosageInstruction         []Dosage         `json:"dosageInstruction,omitempty"`
+	DispenseRequest           *DispenseRequest `json:"dispenseRequest,omitempty"`
+	PriorPrescription         *Reference       `json:"priorPrescription,omitempty"`
+}
+
+func (r MedicationRequest) Path() string { return "MedicationRequest/" + r.ID }
+
+func (r MedicationRequest) VersionID() string { return r.Meta.versionID() }
+
+type Dosage struct {
+	Text               string            `json:"text,omitempty"`
+	PatientInstruction string            `json:"patientInstruction,omitempty"`
+	Timing             *Timing           `json:"timing,omitempty"`
+	AsNeededBoolean    bool              `json:"asNeededBoolean,omitempty"`
+	Route              *CodeableConcept  `json:"route,omitempty"`
+	DoseAndRate        []DosageDoseRange `json:"doseAndRate,omitempty"`
+}
+
+type Timing struct {
+	Code *CodeableConcept `json:"code,omitempty"`
+}
+
+type DosageDoseRange struct {
+	DoseQuantity *Quantity `json:"doseQuantity,omitempty"`
+}
+
+type DispenseRequest struct {
+	DispenseInterval       *Quantity `json:"dispenseInterval,omitempty"`
+	NumberOfRepeatsAllowed int       `json:"numberOfRepeatsAllowed"`
+	Quantity               *Quantity `json:"quantity,omitempty"`
+	ExpectedSupplyDuration *Quantity `json:"expectedSupplyDuration,omitempty"`
+}
+
+// MedicationRequestResource maps a prescription to a MedicationRequest
+// resource, naming the patient and doctor when they are loaded. Refills point
+// at the original prescription.
+func MedicationRequestResource(prescription *models.Prescription) MedicationRequest {
+	resource := MedicationRequest{
+		ResourceType:              "MedicationRequest",
+		ID:                        prescription.ID.String(),
+		Meta:                      &Meta{LastUpdated: instant(prescription.UpdatedAt)},
+		Status:                    MedicationRequestStatuses[prescription.Status],
+		Intent:                    "order",
+		MedicationCodeableConcept: CodeableConcept{Text: prescription.Medication},
+		Subject:                   Reference{Reference: "Patient/" + prescription.PatientID.String(), Display: prescription.Patient.Name},
+		AuthoredOn:                instant(prescription.CreatedAt),
+		Requester:                 &Reference{Reference: "Practitioner/" + prescription.DoctorID.String(), Display: prescription.Doctor.Name},
+		DispenseRequest:           &DispenseRequest{NumberOfRepeatsAllowed: prescription.RefillsAllowed},
+	}
+	if prescription.VerificationCode != "" {
+		resource.Identifier = []Identifier{{System: SystemVerificationCode, Value: prescription.VerificationCode}}
+	}
+	if prescription.DiscontinuedReason != "" {
+		resource.StatusReason = &CodeableConcept{Text: prescription.DiscontinuedReason}
+	}
+	if prescription.RefillOfID != nil {
+		resource.PriorPrescription = &Reference{Reference: "MedicationRequest/" + prescription.RefillOfID.String()}
+	}
+
+	dosage := Dosage{Text: prescription.Dosage, PatientInstruction: prescription.Instructions}
+	if prescription.Frequency != "" {
+		text := models.PrescriptionFrequencies[prescription.Frequency]
+		if text == "" {
+			text = prescription.Frequency
+		}
+		dosage.Timing = &Timing{Code: &CodeableConcept{Text: text}}
+		dosage.AsNeededBoolean = prescription.Frequency == "PRN"
+	}
+	if prescription.Route != "" {
+		dosage.Route = &CodeableConcept{Text: prescription.Route}
+	}
+	if prescription.Dose > 0 {
+		dosage.DoseAndRate = []DosageDoseRange{{DoseQuantity: &Quantity{Value: prescription.Dose, Unit: prescription.DoseUnit}}}
+	}
+	resource.DosageInstruction = []Dosage{dosage}
+
+	if prescription.Quantity > 0 {
+		resource.DispenseRequest.Quantity = &Quantity{Value: prescription.Quantity}
+	}
+	if prescription.DurationDays > 0 {
+		resource.DispenseRequest.ExpectedSupplyDuration = days(prescription.DurationDays)
+	}
+	if prescription.RefillIntervalDays > 0 {
+		resource.DispenseRequest.DispenseInterval = days(prescription.RefillIntervalDays)
+	}
+	return resource
+}
+
+func days(n int) *Quantity {
+	return &Quantity{Value: float64(n), Unit: "days", System: SystemUCUM, Code: "d"}
+}
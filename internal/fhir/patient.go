@@ -0,0 +1,243 @@
+package fhir
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"hospital/internal/models"
+)
+
+type Patient struct {
+	ResourceType  string                 `json:"resourceType"`
+	ID            string                 `json:"id,omitempty"`
+	Meta          *Meta                  `json:"meta,omitempty"`
+	Identifier    []Identifier           `json:"identifier,omitempty"`
+	Name          []HumanName            `json:"name,omitempty"`
+	Telecom       []ContactPoint         `json:"telecom,omitempty"`
+	Gender        string                 `json:"gender,omitempty"`
+	BirthDate     string                 `json:"birthDate,omitempty"`
+	Address       []Address              `json:"address,omitempty"`
+	Communication []PatientCommunication `json:"communication,omitempty"`
+	// GeneralPractitioner is the primary doctor.
+	GeneralPractitioner []Reference `json:"generalPractitioner,omitempty"`
+}
+
+func (r Patient) Path() string { return "Patient/" + r.ID }
+
+func (r Patient) VersionID() string { return r.Meta.versionID() }
+
+type PatientCommunication struct {
+	Language  CodeableConcept `json:"language"`
+	Preferred bool            `json:"preferred,omitempty"`
+}
+
+// PatientResource maps a patient to a Patient resource. The MRN, national ID
+// and insurance number are identifiers, told apart by their systems. The
+// primary doctor is given when the care team is loaded.
+func PatientResource(patient *models.Patient) Patient {
+	resource := Patient{
+		ResourceType: "Patient",
+		ID:           patient.ID.String(),
+		Meta:         &Meta{VersionID: strconv.Itoa(patient.Version), LastUpdated: instant(patient.UpdatedAt)},
+		Identifier: []Identifier{{
+			Use:    "official",
+			Type:   identifierType("MR", "Medical record number"),
+			System: SystemMRN,
+			Value:  patient.MRN,
+		}},
+		Name:   []HumanName{humanName(patient.Name)},
+		Gender: patient.Sex,
+	}
+	if patient.NationalID != "" {
+		resource.Identifier = append(resource.Identifier, Identifier{
+			Type:   identifierType("NI", "National unique individual identifier"),
+			System: SystemNationalID,
+			Value:  patient.NationalID,
+		})
+	}
+	if patient.InsuranceNumber != "" {
+		resource.Identifier = append(resource.Identifier, Identifier{
+			Type:   identifierType("MB", "Member Number"),
+			System: SystemInsuranceNumber,
+			Value:  patient.InsuranceNumber,
+		})
+	}
+	if patient.Phone != "" {
+		resource.Telecom = append(resource.Telecom, ContactPoint{System: "phone", Value: patient.Phone})
+	}
+	if patient.Email != "" {
+		resource.Telecom = append(resource.Telecom, ContactPoint{System: "email", Value: patient.Email})
+	}
+	if patient.DateOfBirth != nil {
+		resource.BirthDate = patient.DateOfBirth.String()
+	}
+	if patient.Address != "" || patient.City != "" {
+		address := Address{
+			Use:        "home",
+			Text:       patient.Address,
+			City:       patient.City,
+			State:      patient.State,
+			PostalCode: patient.PostalCode,
+			Country:    patient.Country,
+		}
+		for _, line := range []string{patient.AddressLine1, patient.AddressLine2} {
+			if line != "" {
+				address.Line = append(address.Line, line)
+			}
+		}
+		resource.Address = []Address{address}
+	}
+	if patient.PreferredLanguage != "" {
+		resource.Communication = []PatientCommunication{{
+			Language:  CodeableConcept{Coding: []Coding{{System: SystemBCP47, Code: patient.PreferredLanguage}}},
+			Preferred: true,
+		}}
+	}
+	if doctorID, ok := patient.PrimaryDoctorID(); ok {
+		resource.GeneralPractitioner = []Reference{{Reference: "Practitioner/" + doctorID.String()}}
+	}
+	return resource
+}
+
+// ApplyPatient copies a Patient resource sent by a client onto patient, which
+// is either new or the stored record being updated, and returns the ID of the
+// general practitioner if one is given. The MRN is assigned by the hospital,
+// so an MRN identifier is ignored. Name, phone, email, address and a full birth
+// date are required.
+func ApplyPatient(resource *Patient, patient *models.Patient) (*uuid.UUID, error) {
+	invalid := &InvalidResource{}
+	if resource.ResourceType != "Patient" {
+		invalid.add("invalid", "Patient.resourceType", "must be Patient")
+	}
+
+	patient.Name = ""
+	for _, name := range resource.Name {
+		if text := nameText(name); text != "" {
+			patient.Name = text
+			break
+		}
+	}
+	if patient.Name == "" {
+		invalid.add("required", "Patient.name", "a name is required")
+	}
+
+	patient.Phone, patient.Email = "", ""
+	for _, telecom := range resource.Telecom {
+		switch {
+		case telecom.System == "phone" && patient.Phone == "":
+			patient.Phone = strings.TrimSpace(telecom.Value)
+		case telecom.System == "email" && patient.Email == "":
+			patient.Email = strings.TrimSpace(telecom.Value)
+		}
+	}
+	if patient.Phone == "" {
+		invalid.add("required", "Patient.telecom", "a phone number is required")
+	}
+	if patient.Email == "" {
+		invalid.add("required", "Patient.telecom", "an email address is required")
+	}
+
+	switch resource.Gender {
+	case "":
+		patient.Sex = "unknown"
+	case "male", "female", "other", "unknown":
+		patient.Sex = resource.Gender
+	default:
+		invalid.add("code-invalid", "Patient.gender", "must be male, female, other or unknown")
+	}
+
+	patient.DateOfBirth = nil
+	if resource.BirthDate == "" {
+		invalid.add("required", "Patient.birthDate", "the birth date is required")
+	} else {
+		birthDate, err := time.Parse(time.DateOnly, resource.BirthDate)
+		if err != nil {
+			invalid.add("value", "Patient.birthDate", "must be a full date, YYYY-MM-DD")
+		} else {
+			patient.DateOfBirth = &models.Date{Time: birthDate}
+		}
+	}
+
+	patient.NationalID, patient.InsuranceNumber = "", ""
+	for i, identifier := range resource.Identifier {
+		switch identifier.System {
+		case SystemNationalID:
+			patient.NationalID = strings.TrimSpace(identifier.Value)
+		case SystemInsuranceNumber:
+			patient.InsuranceNumber = strings.TrimSpace(identifier.Value)
+		case SystemMRN:
+		default:
+			invalid.add("value", fmt.Sprintf("Patient.identifier[%d].system", i), "unknown identifier system "+identifier.System)
+		}
+	}
+
+	patient.Address, patient.AddressLine1, patient.AddressLine2 = "", "", ""
+	patient.City, patient.State, patient.PostalCode, patient.Country = "", "", "", ""
+	if len(resource.Address) > 0 {
+		address := resource.Address[0]
+		patient.Address = strings.TrimSpace(address.Text)
+		if len(address.Line) > 0 {
+			patient.AddressLine1 = address.Line[0]
+		}
+		if len(address.Line) > 1 {
+			patient.AddressLine2 = strings.Join(address.Line[1:], ", ")
+		}
+		patient.City = address.City
+		patient.State = address.State
+		patient.PostalCode = address.PostalCode
+		patient.Country = address.Country
+	}
+	if patient.Address == "" && patient.AddressLine1 == "" && patient.City == "" {
+		invalid.add("required", "Patient.address", "an address is required")
+	}
+
+	patient.PreferredLanguage = ""
+	for _, communication := range resource.Communication {
+		language := communication.Language.Text
+		if len(communication.Language.Coding) > 0 {
+			language = communication.Language.Coding[0].Code
+		}
+		if patient.PreferredLanguage == "" || communication.Preferred {
+			patient.PreferredLanguage = language
+		}
+	}
+
+	var doctorID *uuid.UUID
+	if len(resource.GeneralPractitioner) > 0 {
+		id, ok := referenceID(resource.GeneralPractitioner[0].Reference, "Practitioner")
+		if !ok {
+			invalid.add("value", "Patient.generalPractitioner[0]", "must reference a Practitioner")
+		} else {
+			doctorID = &id
+		}
+	}
+	return doctorID, invalid.err()
+}
+
+// nameText joins the parts of a name, preferring its text.
+func nameText(name HumanName) string {
+	if text := strings.TrimSpace(name.Text); text != "" {
+		return text
+	}
+	return strings.Join(strings.Fields(strings.Join(append(name.Given, name.Family), " ")), " ")
+}
+
+// humanName splits a name, which is stored as one string, taking the last word
+// as the family name.
+func humanName(name string) HumanName {
+	result := HumanName{Use: "official", Text: name}
+	words := strings.Fields(name)
+	if len(words) > 0 {
+		result.Family = words[len(words)-1]
+		result.Given = words[:len(words)-1]
+	}
+	return result
+}
+
+func identifierType(code, display string) *CodeableConcept {
+	return &CodeableConcept{Coding: []Coding{{System: SystemIdentifierType, Code: code, Display: display}}}
+}
@@ -0,0 +1,38 @@
+package fhir
+
+import (
+	"hospital/internal/models"
+)
+
+type Practitioner struct {
+	ResourceType string         `json:"resourceType"`
+	ID           string         `json:"id,omitempty"`
+	Identifier   []Identifier   `json:"identifier,omitempty"`
+	Active       bool           `json:"active"`
+	Name         []HumanName    `json:"name,omitempty"`
+	Telecom      []ContactPoint `json:"telecom,omitempty"`
+}
+
+func (r Practitioner) Path() string { return "Practitioner/" + r.ID }
+
+func (r Practitioner) VersionID() string { return "" }
+
+// PractitionerResource maps a doctor to a Practitioner resource, identified by
+// the medical council registration number when there is one.
+func PractitionerResource(doctor *models.User) Practitioner {
+	resource := Practitioner{
+		ResourceType: "Practitioner",
+		ID:           doctor.ID.String(),
+		Active:       true,
+		Name:         []HumanName{humanName(doctor.Name)},
+		Telecom:      []ContactPoint{{System: "email", Value: doctor.Email, Use: "work"}},
+	}
+	if doctor.LicenseNumber != "" {
+		resource.Identifier = []Identifier{{
+			Type:   identifierType("MD", "Medical License number"),
+			System: SystemLicense,
+			Value:  doctor.LicenseNumber,
+		}}
+	}
+	return resource
+}
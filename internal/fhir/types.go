@@ -0,0 +1,186 @@
+// Package fhir maps the hospital's records to and from FHIR R4 resources.
+// Only the elements this service fills in are modelled.
+package fhir
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ContentType is the media type of FHIR JSON.
+const ContentType = "application/fhir+json"
+
+// Version is the FHIR version served.
+const Version = "4.0.1"
+
+// Code systems used in the mapped resources.
+const (
+	SystemLOINC           = "http://loinc.org"
+	SystemUCUM            = "http://unitsofmeasure.org"
+	SystemBCP47           = "urn:ietf:bcp:47"
+	SystemIdentifierType  = "http://terminology.hl7.org/CodeSystem/v2-0203"
+	SystemAppointmentType = "http://terminology.hl7.org/CodeSystem/v2-0276"
+)
+
+// Identifier systems of the hospital's own identifiers.
+const (
+	SystemMRN              = "urn:hospital:mrn"
+	SystemNationalID       = "urn:hospital:national-id"
+	SystemInsuranceNumber  = "urn:hospital:insurance-number"
+	SystemLicense          = "urn:hospital:license"
+	SystemVerificationCode = "urn:hospital:prescription-verification-code"
+)
+
+// Resource is a resource served by the FHIR API.
+type Resource interface {
+	// Path is the resource's relative URL, e.g. Patient/123.
+	Path() string
+	// VersionID is the current version, or empty for unversioned resources.
+	VersionID() string
+}
+
+type Meta struct {
+	VersionID   string `json:"versionId,omitempty"`
+	LastUpdated string `json:"lastUpdated,omitempty"`
+}
+
+func (m *Meta) versionID() string {
+	if m == nil {
+		return ""
+	}
+	return m.VersionID
+}
+
+type Reference struct {
+	Reference string `json:"reference,omitempty"`
+	Display   string `json:"display,omitempty"`
+}
+
+type Coding struct {
+	System  string `json:"system,omitempty"`
+	Code    string `json:"code,omitempty"`
+	Display string `json:"display,omitempty"`
+}
+
+type CodeableConcept struct {
+	Coding []Coding `json:"coding,omitempty"`
+	Text   string   `json:"text,omitempty"`
+}
+
+type Identifier struct {
+	Use    string           `json:"use,omitempty"`
+	Type   *CodeableConcept `json:"type,omitempty"`
+	System string           `json:"system,omitempty"`
+	Value  string           `json:"value,omitempty"`
+}
+
+type HumanName struct {
+	Use    string   `json:"use,omitempty"`
+	Text   string   `json:"text,omitempty"`
+	Family string   `json:"family,omitempty"`
+	Given  []string `json:"given,omitempty"`
+}
+
+type ContactPoint struct {
+	System string `json:"system,omitempty"`
+	Value  string `json:"value,omitempty"`
+	Use    string `json:"use,omitempty"`
+}
+
+type Address struct {
+	Use        string   `json:"use,omitempty"`
+	Text       string   `json:"text,omitempty"`
+	Line       []string `json:"line,omitempty"`
+	City       string   `json:"city,omitempty"`
+	State      string   `json:"state,omitempty"`
+	PostalCode string   `json:"postalCode,omitempty"`
+	Country    string   `json:"country,omitempty"`
+}
+
+// Quantity is also used for the Duration type.
+type Quantity struct {
+	Value  float64 `json:"value"`
+	Unit   string  `json:"unit,omitempty"`
+	System string  `json:"system,omitempty"`
+	Code   string  `json:"code,omitempty"`
+}
+
+type Period struct {
+	Start string `json:"start,omitempty"`
+	End   string `json:"end,omitempty"`
+}
+
+// Narrative is the human readable XHTML text of a resource or section.
+type Narrative struct {
+	Status string `json:"status"`
+	Div    string `json:"div"`
+}
+
+// narrative renders paragraphs of plain text as a generated narrative. FHIR
+// doesn't allow an empty div, so a narrative without text says so.
+func narrative(paragraphs ...string) *Narrative {
+	var b strings.Builder
+	b.WriteString(`<div xmlns="http://www.w3.org/1999/xhtml">`)
+	written := false
+	for _, paragraph := range paragraphs {
+		if paragraph != "" {
+			b.WriteString("<p>" + html.EscapeString(paragraph) + "</p>")
+			written = true
+		}
+	}
+	if !written {
+		b.WriteString("<p>Not recorded</p>")
+	}
+	b.WriteString("</div>")
+	return &Narrative{Status: "generated", Div: b.String()}
+}
+
+// list renders items as an XHTML list, or the empty text when there are none.
+func list(items []string, empty string) *Narrative {
+	if len(items) == 0 {
+		return narrative(empty)
+	}
+	var b strings.Builder
+	b.WriteString(`<div xmlns="http://www.w3.org/1999/xhtml"><ul>`)
+	for _, item := range items {
+		b.WriteString("<li>" + html.EscapeString(item) + "</li>")
+	}
+	b.WriteString("</ul></div>")
+	return &Narrative{Status: "generated", Div: b.String()}
+}
+
+// ETag returns the weak ETag of a resource version.
+func ETag(versionID string) string {
+	return `W/"` + versionID + `"`
+}
+
+// ParseETag reads the version from an ETag or If-Match value such as W/"3".
+func ParseETag(value string) (int, error) {
+	value = strings.Trim(strings.TrimPrefix(strings.TrimSpace(value), "W/"), `"`)
+	version, err := strconv.Atoi(value)
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("invalid version %q", value)
+	}
+	return version, nil
+}
+
+// referenceID reads the ID of a reference to a resource of the given type,
+// written as Type/ID or an absolute URL ending in Type/ID.
+func referenceID(reference string, resourceType string) (uuid.UUID, bool) {
+	parts := strings.Split(strings.TrimRight(reference, "/"), "/")
+	if len(parts) < 2 || parts[len(parts)-2] != resourceType {
+		return uuid.Nil, false
+	}
+	id, err := uuid.Parse(parts[len(parts)-1])
+	return id, err == nil
+}
+
+// instant formats a time as a FHIR instant or dateTime.
+func instant(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,76 @@
+package importer
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"hospital/internal/models"
+)
+
+// ImportFormulary loads drug products from a CSV file with the columns
+// generic_name,brand_names,strength,form,route. Brand names are separated by
+// semicolons. A product is identified by its generic name, strength, form and
+// route; re-importing it replaces its brand names and reactivates it.
+func ImportFormulary(db *gorm.DB, r io.Reader) (int, error) {
+	total := 0
+	batch := make([]models.FormularyItem, 0, batchSize)
+	seen := make(map[string]int, batchSize)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := db.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "generic_name"}, {Name: "strength"}, {Name: "form"}, {Name: "route"}},
+			DoUpdates: clause.AssignmentColumns([]string{"brand_names", "active", "updated_at"}),
+		}).Create(&batch).Error; err != nil {
+			return err
+		}
+		total += len(batch)
+		batch = batch[:0]
+		clear(seen)
+		return nil
+	}
+
+	required := []string{"generic_name", "strength", "form", "route"}
+	err := readCSV(r, required, func(line int, row map[string]string) error {
+		item := models.FormularyItem{
+			GenericName: strings.ToLower(row["generic_name"]),
+			Strength:    row["strength"],
+			Form:        strings.ToLower(row["form"]),
+			Route:       strings.ToLower(row["route"]),
+			Active:      true,
+		}
+		if item.GenericName == "" || item.Strength == "" || item.Form == "" || item.Route == "" {
+			return errors.New("generic_name, strength, form and route are required")
+		}
+		for _, brand := range strings.Split(row["brand_names"], ";") {
+			if brand = strings.TrimSpace(brand); brand != "" {
+				item.BrandNames = append(item.BrandNames, brand)
+			}
+		}
+
+		key := item.GenericName + "\x00" + item.Strength + "\x00" + item.Form + "\x00" + item.Route
+		if i, ok := seen[key]; ok {
+			batch[i] = item
+			return nil
+		}
+		seen[key] = len(batch)
+		batch = append(batch, item)
+		if len(batch) == batchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return total, err
+	}
+	if err := flush(); err != nil {
+		return total, err
+	}
+	return total, nil
+}
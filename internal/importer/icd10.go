@@ -0,0 +1,62 @@
+package importer
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"hospital/internal/models"
+)
+
+// ImportICD10 loads ICD-10 codes from a CSV file with the columns
+// code,description. Existing codes have their description replaced.
+func ImportICD10(db *gorm.DB, r io.Reader) (int, error) {
+	total := 0
+	batch := make([]models.ICD10Code, 0, batchSize)
+	// Postgres rejects an upsert touching the same row twice, so duplicates
+	// inside one batch are collapsed onto the last occurrence.
+	seen := make(map[string]int, batchSize)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := db.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "code"}},
+			DoUpdates: clause.AssignmentColumns([]string{"description"}),
+		}).Create(&batch).Error; err != nil {
+			return err
+		}
+		total += len(batch)
+		batch = batch[:0]
+		clear(seen)
+		return nil
+	}
+
+	err := readCSV(r, []string{"code", "description"}, func(line int, row map[string]string) error {
+		code := strings.ToUpper(strings.ReplaceAll(row["code"], " ", ""))
+		if code == "" || row["description"] == "" {
+			return errors.New("code and description are required")
+		}
+		if i, ok := seen[code]; ok {
+			batch[i].Description = row["description"]
+			return nil
+		}
+		seen[code] = len(batch)
+		batch = append(batch, models.ICD10Code{Code: code, Description: row["description"]})
+		if len(batch) == batchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return total, err
+	}
+	if err := flush(); err != nil {
+		return total, err
+	}
+	return total, nil
+}
@@ -0,0 +1,66 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// batchSize is the number of rows buffered before they are written to the database.
+const batchSize = 500
+
+// readCSV streams the rows of r to fn, keyed by the lower-cased header names of
+// the first line. Rows are handed over one at a time so large files are never
+// held in memory.
+func readCSV(r io.Reader, required []string, fn func(line int, row map[string]string) error) error {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return errors.New("csv file is empty")
+		}
+		return err
+	}
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff")))
+	}
+	for _, col := range required {
+		found := false
+		for _, h := range header {
+			if h == col {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("csv header is missing required column %q", col)
+		}
+	}
+
+	line := 1
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		line++
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+
+		row := make(map[string]string, len(header))
+		for i, h := range header {
+			if i < len(record) {
+				row[h] = strings.TrimSpace(record[i])
+			}
+		}
+		if err := fn(line, row); err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+	}
+}
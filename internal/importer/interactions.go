@@ -0,0 +1,78 @@
+package importer
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"hospital/internal/models"
+)
+
+var interactionSeverities = map[string]bool{"minor": true, "moderate": true, "severe": true}
+
+// ImportInteractions loads drug interaction rules from a CSV file with the
+// columns drug_a,drug_b,severity,message. Drug names are generic names; the
+// order of a pair does not matter.
+func ImportInteractions(db *gorm.DB, r io.Reader) (int, error) {
+	total := 0
+	batch := make([]models.DrugInteraction, 0, batchSize)
+	seen := make(map[string]int, batchSize)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := db.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "drug_a"}, {Name: "drug_b"}},
+			DoUpdates: clause.AssignmentColumns([]string{"severity", "message", "updated_at"}),
+		}).Create(&batch).Error; err != nil {
+			return err
+		}
+		total += len(batch)
+		batch = batch[:0]
+		clear(seen)
+		return nil
+	}
+
+	required := []string{"drug_a", "drug_b", "severity", "message"}
+	err := readCSV(r, required, func(line int, row map[string]string) error {
+		a := strings.ToLower(strings.Join(strings.Fields(row["drug_a"]), " "))
+		b := strings.ToLower(strings.Join(strings.Fields(row["drug_b"]), " "))
+		if a == "" || b == "" || a == b {
+			return errors.New("drug_a and drug_b must name two different drugs")
+		}
+		if b < a {
+			a, b = b, a
+		}
+		severity := strings.ToLower(row["severity"])
+		if !interactionSeverities[severity] {
+			return errors.New("severity must be one of minor, moderate or severe")
+		}
+		if row["message"] == "" {
+			return errors.New("message is required")
+		}
+
+		rule := models.DrugInteraction{DrugA: a, DrugB: b, Severity: severity, Message: row["message"]}
+		key := a + "\x00" + b
+		if i, ok := seen[key]; ok {
+			batch[i] = rule
+			return nil
+		}
+		seen[key] = len(batch)
+		batch = append(batch, rule)
+		if len(batch) == batchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err != nil {
+		return total, err
+	}
+	if err := flush(); err != nil {
+		return total, err
+	}
+	return total, nil
+}
@@ -0,0 +1,116 @@
+package importer
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"hospital/internal/models"
+)
+
+var referenceRangeSexes = map[string]bool{"male": true, "female": true, "any": true}
+
+// ImportLabTests loads the lab test catalog from a CSV file with the columns
+// code,name,specimen,unit,sex,age_min,age_max,low,high. A test with several
+// reference ranges is listed on one row per range. Re-importing a test
+// replaces all of its reference ranges.
+func ImportLabTests(db *gorm.DB, r io.Reader) (int, error) {
+	total := 0
+	err := db.Transaction(func(tx *gorm.DB) error {
+		tests := make(map[string]uuid.UUID, 64)
+
+		required := []string{"code", "name", "specimen"}
+		return readCSV(r, required, func(line int, row map[string]string) error {
+			code := strings.ToUpper(row["code"])
+			if code == "" || row["name"] == "" || row["specimen"] == "" {
+				return errors.New("code, name and specimen are required")
+			}
+
+			testID, ok := tests[code]
+			if !ok {
+				test := models.LabTest{
+					Code:     code,
+					Name:     row["name"],
+					Specimen: strings.ToLower(row["specimen"]),
+					Unit:     row["unit"],
+					Active:   true,
+				}
+				if err := tx.Clauses(clause.OnConflict{
+					Columns:   []clause.Column{{Name: "code"}},
+					DoUpdates: clause.AssignmentColumns([]string{"name", "specimen", "unit", "active", "updated_at"}),
+				}).Create(&test).Error; err != nil {
+					return err
+				}
+				// The upsert does not return the ID of an existing row
+				if err := tx.Select("id").First(&test, "code = ?", code).Error; err != nil {
+					return err
+				}
+				if err := tx.Where("lab_test_id = ?", test.ID).Delete(&models.LabReferenceRange{}).Error; err != nil {
+					return err
+				}
+				testID = test.ID
+				tests[code] = testID
+				total++
+			}
+
+			if row["low"] == "" && row["high"] == "" {
+				return nil
+			}
+			rng := models.LabReferenceRange{LabTestID: testID, Sex: strings.ToLower(row["sex"])}
+			if rng.Sex == "" {
+				rng.Sex = "any"
+			}
+			if !referenceRangeSexes[rng.Sex] {
+				return errors.New("sex must be one of male, female or any")
+			}
+			var err error
+			if rng.AgeMinYears, err = optionalInt(row["age_min"]); err != nil {
+				return errors.New("age_min must be a whole number of years")
+			}
+			if rng.AgeMaxYears, err = optionalInt(row["age_max"]); err != nil {
+				return errors.New("age_max must be a whole number of years")
+			}
+			if rng.Low, err = optionalFloat(row["low"]); err != nil {
+				return errors.New("low must be a number")
+			}
+			if rng.High, err = optionalFloat(row["high"]); err != nil {
+				return errors.New("high must be a number")
+			}
+			if rng.Low != nil && rng.High != nil && *rng.Low > *rng.High {
+				return errors.New("low must not be greater than high")
+			}
+			return tx.Create(&rng).Error
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+func optionalInt(value string) (*int, error) {
+	if value == "" {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+func optionalFloat(value string) (*float64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
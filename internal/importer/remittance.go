@@ -0,0 +1,108 @@
+package importer
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"hospital/internal/models"
+)
+
+// RemittanceRow is one claim in a remittance file, modelled on the CLP
+// segment of an X12 835. Amounts are in minor currency units.
+type RemittanceRow struct {
+	Line                  int
+	ClaimNumber           string
+	PayerClaimID          string
+	StatusCode            string
+	Charged               int64
+	Paid                  int64
+	PatientResponsibility int64
+	AdjustmentCode        string
+	CheckNumber           string
+	PaidDate              *models.Date
+	// Err is set when the row could not be parsed. The other fields hold
+	// whatever could be read so the row can still be reported.
+	Err error
+}
+
+// ReadRemittance streams the rows of a remittance CSV with the columns
+// claim_number,payer_claim_id,status_code,charged,paid,patient_responsibility,
+// adjustment_code,check_number,paid_date. Amounts are decimals such as 125.50
+// and status codes follow CLP02: 1, 2 and 3 are processed as primary,
+// secondary or tertiary, 4 is denied. Unlike the catalog importers it does not
+// stop at a bad row; the row is handed to fn with Err set.
+func ReadRemittance(r io.Reader, fn func(row RemittanceRow) error) error {
+	required := []string{"claim_number", "status_code", "paid"}
+	return readCSV(r, required, func(line int, row map[string]string) error {
+		result := RemittanceRow{
+			Line:           line,
+			ClaimNumber:    strings.ToUpper(row["claim_number"]),
+			PayerClaimID:   row["payer_claim_id"],
+			StatusCode:     row["status_code"],
+			AdjustmentCode: strings.ToUpper(row["adjustment_code"]),
+			CheckNumber:    row["check_number"],
+		}
+		result.Err = parseRemittanceRow(row, &result)
+		return fn(result)
+	})
+}
+
+func parseRemittanceRow(row map[string]string, result *RemittanceRow) error {
+	if result.ClaimNumber == "" || result.StatusCode == "" {
+		return errors.New("claim_number and status_code are required")
+	}
+	var err error
+	if result.Charged, err = parseAmount(row["charged"]); err != nil {
+		return errors.New("charged must be an amount such as 125.50")
+	}
+	if result.Paid, err = parseAmount(row["paid"]); err != nil {
+		return errors.New("paid must be an amount such as 125.50")
+	}
+	if result.PatientResponsibility, err = parseAmount(row["patient_responsibility"]); err != nil {
+		return errors.New("patient_responsibility must be an amount such as 125.50")
+	}
+	if row["paid_date"] != "" {
+		date, err := models.ParseDate(row["paid_date"])
+		if err != nil {
+			return errors.New("paid_date must be a date in YYYY-MM-DD format")
+		}
+		result.PaidDate = &date
+	}
+	return nil
+}
+
+// parseAmount converts a decimal amount with at most two decimal places to
+// minor units, e.g. "125.5" to 12550. An empty value is zero.
+func parseAmount(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	negative := strings.HasPrefix(value, "-")
+	value = strings.TrimPrefix(value, "-")
+	whole, fraction, _ := strings.Cut(value, ".")
+	if whole == "" || len(fraction) > 2 || !isDigits(whole) || !isDigits(fraction) {
+		return 0, errors.New("invalid amount")
+	}
+	for len(fraction) < 2 {
+		fraction += "0"
+	}
+	amount, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
+
+func isDigits(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
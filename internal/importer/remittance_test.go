@@ -0,0 +1,99 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"0", 0, false},
+		{"125", 12500, false},
+		{"125.5", 12550, false},
+		{"125.50", 12550, false},
+		{"0.05", 5, false},
+		{"-12.30", -1230, false},
+		{"1.234", 0, true},
+		{".50", 0, true},
+		{"12.", 1200, false},
+		{"abc", 0, true},
+		{"--5", 0, true},
+		{"+5", 0, true},
+		{"1,250.00", 0, true},
+		{"12.-5", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseAmount(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseAmount(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parseAmount(%q) = %d, want %d", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadRemittance(t *testing.T) {
+	csv := "\ufeffClaim_Number,payer_claim_id,status_code,charged,paid,patient_responsibility,adjustment_code,check_number,paid_date\n" +
+		"clm-000001,P1,1,150.00,120.00,30,co45,CHK1,2026-03-02\n" +
+		"CLM-000002,P2,4,80.00,0,,,CHK1,\n" +
+		"CLM-000003,P3,1,abc,10.00,,,,\n" +
+		",P4,1,10.00,10.00,,,,\n" +
+		"CLM-000005,P5,1,10.00,10.00,,,,02/03/2026\n"
+
+	var rows []RemittanceRow
+	if err := ReadRemittance(strings.NewReader(csv), func(row RemittanceRow) error {
+		rows = append(rows, row)
+		return nil
+	}); err != nil {
+		t.Fatalf("ReadRemittance: %v", err)
+	}
+	if len(rows) != 5 {
+		t.Fatalf("got %d rows, want 5", len(rows))
+	}
+
+	first := rows[0]
+	if first.Err != nil {
+		t.Fatalf("row 1: unexpected error %v", first.Err)
+	}
+	if first.Line != 2 || first.ClaimNumber != "CLM-000001" || first.AdjustmentCode != "CO45" {
+		t.Errorf("row 1 = %+v, want line 2 with upper-cased claim number and adjustment code", first)
+	}
+	if first.Charged != 15000 || first.Paid != 12000 || first.PatientResponsibility != 3000 {
+		t.Errorf("row 1 amounts = %d/%d/%d, want 15000/12000/3000", first.Charged, first.Paid, first.PatientResponsibility)
+	}
+	if first.PaidDate == nil || first.PaidDate.String() != "2026-03-02" {
+		t.Errorf("row 1 paid date = %v, want 2026-03-02", first.PaidDate)
+	}
+
+	if rows[1].Err != nil || rows[1].StatusCode != "4" || rows[1].Paid != 0 || rows[1].PaidDate != nil {
+		t.Errorf("row 2 = %+v, want a denied row with nothing paid", rows[1])
+	}
+
+	for i, want := range map[int]string{
+		2: "charged must be an amount",
+		3: "claim_number and status_code are required",
+		4: "paid_date must be a date",
+	} {
+		if rows[i].Err == nil || !strings.Contains(rows[i].Err.Error(), want) {
+			t.Errorf("row %d error = %v, want %q", i+1, rows[i].Err, want)
+		}
+		if rows[i].Line != i+2 {
+			t.Errorf("row %d line = %d, want %d", i+1, rows[i].Line, i+2)
+		}
+	}
+}
+
+func TestReadRemittanceMissingColumn(t *testing.T) {
+	err := ReadRemittance(strings.NewReader("claim_number,status_code\nCLM-1,1\n"), func(RemittanceRow) error { return nil })
+	if err == nil || !strings.Contains(err.Error(), `"paid"`) {
+		t.Errorf("error = %v, want the missing paid column reported", err)
+	}
+}
@@ -0,0 +1,123 @@
+package importer
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"hospital/internal/models"
+)
+
+// ImportVaccines loads the vaccine catalog and its schedule from a CSV file
+// with the columns code,name,cvx_code,route,dose,age,min_interval_days,
+// grace_days,max_age. A vaccine is listed on one row per scheduled dose; rows
+// without a dose add the vaccine without a schedule. Ages are written as a
+// number with a unit, e.g. 0d, 6w, 9m or 4y. Re-importing a vaccine replaces
+// its whole schedule.
+func ImportVaccines(db *gorm.DB, r io.Reader) (int, error) {
+	total := 0
+	err := db.Transaction(func(tx *gorm.DB) error {
+		vaccines := make(map[string]uuid.UUID, 32)
+
+		required := []string{"code", "name"}
+		return readCSV(r, required, func(line int, row map[string]string) error {
+			code := strings.ToUpper(row["code"])
+			if code == "" || row["name"] == "" {
+				return errors.New("code and name are required")
+			}
+
+			vaccineID, ok := vaccines[code]
+			if !ok {
+				vaccine := models.Vaccine{
+					Code:         code,
+					Name:         row["name"],
+					CVXCode:      row["cvx_code"],
+					DefaultRoute: strings.ToLower(row["route"]),
+					Active:       true,
+				}
+				if vaccine.DefaultRoute != "" && !models.ImmunizationRoutes[vaccine.DefaultRoute] {
+					return errors.New("route must be one of intramuscular, subcutaneous, intradermal, oral or intranasal")
+				}
+				if err := tx.Clauses(clause.OnConflict{
+					Columns:   []clause.Column{{Name: "code"}},
+					DoUpdates: clause.AssignmentColumns([]string{"name", "cvx_code", "default_route", "active", "updated_at"}),
+				}).Create(&vaccine).Error; err != nil {
+					return err
+				}
+				// The upsert does not return the ID of an existing row
+				if err := tx.Select("id").First(&vaccine, "code = ?", code).Error; err != nil {
+					return err
+				}
+				if err := tx.Where("vaccine_id = ?", vaccine.ID).Delete(&models.VaccineScheduleRule{}).Error; err != nil {
+					return err
+				}
+				vaccineID = vaccine.ID
+				vaccines[code] = vaccineID
+				total++
+			}
+
+			if row["dose"] == "" {
+				return nil
+			}
+			rule := models.VaccineScheduleRule{VaccineID: vaccineID}
+			var err error
+			if rule.DoseNumber, err = strconv.Atoi(row["dose"]); err != nil || rule.DoseNumber < 1 {
+				return errors.New("dose must be a positive whole number")
+			}
+			if rule.AgeMonths, rule.AgeDays, err = parseAge(row["age"]); err != nil {
+				return errors.New("age must be a number followed by d, w, m or y")
+			}
+			for column, target := range map[string]*int{"min_interval_days": &rule.MinIntervalDays, "grace_days": &rule.GraceDays} {
+				value, err := optionalInt(row[column])
+				if err != nil || (value != nil && *value < 0) {
+					return errors.New(column + " must be a whole number of days")
+				}
+				if value != nil {
+					*target = *value
+				}
+			}
+			if row["max_age"] != "" {
+				months, days, err := parseAge(row["max_age"])
+				if err != nil || days != 0 {
+					return errors.New("max_age must be a number of months or years, e.g. 18m or 5y")
+				}
+				rule.MaxAgeMonths = &months
+			}
+			return tx.Create(&rule).Error
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// parseAge reads an age such as 6w or 9m as whole months and days. Weeks are
+// counted in days and years in months so the age lands on the same calendar
+// day as the birthday.
+func parseAge(value string) (months, days int, err error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" || value == "0" {
+		return 0, 0, nil
+	}
+	n, err := strconv.Atoi(value[:len(value)-1])
+	if err != nil || n < 0 {
+		return 0, 0, errors.New("invalid age")
+	}
+	switch value[len(value)-1] {
+	case 'd':
+		return 0, n, nil
+	case 'w':
+		return 0, n * 7, nil
+	case 'm':
+		return n, 0, nil
+	case 'y':
+		return n * 12, 0, nil
+	}
+	return 0, 0, errors.New("invalid age unit")
+}
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BedStatuses lists the states a bed can be in. A bed is occupied while an
+// admission is in it and goes to cleaning when the patient leaves.
+var BedStatuses = map[string]bool{"free": true, "occupied": true, "cleaning": true, "blocked": true}
+
+// Ward is a nursing unit made up of rooms of beds.
+type Ward struct {
+	ID        uuid.UUID `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	Code      string    `gorm:"uniqueIndex;not null" json:"code"`
+	Name      string    `gorm:"not null" json:"name"`
+	Specialty string    `json:"specialty,omitempty"`
+	Floor     string    `json:"floor,omitempty"`
+	Active    bool      `gorm:"not null;default:true" json:"active"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relationships
+	Rooms []Room `gorm:"foreignKey:WardID" json:"rooms,omitempty"`
+}
+
+// Room is a room on a ward. Its number is unique within the ward.
+type Room struct {
+	ID        uuid.UUID `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	WardID    uuid.UUID `gorm:"not null;uniqueIndex:idx_room_ward_number" json:"ward_id"`
+	Number    string    `gorm:"not null;uniqueIndex:idx_room_ward_number" json:"number"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relationships
+	Beds []Bed `gorm:"foreignKey:RoomID" json:"beds,omitempty"`
+}
+
+// Bed is a bed in a room. Its label is unique within the room.
+type Bed struct {
+	ID         uuid.UUID `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	RoomID     uuid.UUID `gorm:"not null;uniqueIndex:idx_bed_room_label" json:"room_id"`
+	WardID     uuid.UUID `gorm:"not null;index" json:"ward_id"` // The room's ward, kept here for the bed board
+	Label      string    `gorm:"not null;uniqueIndex:idx_bed_room_label" json:"label"`
+	Status     string    `gorm:"type:text CHECK (status IN ('free','occupied','cleaning','blocked'));not null;default:'free'" json:"status"`
+	StatusNote string    `gorm:"type:text" json:"status_note,omitempty"` // Why a bed is blocked, for example
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relationships
+	Room *Room `gorm:"foreignKey:RoomID" json:"room,omitempty"`
+	Ward *Ward `gorm:"foreignKey:WardID" json:"ward,omitempty"`
+	// The admission in progress in the bed, when preloaded
+	Admission *Admission `gorm:"foreignKey:BedID" json:"admission,omitempty"`
+}
+
+// Admission is a patient's inpatient stay. A patient has at most one admission
+// in progress and a bed holds at most one of them.
+type Admission struct {
+	ID                uuid.UUID  `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	PatientID         uuid.UUID  `gorm:"not null;index" json:"patient_id"`
+	AttendingDoctorID uuid.UUID  `gorm:"not null;index" json:"attending_doctor_id"`
+	BedID             uuid.UUID  `gorm:"not null;index" json:"bed_id"` // Current bed; earlier beds are in Transfers
+	Reason            string     `gorm:"type:text;not null" json:"reason"`
+	Status            string     `gorm:"type:text CHECK (status IN ('admitted','discharged'));not null;default:'admitted';index" json:"status"`
+	AdmittedAt        time.Time  `gorm:"not null" json:"admitted_at"`
+	AdmittedBy        uuid.UUID  `gorm:"not null" json:"admitted_by"`
+	DischargedAt      *time.Time `json:"discharged_at,omitempty"`
+	DischargedBy      *uuid.UUID `gorm:"type:uuid" json:"discharged_by,omitempty"`
+	Disposition       string     `gorm:"type:text" json:"disposition,omitempty"` // Where the patient went, e.g. home or another facility
+	DischargeNotes    string     `gorm:"type:text" json:"discharge_notes,omitempty"`
+	CreatedAt         time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt         time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relationships
+	Patient         *Patient      `gorm:"foreignKey:PatientID" json:"patient,omitempty"`
+	AttendingDoctor *User         `gorm:"foreignKey:AttendingDoctorID" json:"attending_doctor,omitempty"`
+	Bed             *Bed          `gorm:"foreignKey:BedID" json:"bed,omitempty"`
+	Transfers       []BedTransfer `gorm:"foreignKey:AdmissionID" json:"transfers,omitempty"`
+}
+
+// BedTransfer records an admission moving from one bed to another.
+type BedTransfer struct {
+	ID            uuid.UUID `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	AdmissionID   uuid.UUID `gorm:"not null;index" json:"admission_id"`
+	FromBedID     uuid.UUID `gorm:"not null" json:"from_bed_id"`
+	ToBedID       uuid.UUID `gorm:"not null" json:"to_bed_id"`
+	Reason        string    `gorm:"type:text" json:"reason,omitempty"`
+	TransferredBy uuid.UUID `gorm:"not null" json:"transferred_by"`
+	TransferredAt time.Time `gorm:"not null" json:"transferred_at"`
+
+	// Relationships
+	FromBed *Bed `gorm:"foreignKey:FromBedID" json:"from_bed,omitempty"`
+	ToBed   *Bed `gorm:"foreignKey:ToBedID" json:"to_bed,omitempty"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PatientAllergy is an entry on the patient's allergy list. Active entries are
+// checked against every new prescription.
+type PatientAllergy struct {
+	ID         uuid.UUID `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	PatientID  uuid.UUID `gorm:"not null;index" json:"patient_id"`
+	Substance  string    `gorm:"not null" json:"substance"`
+	Reaction   string    `gorm:"type:text" json:"reaction,omitempty"`
+	Severity   string    `gorm:"type:text CHECK (severity IN ('mild','moderate','severe'));default:'moderate'" json:"severity"`
+	Status     string    `gorm:"type:text CHECK (status IN ('active','inactive','resolved'));default:'active'" json:"status"`
+	RecordedBy uuid.UUID `gorm:"not null" json:"recorded_by"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
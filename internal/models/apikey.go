@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKey authenticates an external system such as a pharmacy or the lab.
+// Only the SHA-256 hash of the key is stored.
+type APIKey struct {
+	ID         uuid.UUID  `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	Name       string     `gorm:"not null" json:"name"`
+	Scope      string     `gorm:"not null;index" json:"scope"` // One of APIKeyScopes
+	KeyHash    string     `gorm:"uniqueIndex;not null" json:"-"`
+	Active     bool       `gorm:"not null;default:true" json:"active"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// APIKeyScopes lists the external systems a key can be issued for.
+var APIKeyScopes = map[string]bool{"pharmacy": true, "lab": true}
+
+func (APIKey) TableName() string {
+	return "api_keys"
+}
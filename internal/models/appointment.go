@@ -6,13 +6,17 @@ import (
 	"github.com/google/uuid"
 )
 
+var AppointmentTypes = map[string]bool{"consultation": true, "follow_up": true, "procedure": true, "emergency": true}
+
 type Appointment struct {
 	ID              uuid.UUID `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
 	PatientID       uuid.UUID `gorm:"not null" json:"patient_id"`
 	DoctorID        uuid.UUID `gorm:"not null" json:"doctor_id"`
 	AppointmentDate time.Time `gorm:"not null" json:"appointment_date"`
 	Status          string    `gorm:"type:text CHECK (status IN ('scheduled','completed','cancelled'));default:'scheduled'" json:"status"`
+	Type            string    `gorm:"type:text CHECK (type IN ('consultation','follow_up','procedure','emergency'));default:'consultation'" json:"type"`
 	Notes           string    `gorm:"type:text" json:"notes,omitempty"`
+	Version         int       `gorm:"not null;default:1" json:"version"` // Bumped by the database on every update
 	CreatedAt       time.Time `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt       time.Time `gorm:"autoUpdateTime" json:"updated_at"`
 
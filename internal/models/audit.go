@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditEvent records a security or safety relevant action, such as a doctor
+// overriding a prescribing safety check.
+type AuditEvent struct {
+	ID         uuid.UUID      `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	UserID     *uuid.UUID     `gorm:"type:uuid;index" json:"user_id,omitempty"`
+	Action     string         `gorm:"not null;index" json:"action"`
+	EntityType string         `gorm:"not null" json:"entity_type"`
+	EntityID   uuid.UUID      `gorm:"type:uuid;not null;index" json:"entity_id"`
+	Details    map[string]any `gorm:"serializer:json;type:jsonb" json:"details,omitempty"`
+	CreatedAt  time.Time      `gorm:"autoCreateTime" json:"created_at"`
+}
@@ -0,0 +1,164 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Amounts in billing are whole minor currency units (cents) so totals never
+// pick up floating point error. Tax rates are percentages.
+
+// ServicePrice is an entry in the price list. A price with an AppointmentType
+// is charged automatically when an appointment of that type is completed; one
+// that also has a DoctorID is that doctor's fee and wins over the general price.
+type ServicePrice struct {
+	ID              uuid.UUID  `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	Code            string     `gorm:"uniqueIndex;not null" json:"code"`
+	Name            string     `gorm:"not null" json:"name"`
+	ProcedureCode   string     `json:"procedure_code,omitempty"` // CPT/HCPCS code put on insurance claims
+	AppointmentType string     `gorm:"type:text CHECK (appointment_type IN ('','consultation','follow_up','procedure','emergency'));default:''" json:"appointment_type,omitempty"`
+	DoctorID        *uuid.UUID `gorm:"type:uuid" json:"doctor_id,omitempty"`
+	UnitPrice       int64      `gorm:"not null;check:unit_price >= 0" json:"unit_price"`
+	TaxRate         float64    `gorm:"type:numeric(5,2);not null;default:0" json:"tax_rate"`
+	Active          bool       `gorm:"not null;default:true" json:"active"`
+	CreatedAt       time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relationships
+	Doctor *User `gorm:"foreignKey:DoctorID" json:"doctor,omitempty"`
+}
+
+// Charge is a billable item for a patient that has not necessarily been
+// invoiced yet. Charges for completed appointments are captured automatically;
+// others are entered by reception.
+type Charge struct {
+	ID             uuid.UUID  `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	PatientID      uuid.UUID  `gorm:"not null;index" json:"patient_id"`
+	AppointmentID  *uuid.UUID `gorm:"type:uuid;index" json:"appointment_id,omitempty"`
+	ServicePriceID *uuid.UUID `gorm:"type:uuid" json:"service_price_id,omitempty"`
+	Description    string     `gorm:"not null" json:"description"`
+	ProcedureCode  string     `json:"procedure_code,omitempty"`
+	Quantity       int        `gorm:"not null;default:1;check:quantity > 0" json:"quantity"`
+	UnitPrice      int64      `gorm:"not null" json:"unit_price"`
+	TaxRate        float64    `gorm:"type:numeric(5,2);not null;default:0" json:"tax_rate"`
+	InvoiceID      *uuid.UUID `gorm:"type:uuid;index" json:"invoice_id,omitempty"` // Empty until billed
+	CreatedBy      uuid.UUID  `gorm:"not null" json:"created_by"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+
+	// Relationships
+	Patient *Patient `gorm:"foreignKey:PatientID" json:"patient,omitempty"`
+}
+
+// Invoice bills a patient for a set of charges. Its number is sequential
+// within the fiscal year, e.g. INV-2026-000042.
+type Invoice struct {
+	ID         uuid.UUID `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	Number     string    `gorm:"uniqueIndex;not null" json:"number"`
+	FiscalYear int       `gorm:"not null" json:"fiscal_year"`
+	PatientID  uuid.UUID `gorm:"not null;index" json:"patient_id"`
+	Status     string    `gorm:"type:text CHECK (status IN ('issued','partially_paid','paid','void'));default:'issued'" json:"status"`
+	IssuedAt   time.Time `gorm:"not null" json:"issued_at"`
+	DueDate    Date      `gorm:"not null" json:"due_date"`
+	Subtotal   int64     `gorm:"not null" json:"subtotal"`
+	Tax        int64     `gorm:"not null" json:"tax"`
+	Total      int64     `gorm:"not null" json:"total"`
+	AmountPaid int64     `gorm:"not null;default:0" json:"amount_paid"`
+	Currency   string    `gorm:"not null" json:"currency"`
+	Notes      string    `gorm:"type:text" json:"notes,omitempty"`
+	VoidReason string    `gorm:"type:text" json:"void_reason,omitempty"`
+	CreatedBy  uuid.UUID `gorm:"not null" json:"created_by"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relationships
+	Patient  *Patient      `gorm:"foreignKey:PatientID" json:"patient,omitempty"`
+	Lines    []InvoiceLine `gorm:"foreignKey:InvoiceID" json:"lines,omitempty"`
+	Payments []Payment     `gorm:"foreignKey:InvoiceID" json:"payments,omitempty"`
+	Refunds  []Refund      `gorm:"foreignKey:InvoiceID" json:"refunds,omitempty"`
+}
+
+// Balance is the amount still owed on the invoice. AmountPaid is net of refunds.
+func (i *Invoice) Balance() int64 {
+	return i.Total - i.AmountPaid
+}
+
+// InvoiceLine is a charge as it was billed. The amounts are copied so the
+// invoice never changes after it is issued.
+type InvoiceLine struct {
+	ID          uuid.UUID  `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	InvoiceID   uuid.UUID  `gorm:"not null;index" json:"invoice_id"`
+	ChargeID    *uuid.UUID `gorm:"type:uuid" json:"charge_id,omitempty"`
+	Description string     `gorm:"not null" json:"description"`
+	Quantity    int        `gorm:"not null" json:"quantity"`
+	UnitPrice   int64      `gorm:"not null" json:"unit_price"`
+	TaxRate     float64    `gorm:"type:numeric(5,2);not null" json:"tax_rate"`
+	Subtotal    int64      `gorm:"not null" json:"subtotal"`
+	Tax         int64      `gorm:"not null" json:"tax"`
+	Total       int64      `gorm:"not null" json:"total"`
+}
+
+// Payment is money received against an invoice. An invoice can be settled by
+// several payments, each by cash, card or insurance.
+type Payment struct {
+	ID                uuid.UUID  `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	InvoiceID         uuid.UUID  `gorm:"not null;index" json:"invoice_id"`
+	Method            string     `gorm:"type:text CHECK (method IN ('cash','card','insurance'));not null" json:"method"`
+	Amount            int64      `gorm:"not null;check:amount > 0" json:"amount"`
+	Reference         string     `json:"reference,omitempty"` // Card terminal slip or insurer's payment reference
+	InsurancePolicyID *uuid.UUID `gorm:"type:uuid" json:"insurance_policy_id,omitempty"`
+	ReceivedBy        uuid.UUID  `gorm:"not null;index" json:"received_by"`
+	ReceivedAt        time.Time  `gorm:"not null" json:"received_at"`
+	DrawerCloseID     *uuid.UUID `gorm:"type:uuid;index" json:"drawer_close_id,omitempty"` // Set when the shift is closed; the payment is then locked
+	CreatedAt         time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// Refund gives back part or all of a payment, paid out the same way the
+// payment came in.
+type Refund struct {
+	ID            uuid.UUID  `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	PaymentID     uuid.UUID  `gorm:"not null;index" json:"payment_id"`
+	InvoiceID     uuid.UUID  `gorm:"not null;index" json:"invoice_id"`
+	Method        string     `gorm:"type:text CHECK (method IN ('cash','card','insurance'));not null" json:"method"`
+	Amount        int64      `gorm:"not null;check:amount > 0" json:"amount"`
+	Reason        string     `gorm:"type:text;not null" json:"reason"`
+	RefundedBy    uuid.UUID  `gorm:"not null;index" json:"refunded_by"`
+	RefundedAt    time.Time  `gorm:"not null" json:"refunded_at"`
+	DrawerCloseID *uuid.UUID `gorm:"type:uuid;index" json:"drawer_close_id,omitempty"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// DrawerClose is a receptionist's end-of-shift close. It takes every payment
+// and refund they handled since their last close, which are locked from then
+// on, and compares the cash that should be in the drawer with the cash counted.
+type DrawerClose struct {
+	ID                uuid.UUID  `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	ReceptionistID    uuid.UUID  `gorm:"not null;index" json:"receptionist_id"`
+	PeriodStart       *time.Time `json:"period_start,omitempty"` // First payment or refund in the shift
+	ClosedAt          time.Time  `gorm:"not null" json:"closed_at"`
+	PaymentCount      int        `gorm:"not null" json:"payment_count"`
+	CashPayments      int64      `gorm:"not null" json:"cash_payments"`
+	CardPayments      int64      `gorm:"not null" json:"card_payments"`
+	InsurancePayments int64      `gorm:"not null" json:"insurance_payments"`
+	RefundCount       int        `gorm:"not null" json:"refund_count"`
+	CashRefunds       int64      `gorm:"not null" json:"cash_refunds"`
+	CardRefunds       int64      `gorm:"not null" json:"card_refunds"`
+	InsuranceRefunds  int64      `gorm:"not null" json:"insurance_refunds"`
+	OpeningFloat      int64      `gorm:"not null" json:"opening_float"`
+	ExpectedCash      int64      `gorm:"not null" json:"expected_cash"` // Float plus cash payments minus cash refunds
+	CountedCash       int64      `gorm:"not null" json:"counted_cash"`
+	Variance          int64      `gorm:"not null" json:"variance"` // Counted minus expected; negative when cash is short
+	Notes             string     `gorm:"type:text" json:"notes,omitempty"`
+	CreatedAt         time.Time  `gorm:"autoCreateTime" json:"created_at"`
+
+	// Relationships
+	Receptionist *User `gorm:"foreignKey:ReceptionistID" json:"receptionist,omitempty"`
+}
+
+// InvoiceSequence holds the last invoice number issued in a fiscal year.
+type InvoiceSequence struct {
+	FiscalYear int   `gorm:"primaryKey;autoIncrement:false"`
+	LastValue  int64 `gorm:"not null"`
+}
+
+var PaymentMethods = map[string]bool{"cash": true, "card": true, "insurance": true}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CareTeamRoles lists the roles a doctor can hold on a patient's care team.
+var CareTeamRoles = map[string]bool{"primary": true, "consulting": true, "covering": true}
+
+// CareTeamMember gives a doctor access to a patient for a period of time. A
+// membership is in effect from EffectiveFrom until EffectiveTo; an empty
+// EffectiveTo means it has no end. A patient has at most one open-ended
+// primary doctor.
+type CareTeamMember struct {
+	ID            uuid.UUID  `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	PatientID     uuid.UUID  `gorm:"not null;index" json:"patient_id"`
+	DoctorID      uuid.UUID  `gorm:"not null;index" json:"doctor_id"`
+	Role          string     `gorm:"type:text CHECK (role IN ('primary','consulting','covering'));not null" json:"role"`
+	EffectiveFrom time.Time  `gorm:"not null" json:"effective_from"`
+	EffectiveTo   *time.Time `json:"effective_to,omitempty"`
+	Notes         string     `gorm:"type:text" json:"notes,omitempty"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relationships
+	Doctor *User `gorm:"foreignKey:DoctorID" json:"doctor,omitempty"`
+}
+
+// ActiveAt reports whether the membership is in effect at t.
+func (m *CareTeamMember) ActiveAt(t time.Time) bool {
+	return !m.EffectiveFrom.After(t) && (m.EffectiveTo == nil || m.EffectiveTo.After(t))
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Claim asks an insurer to pay for one completed encounter. It is drafted
+// from the appointment's diagnoses and charges and then follows the payer's
+// answers: submitted, accepted or denied, and finally paid. Amounts are in
+// minor currency units like the rest of billing.
+type Claim struct {
+	ID                    uuid.UUID  `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	Number                string     `gorm:"uniqueIndex;not null" json:"number"` // Sent to the payer as the claim control number
+	PatientID             uuid.UUID  `gorm:"not null;index" json:"patient_id"`
+	AppointmentID         uuid.UUID  `gorm:"not null;index" json:"appointment_id"`
+	InsurancePolicyID     uuid.UUID  `gorm:"not null" json:"insurance_policy_id"`
+	PayerID               uuid.UUID  `gorm:"not null" json:"payer_id"`
+	InvoiceID             *uuid.UUID `gorm:"type:uuid;index" json:"invoice_id,omitempty"` // Where insurance payments are posted
+	Status                string     `gorm:"type:text CHECK (status IN ('draft','submitted','accepted','denied','paid'));default:'draft'" json:"status"`
+	ServiceDate           Date       `gorm:"not null" json:"service_date"`
+	TotalCharged          int64      `gorm:"not null" json:"total_charged"`
+	AmountPaid            int64      `gorm:"not null;default:0" json:"amount_paid"`
+	PatientResponsibility int64      `gorm:"not null;default:0" json:"patient_responsibility"`
+	PayerClaimID          string     `json:"payer_claim_id,omitempty"` // The payer's own reference, from the remittance
+	DenialReason          string     `json:"denial_reason,omitempty"`
+	NeedsFollowUp         bool       `gorm:"not null;default:false;index" json:"needs_follow_up"`
+	FollowUpReason        string     `json:"follow_up_reason,omitempty"`
+	SubmittedAt           *time.Time `json:"submitted_at,omitempty"`
+	AdjudicatedAt         *time.Time `json:"adjudicated_at,omitempty"`
+	PaidAt                *time.Time `json:"paid_at,omitempty"`
+	CreatedBy             uuid.UUID  `gorm:"not null" json:"created_by"`
+	CreatedAt             time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt             time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relationships
+	Patient   *Patient         `gorm:"foreignKey:PatientID" json:"patient,omitempty"`
+	Policy    *InsurancePolicy `gorm:"foreignKey:InsurancePolicyID" json:"policy,omitempty"`
+	Diagnoses []ClaimDiagnosis `gorm:"foreignKey:ClaimID" json:"diagnoses,omitempty"`
+	Lines     []ClaimLine      `gorm:"foreignKey:ClaimID" json:"lines,omitempty"`
+}
+
+// ClaimDiagnosis is an ICD-10 code on a claim. Sequence 1 is the principal
+// diagnosis.
+type ClaimDiagnosis struct {
+	ID       uuid.UUID `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	ClaimID  uuid.UUID `gorm:"not null;index" json:"claim_id"`
+	Sequence int       `gorm:"not null" json:"sequence"`
+	Code     string    `gorm:"type:varchar(10);not null" json:"code"`
+}
+
+// ClaimLine is a billed procedure on a claim, copied from the encounter's charges.
+type ClaimLine struct {
+	ID            uuid.UUID  `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	ClaimID       uuid.UUID  `gorm:"not null;index" json:"claim_id"`
+	ChargeID      *uuid.UUID `gorm:"type:uuid" json:"charge_id,omitempty"`
+	ProcedureCode string     `gorm:"not null" json:"procedure_code"`
+	Description   string     `gorm:"not null" json:"description"`
+	Quantity      int        `gorm:"not null" json:"quantity"`
+	Charged       int64      `gorm:"not null" json:"charged"`
+}
+
+// RemittanceBatch is one imported remittance file.
+type RemittanceBatch struct {
+	ID         uuid.UUID `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	FileName   string    `json:"file_name"`
+	ImportedBy uuid.UUID `gorm:"not null" json:"imported_by"`
+	Rows       int       `gorm:"not null" json:"rows"`
+	Matched    int       `gorm:"not null" json:"matched"`
+	Unmatched  int       `gorm:"not null" json:"unmatched"`
+	Flagged    int       `gorm:"not null" json:"flagged"` // Denials and underpayments needing follow-up
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	// Relationships
+	Lines []RemittanceLine `gorm:"foreignKey:BatchID" json:"lines,omitempty"`
+}
+
+// RemittanceLine is one claim payment or denial from a remittance file and
+// what the import did with it.
+type RemittanceLine struct {
+	ID                    uuid.UUID  `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	BatchID               uuid.UUID  `gorm:"not null;index" json:"batch_id"`
+	Line                  int        `gorm:"not null" json:"line"`
+	ClaimNumber           string     `json:"claim_number"`
+	ClaimID               *uuid.UUID `gorm:"type:uuid;index" json:"claim_id,omitempty"`
+	PayerClaimID          string     `json:"payer_claim_id,omitempty"`
+	StatusCode            string     `json:"status_code"`
+	Charged               int64      `json:"charged"`
+	Paid                  int64      `json:"paid"`
+	PatientResponsibility int64      `json:"patient_responsibility"`
+	AdjustmentCode        string     `json:"adjustment_code,omitempty"`
+	CheckNumber           string     `json:"check_number,omitempty"`
+	PaidDate              *Date      `json:"paid_date,omitempty"`
+	Outcome               string     `gorm:"type:text CHECK (outcome IN ('matched','unmatched','duplicate','rejected'));not null" json:"outcome"`
+	Flag                  string     `gorm:"type:text CHECK (flag IN ('','denied','underpaid'))" json:"flag,omitempty"`
+	Message               string     `json:"message,omitempty"`
+}
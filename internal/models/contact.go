@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ContactRelationships lists how a contact can be related to the patient.
+var ContactRelationships = map[string]bool{
+	"spouse":    true,
+	"partner":   true,
+	"parent":    true,
+	"child":     true,
+	"sibling":   true,
+	"guardian":  true,
+	"relative":  true,
+	"friend":    true,
+	"caregiver": true,
+	"other":     true,
+}
+
+// PatientContact is an emergency contact, next of kin or guardian of a
+// patient. Contacts are tried in order of Priority, lowest first.
+type PatientContact struct {
+	ID           uuid.UUID `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	PatientID    uuid.UUID `gorm:"not null;index" json:"patient_id"`
+	Name         string    `gorm:"not null" json:"name"`
+	Relationship string    `gorm:"not null" json:"relationship"` // One of ContactRelationships
+	Phone        string    `json:"phone,omitempty"`
+	Email        string    `json:"email,omitempty"`
+	Priority     int       `gorm:"not null;default:1" json:"priority"`
+	// MayReceiveClinicalInfo records the patient's consent to share clinical
+	// details with this contact. Legal guardians always may.
+	MayReceiveClinicalInfo bool      `gorm:"not null;default:false" json:"may_receive_clinical_info"`
+	LegalGuardian          bool      `gorm:"not null;default:false" json:"legal_guardian"`
+	Notes                  string    `gorm:"type:text" json:"notes,omitempty"`
+	CreatedAt              time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt              time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
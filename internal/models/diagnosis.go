@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Diagnosis is a coded diagnosis recorded by a doctor. Diagnoses with status
+// active or chronic make up the patient's problem list.
+type Diagnosis struct {
+	ID            uuid.UUID  `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	PatientID     uuid.UUID  `gorm:"not null;index" json:"patient_id"`
+	DoctorID      uuid.UUID  `gorm:"not null" json:"doctor_id"`
+	AppointmentID *uuid.UUID `gorm:"type:uuid;index" json:"appointment_id,omitempty"` // Encounter the diagnosis was made in
+	Code          string     `gorm:"type:varchar(10);not null" json:"code"`
+	Status        string     `gorm:"type:text CHECK (status IN ('active','resolved','chronic'));default:'active'" json:"status"`
+	OnsetDate     *time.Time `json:"onset_date,omitempty"`
+	ResolvedAt    *time.Time `json:"resolved_at,omitempty"`
+	Notes         string     `gorm:"type:text" json:"notes,omitempty"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relationships
+	ICD10       ICD10Code    `gorm:"foreignKey:Code;references:Code" json:"icd10,omitempty"`
+	Appointment *Appointment `gorm:"foreignKey:AppointmentID" json:"appointment,omitempty"`
+	Doctor      User         `gorm:"foreignKey:DoctorID" json:"doctor,omitempty"`
+}
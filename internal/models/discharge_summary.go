@@ -0,0 +1,69 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DischargeSummary is the attending doctor's account of an admission. It is
+// pre-filled from the records of the stay when it is drafted, can be edited
+// while it is a draft and cannot be changed once signed.
+type DischargeSummary struct {
+	ID                 uuid.UUID  `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	AdmissionID        uuid.UUID  `gorm:"not null;uniqueIndex" json:"admission_id"`
+	PatientID          uuid.UUID  `gorm:"not null;index" json:"patient_id"`
+	DoctorID           uuid.UUID  `gorm:"not null;index" json:"doctor_id"` // Attending doctor, who writes and signs it
+	Status             string     `gorm:"type:text CHECK (status IN ('draft','signed'));not null;default:'draft'" json:"status"`
+	AdmissionReason    string     `gorm:"type:text" json:"admission_reason"`
+	HospitalCourse     string     `gorm:"type:text" json:"hospital_course"`
+	DischargeCondition string     `gorm:"type:text" json:"discharge_condition"`
+	Instructions       string     `gorm:"type:text" json:"instructions"` // For the patient
+	SignedAt           *time.Time `json:"signed_at,omitempty"`
+	CreatedAt          time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt          time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Diagnoses            []SummaryDiagnosis  `gorm:"serializer:json;type:jsonb" json:"diagnoses"`
+	Procedures           []SummaryProcedure  `gorm:"serializer:json;type:jsonb" json:"procedures"`
+	MedicationsGiven     []SummaryMedication `gorm:"serializer:json;type:jsonb" json:"medications_given"`
+	DischargeMedications []SummaryMedication `gorm:"serializer:json;type:jsonb" json:"discharge_medications"`
+	FollowUps            []SummaryFollowUp   `gorm:"serializer:json;type:jsonb" json:"follow_ups"`
+
+	// Relationships
+	Admission *Admission `gorm:"foreignKey:AdmissionID" json:"admission,omitempty"`
+	Patient   *Patient   `gorm:"foreignKey:PatientID" json:"patient,omitempty"`
+	Doctor    *User      `gorm:"foreignKey:DoctorID" json:"doctor,omitempty"`
+}
+
+// SummaryDiagnosis is a diagnosis listed on a discharge summary.
+type SummaryDiagnosis struct {
+	Code        string `json:"code"` // ICD-10
+	Description string `json:"description"`
+	Status      string `json:"status,omitempty"`
+}
+
+// SummaryProcedure is a procedure performed during the stay.
+type SummaryProcedure struct {
+	Code        string     `json:"code,omitempty"`
+	Description string     `json:"description"`
+	PerformedAt *time.Time `json:"performed_at,omitempty"`
+}
+
+// SummaryMedication is a medication given during the stay or prescribed on
+// discharge.
+type SummaryMedication struct {
+	PrescriptionID *uuid.UUID `json:"prescription_id,omitempty"`
+	Medication     string     `json:"medication"`
+	Dosage         string     `json:"dosage,omitempty"`
+	Instructions   string     `json:"instructions,omitempty"`
+}
+
+// SummaryFollowUp is a follow-up appointment. Follow-ups without an
+// AppointmentID are booked when the summary is signed.
+type SummaryFollowUp struct {
+	DoctorID      uuid.UUID  `json:"doctor_id"`
+	Date          time.Time  `json:"date"`
+	Type          string     `json:"type"`
+	Notes         string     `json:"notes,omitempty"`
+	AppointmentID *uuid.UUID `json:"appointment_id,omitempty"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FHIRExport is a FHIR bulk data export, run in the background. The NDJSON
+// files it writes are kept in the blob store until ExpiresAt.
+type FHIRExport struct {
+	ID          uuid.UUID  `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	RequestedBy uuid.UUID  `gorm:"type:uuid;not null;index" json:"requested_by"`
+	Request     string     `gorm:"not null" json:"request"` // The kick-off URL
+	Types       []string   `gorm:"serializer:json;type:jsonb;not null" json:"types"`
+	Since       *time.Time `json:"since,omitempty"`
+	Status      string     `gorm:"type:text CHECK (status IN ('in_progress','completed','failed','cancelled','expired'));not null;default:'in_progress'" json:"status"`
+	Progress    string     `json:"progress,omitempty"` // The resource type being exported
+	Error       string     `json:"error,omitempty"`
+	// Changes from TransactionTime on may be missing from the files, so the
+	// next export can start from it with _since
+	TransactionTime time.Time        `gorm:"not null" json:"transaction_time"`
+	Files           []FHIRExportFile `gorm:"serializer:json;type:jsonb" json:"files,omitempty"`
+	CompletedAt     *time.Time       `json:"completed_at,omitempty"`
+	ExpiresAt       *time.Time       `gorm:"index" json:"expires_at,omitempty"`
+	CreatedAt       time.Time        `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// FHIRExportFile is one NDJSON file of an export, holding the resources of
+// one type.
+type FHIRExportFile struct {
+	Type       string `json:"type"`
+	StorageKey string `json:"storage_key"`
+	Count      int64  `json:"count"`
+}
+
+func (FHIRExport) TableName() string {
+	return "fhir_exports"
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FormularyItem is a drug product doctors can prescribe from, e.g.
+// amoxicillin 500 mg oral capsule.
+type FormularyItem struct {
+	ID          uuid.UUID `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	GenericName string    `gorm:"not null;uniqueIndex:idx_formulary_product" json:"generic_name"`
+	BrandNames  []string  `gorm:"serializer:json;type:jsonb" json:"brand_names,omitempty"`
+	Strength    string    `gorm:"not null;uniqueIndex:idx_formulary_product" json:"strength"` // e.g. "500 mg", "250 mg/5 ml"
+	Form        string    `gorm:"not null;uniqueIndex:idx_formulary_product" json:"form"`     // e.g. tablet, capsule, syrup
+	Route       string    `gorm:"not null;uniqueIndex:idx_formulary_product" json:"route"`    // e.g. oral, iv, topical
+	Active      bool      `gorm:"not null;default:true" json:"active"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// DisplayName is the product name used on prescriptions, e.g. "Amoxicillin 500 mg capsule".
+func (f FormularyItem) DisplayName() string {
+	name := f.GenericName
+	if name != "" {
+		name = strings.ToUpper(name[:1]) + name[1:]
+	}
+	return strings.Join(strings.Fields(name+" "+f.Strength+" "+f.Form), " ")
+}
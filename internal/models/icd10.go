@@ -0,0 +1,11 @@
+package models
+
+// ICD10Code is a single entry of the ICD-10 code catalog used when recording diagnoses.
+type ICD10Code struct {
+	Code        string `gorm:"primaryKey;type:varchar(10)" json:"code"`
+	Description string `gorm:"type:text;not null" json:"description"`
+}
+
+func (ICD10Code) TableName() string {
+	return "icd10_codes"
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ImmunizationSites and ImmunizationRoutes list where and how a dose can be
+// given.
+var (
+	ImmunizationSites  = map[string]bool{"left_arm": true, "right_arm": true, "left_thigh": true, "right_thigh": true, "oral": true, "nasal": true, "other": true}
+	ImmunizationRoutes = map[string]bool{"intramuscular": true, "subcutaneous": true, "intradermal": true, "oral": true, "intranasal": true}
+)
+
+// Vaccine is a vaccine from the immunization catalog, together with the
+// schedule of doses recommended by age.
+type Vaccine struct {
+	ID           uuid.UUID `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	Code         string    `gorm:"uniqueIndex;not null" json:"code"`
+	Name         string    `gorm:"not null" json:"name"`
+	CVXCode      string    `gorm:"column:cvx_code" json:"cvx_code,omitempty"` // CDC vaccine code, used in exchanges with registries
+	DefaultRoute string    `json:"default_route,omitempty"`
+	Active       bool      `gorm:"not null;default:true" json:"active"`
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Schedule []VaccineScheduleRule `gorm:"foreignKey:VaccineID" json:"schedule,omitempty"`
+}
+
+// VaccineScheduleRule recommends a dose of a vaccine at an age. The dose is
+// due at AgeMonths plus AgeDays after birth, or MinIntervalDays after the
+// previous dose if that is later, and overdue GraceDays after it is due. It
+// is no longer recommended from MaxAgeMonths.
+type VaccineScheduleRule struct {
+	ID              uuid.UUID `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	VaccineID       uuid.UUID `gorm:"not null;uniqueIndex:idx_vaccine_schedule_dose" json:"vaccine_id"`
+	DoseNumber      int       `gorm:"not null;uniqueIndex:idx_vaccine_schedule_dose;check:dose_number > 0" json:"dose_number"`
+	AgeMonths       int       `gorm:"not null;default:0" json:"age_months"`
+	AgeDays         int       `gorm:"not null;default:0" json:"age_days"`
+	MinIntervalDays int       `gorm:"not null;default:0" json:"min_interval_days"`
+	GraceDays       int       `gorm:"not null;default:0" json:"grace_days"`
+	MaxAgeMonths    *int      `json:"max_age_months,omitempty"`
+}
+
+// Immunization is a dose of a vaccine given to a patient.
+type Immunization struct {
+	ID             uuid.UUID `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	PatientID      uuid.UUID `gorm:"not null;index" json:"patient_id"`
+	VaccineID      uuid.UUID `gorm:"not null;index" json:"vaccine_id"`
+	AdministeredOn Date      `gorm:"not null" json:"administered_on"`
+	LotNumber      string    `gorm:"not null" json:"lot_number"`
+	ExpiryDate     *Date     `json:"expiry_date,omitempty"` // Of the lot
+	Site           string    `gorm:"type:text CHECK (site IN ('left_arm','right_arm','left_thigh','right_thigh','oral','nasal','other'));not null" json:"site"`
+	Route          string    `gorm:"type:text CHECK (route IN ('intramuscular','subcutaneous','intradermal','oral','intranasal'));not null" json:"route"`
+	AdministeredBy uuid.UUID `gorm:"not null" json:"administered_by"`
+	Notes          string    `gorm:"type:text" json:"notes,omitempty"`
+	CreatedAt      time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	// Relationships
+	Patient       *Patient `gorm:"foreignKey:PatientID" json:"patient,omitempty"`
+	Vaccine       *Vaccine `gorm:"foreignKey:VaccineID" json:"vaccine,omitempty"`
+	Administrator *User    `gorm:"foreignKey:AdministeredBy" json:"administered_by_user,omitempty"`
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// InsurancePayer is an insurance company or scheme that pays for care.
+type InsurancePayer struct {
+	ID        uuid.UUID `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	Name      string    `gorm:"uniqueIndex;not null" json:"name"`
+	PayerCode string    `gorm:"uniqueIndex;not null" json:"payer_code"` // Identifier used by the clearinghouse
+	Phone     string    `json:"phone,omitempty"`
+	Email     string    `json:"email,omitempty"`
+	Address   string    `json:"address,omitempty"`
+	Active    bool      `gorm:"not null;default:true" json:"active"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// InsurancePolicy is a patient's cover with a payer. Priority 1 is the
+// primary policy, 2 the secondary and so on. The policy covers dates from
+// ValidFrom through ValidTo; an empty ValidTo means it has no end date.
+type InsurancePolicy struct {
+	ID                     uuid.UUID  `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	PatientID              uuid.UUID  `gorm:"not null;index" json:"patient_id"`
+	PayerID                uuid.UUID  `gorm:"not null" json:"payer_id"`
+	MemberID               string     `gorm:"not null" json:"member_id"`
+	GroupNumber            string     `json:"group_number,omitempty"`
+	PlanName               string     `json:"plan_name,omitempty"`
+	SubscriberName         string     `json:"subscriber_name,omitempty"` // Policy holder, when not the patient
+	SubscriberRelationship string     `gorm:"type:text CHECK (subscriber_relationship IN ('self','spouse','parent','child','other'));default:'self'" json:"subscriber_relationship"`
+	Priority               int        `gorm:"not null;default:1" json:"priority"`
+	ValidFrom              Date       `gorm:"not null" json:"valid_from"`
+	ValidTo                *Date      `json:"valid_to,omitempty"`
+	CardFrontDocumentID    *uuid.UUID `gorm:"type:uuid" json:"card_front_document_id,omitempty"` // Scan of the insurance card, a patient document
+	CardBackDocumentID     *uuid.UUID `gorm:"type:uuid" json:"card_back_document_id,omitempty"`
+
+	// Outcome of the last eligibility check
+	EligibilityStatus    string     `gorm:"type:text CHECK (eligibility_status IN ('','eligible','ineligible','unknown'))" json:"eligibility_status,omitempty"`
+	EligibilityMessage   string     `json:"eligibility_message,omitempty"`
+	EligibilityCheckedAt *time.Time `json:"eligibility_checked_at,omitempty"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relationships
+	Patient *Patient       `gorm:"foreignKey:PatientID" json:"patient,omitempty"`
+	Payer   InsurancePayer `gorm:"foreignKey:PayerID" json:"payer,omitempty"`
+}
+
+// CoversDate reports whether the policy is valid on the given date.
+func (p *InsurancePolicy) CoversDate(date Date) bool {
+	return !p.ValidFrom.After(date.Time) && (p.ValidTo == nil || !p.ValidTo.Before(date.Time))
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DrugInteraction is a known interaction between two drugs. DrugA and DrugB are
+// lower-case generic names stored in alphabetical order so a pair is only
+// recorded once.
+type DrugInteraction struct {
+	ID        uuid.UUID `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	DrugA     string    `gorm:"not null;uniqueIndex:idx_drug_interaction_pair" json:"drug_a"`
+	DrugB     string    `gorm:"not null;uniqueIndex:idx_drug_interaction_pair" json:"drug_b"`
+	Severity  string    `gorm:"type:text CHECK (severity IN ('minor','moderate','severe'));not null" json:"severity"`
+	Message   string    `gorm:"type:text;not null" json:"message"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LabTest is an orderable investigation from the lab catalog.
+type LabTest struct {
+	ID        uuid.UUID `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	Code      string    `gorm:"uniqueIndex;not null" json:"code"`
+	Name      string    `gorm:"not null" json:"name"`
+	Specimen  string    `gorm:"not null" json:"specimen"` // e.g. blood, urine
+	Unit      string    `json:"unit,omitempty"`
+	Active    bool      `gorm:"not null;default:true" json:"active"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	ReferenceRanges []LabReferenceRange `gorm:"foreignKey:LabTestID" json:"reference_ranges,omitempty"`
+}
+
+// LabReferenceRange is the normal range of a test for a sex and age band.
+// Sex "any" and open age bounds make a range apply more broadly.
+type LabReferenceRange struct {
+	ID          uuid.UUID `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	LabTestID   uuid.UUID `gorm:"not null;index" json:"lab_test_id"`
+	Sex         string    `gorm:"type:text CHECK (sex IN ('male','female','any'));default:'any'" json:"sex"`
+	AgeMinYears *int      `json:"age_min_years,omitempty"` // Inclusive
+	AgeMaxYears *int      `json:"age_max_years,omitempty"` // Exclusive
+	Low         *float64  `json:"low,omitempty"`
+	High        *float64  `json:"high,omitempty"`
+}
+
+// LabOrder is an investigation a doctor ordered for a patient. Its status moves
+// through ordered, collected, resulted and reviewed.
+type LabOrder struct {
+	ID            uuid.UUID  `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	PatientID     uuid.UUID  `gorm:"not null;index" json:"patient_id"`
+	DoctorID      uuid.UUID  `gorm:"not null;index" json:"doctor_id"`
+	AppointmentID *uuid.UUID `gorm:"type:uuid;index" json:"appointment_id,omitempty"`
+	LabTestID     uuid.UUID  `gorm:"not null" json:"lab_test_id"`
+	Status        string     `gorm:"type:text CHECK (status IN ('ordered','collected','resulted','reviewed'));default:'ordered';index" json:"status"`
+	Priority      string     `gorm:"type:text CHECK (priority IN ('routine','urgent'));default:'routine'" json:"priority"`
+	ClinicalNotes string     `gorm:"type:text" json:"clinical_notes,omitempty"`
+	CollectedAt   *time.Time `json:"collected_at,omitempty"`
+	ResultedAt    *time.Time `json:"resulted_at,omitempty"`
+	ReviewedAt    *time.Time `json:"reviewed_at,omitempty"`
+	ReviewedBy    *uuid.UUID `gorm:"type:uuid" json:"reviewed_by,omitempty"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relationships
+	LabTest LabTest    `gorm:"foreignKey:LabTestID" json:"lab_test,omitempty"`
+	Patient *Patient   `gorm:"foreignKey:PatientID" json:"patient,omitempty"`
+	Result  *LabResult `gorm:"foreignKey:LabOrderID" json:"result,omitempty"`
+}
+
+// LabResult is the result the lab system reported for an order. The reference
+// range used for flagging is copied onto the result.
+type LabResult struct {
+	ID            uuid.UUID `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	LabOrderID    uuid.UUID `gorm:"uniqueIndex;not null" json:"lab_order_id"`
+	Value         *float64  `json:"value,omitempty"`
+	ValueText     string    `gorm:"type:text" json:"value_text,omitempty"` // For non-numeric results
+	Unit          string    `json:"unit,omitempty"`
+	ReferenceLow  *float64  `json:"reference_low,omitempty"`
+	ReferenceHigh *float64  `json:"reference_high,omitempty"`
+	Flag          string    `gorm:"type:text CHECK (flag IN ('normal','low','high',''))" json:"flag,omitempty"`
+	Comment       string    `gorm:"type:text" json:"comment,omitempty"`
+	ReportedBy    uuid.UUID `gorm:"not null" json:"reported_by"` // API key of the lab system
+	CreatedAt     time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
@@ -7,15 +7,68 @@ import (
 )
 
 type Patient struct {
-	ID                   uuid.UUID      `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
-	Name                 string         `gorm:"not null" json:"name"`
-	Email                string         `gorm:"uniqueIndex;not null" json:"email"`
-	Phone                string         `gorm:"not null" json:"phone"`
-	Address              string         `gorm:"not null" json:"address"`
-	CreatedAt            time.Time      `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt            time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
-	PatientPrescriptions []Prescription `gorm:"foreignKey:PatientID" json:"patient_prescriptions,omitempty"`
-	PatientAppointments  []Appointment  `gorm:"foreignKey:PatientID" json:"patient_appointments,omitempty"`
-	UserID               uuid.UUID      `gorm:"not null" json:"user_id"`                            // Foreign key to the doctor treating the patient
-	User                 User           `gorm:"foreignKey:UserID" json:"doctor_assigned,omitempty"` // Relationship to User model
+	ID                   uuid.UUID        `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	MRN                  string           `gorm:"column:mrn;uniqueIndex;not null" json:"mrn"` // Medical record number, assigned on registration
+	Name                 string           `gorm:"not null" json:"name"`
+	Email                string           `gorm:"uniqueIndex;not null" json:"email"`
+	Phone                string           `gorm:"not null" json:"phone"`
+	Address              string           `gorm:"not null" json:"address"` // One-line address, composed from the structured fields when they are given
+	DateOfBirth          *Date            `json:"date_of_birth,omitempty"`
+	Sex                  string           `gorm:"type:text CHECK (sex IN ('male','female','other','unknown'));default:'unknown'" json:"sex"`
+	Gender               string           `json:"gender,omitempty"` // Gender identity as stated by the patient
+	BloodGroup           string           `gorm:"type:text CHECK (blood_group IN ('','A+','A-','B+','B-','AB+','AB-','O+','O-'))" json:"blood_group,omitempty"`
+	NationalID           string           `gorm:"index" json:"national_id,omitempty"`
+	InsuranceNumber      string           `gorm:"index" json:"insurance_number,omitempty"`
+	PreferredLanguage    string           `json:"preferred_language,omitempty"` // BCP 47 tag, e.g. "en" or "pt-BR"
+	AddressLine1         string           `json:"address_line1,omitempty"`
+	AddressLine2         string           `json:"address_line2,omitempty"`
+	City                 string           `json:"city,omitempty"`
+	State                string           `json:"state,omitempty"`
+	PostalCode           string           `json:"postal_code,omitempty"`
+	Country              string           `json:"country,omitempty"`                 // ISO 3166-1 alpha-2
+	Version              int              `gorm:"not null;default:1" json:"version"` // Bumped by the database on every update
+	CreatedAt            time.Time        `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt            time.Time        `gorm:"autoUpdateTime" json:"updated_at"`
+	PatientPrescriptions []Prescription   `gorm:"foreignKey:PatientID" json:"patient_prescriptions,omitempty"`
+	PatientAppointments  []Appointment    `gorm:"foreignKey:PatientID" json:"patient_appointments,omitempty"`
+	Diagnoses            []Diagnosis      `gorm:"foreignKey:PatientID" json:"diagnoses,omitempty"`
+	Allergies            []PatientAllergy `gorm:"foreignKey:PatientID" json:"allergies,omitempty"`
+	CareTeam             []CareTeamMember `gorm:"foreignKey:PatientID" json:"care_team,omitempty"` // Doctors treating the patient
+	Contacts             []PatientContact `gorm:"foreignKey:PatientID" json:"contacts,omitempty"`
+}
+
+// AgeAt returns the patient's age in whole years at t, if the date of birth is known.
+func (p *Patient) AgeAt(t time.Time) (int, bool) {
+	if p.DateOfBirth == nil {
+		return 0, false
+	}
+	dob := p.DateOfBirth.Time
+	age := t.Year() - dob.Year()
+	if t.Month() < dob.Month() || (t.Month() == dob.Month() && t.Day() < dob.Day()) {
+		age--
+	}
+	return age, true
+}
+
+// PrimaryDoctorID returns the doctor of the primary care-team membership in
+// effect now. CareTeam must be loaded.
+func (p *Patient) PrimaryDoctorID() (uuid.UUID, bool) {
+	now := time.Now()
+	for i := range p.CareTeam {
+		if p.CareTeam[i].Role == "primary" && p.CareTeam[i].ActiveAt(now) {
+			return p.CareTeam[i].DoctorID, true
+		}
+	}
+	return uuid.Nil, false
+}
+
+// MRNSequence holds the last number issued for one MRN prefix, such as
+// "HOSP-2026-", so numbering restarts whenever the prefix changes.
+type MRNSequence struct {
+	Prefix    string `gorm:"primaryKey"`
+	LastValue int64  `gorm:"not null"`
+}
+
+func (MRNSequence) TableName() string {
+	return "mrn_sequences"
 }
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PatientDocumentCategories lists the kinds of files that can be attached to a patient.
+var PatientDocumentCategories = map[string]bool{
+	"referral_letter": true,
+	"consent_form":    true,
+	"external_report": true,
+	"insurance_card":  true,
+	"other":           true,
+}
+
+// PatientDocument is a file attached to a patient, such as a scanned referral
+// letter. The contents live in the blob store under StorageKey.
+type PatientDocument struct {
+	ID          uuid.UUID `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	PatientID   uuid.UUID `gorm:"not null;index" json:"patient_id"`
+	UploadedBy  uuid.UUID `gorm:"not null" json:"uploaded_by"`
+	Category    string    `gorm:"not null" json:"category"` // One of PatientDocumentCategories
+	Title       string    `gorm:"not null" json:"title"`
+	FileName    string    `gorm:"not null" json:"file_name"`
+	ContentType string    `gorm:"not null" json:"content_type"` // Sniffed from the contents
+	Size        int64     `gorm:"not null" json:"size"`
+	SHA256      string    `gorm:"column:sha256;type:char(64);not null" json:"sha256"`
+	StorageKey  string    `gorm:"not null;uniqueIndex" json:"-"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	// Relationships
+	Patient *Patient `gorm:"foreignKey:PatientID" json:"patient,omitempty"`
+}
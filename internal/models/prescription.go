@@ -10,13 +10,100 @@ type Prescription struct {
 	ID           uuid.UUID `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
 	PatientID    uuid.UUID `gorm:"not null" json:"patient_id"`
 	DoctorID     uuid.UUID `gorm:"not null" json:"doctor_id"`
-	Medication   string    `gorm:"not null" json:"medication"`
-	Dosage       string    `gorm:"not null" json:"dosage"`
+	Medication   string    `gorm:"not null" json:"medication"` // Filled from the formulary item unless free text
+	Dosage       string    `gorm:"not null" json:"dosage"`     // Human readable summary of the structured dose
 	Instructions string    `gorm:"type:text" json:"instructions"`
-	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt    time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Structured dosing
+	FormularyItemID *uuid.UUID `gorm:"type:uuid;index" json:"formulary_item_id,omitempty"`
+	FreeText        bool       `gorm:"not null;default:false" json:"free_text"` // Medication is not in the formulary
+	Dose            float64    `gorm:"type:numeric(10,3)" json:"dose,omitempty"`
+	DoseUnit        string     `json:"dose_unit,omitempty"`
+	Frequency       string     `json:"frequency,omitempty"` // One of the codes in PrescriptionFrequencies
+	Route           string     `json:"route,omitempty"`
+	DurationDays    int        `json:"duration_days,omitempty"`
+	Quantity        float64    `gorm:"type:numeric(10,2)" json:"quantity,omitempty"`
+
+	Status             string     `gorm:"type:text CHECK (status IN ('active','discontinued','completed'));default:'active'" json:"status"`
+	DiscontinuedReason string     `gorm:"type:text" json:"discontinued_reason,omitempty"`
+	EndedAt            *time.Time `json:"ended_at,omitempty"`                // When it was discontinued or completed
+	Version            int        `gorm:"not null;default:1" json:"version"` // Latest entry in the version history
+
+	// Repeats. Refills are issued as new prescriptions pointing at the original.
+	RefillsAllowed     int        `gorm:"not null;default:0" json:"refills_allowed"`
+	RefillIntervalDays int        `gorm:"not null;default:0" json:"refill_interval_days"` // Minimum days between issues
+	RefillOfID         *uuid.UUID `gorm:"type:uuid;index" json:"refill_of_id,omitempty"`
+	RefillNumber       int        `gorm:"not null;default:0" json:"refill_number,omitempty"`
+
+	// Printed on the paper prescription so pharmacies can check it is genuine
+	VerificationCode string     `gorm:"type:varchar(16);uniqueIndex" json:"verification_code"`
+	PrintSetID       *uuid.UUID `gorm:"type:uuid;index" json:"print_set_id,omitempty"` // Shared by the prescriptions printed on one paper
+	DispensedAt      *time.Time `json:"dispensed_at,omitempty"`
+	DispensedBy      *uuid.UUID `gorm:"type:uuid" json:"dispensed_by,omitempty"` // API key of the dispensing pharmacy
+
+	// Set by the prescribing doctor to knowingly prescribe against a recorded allergy
+	AllergyOverride       bool   `gorm:"not null;default:false" json:"allergy_override"`
+	AllergyOverrideReason string `gorm:"type:text" json:"allergy_override_reason,omitempty"`
+
+	// Set by the prescribing doctor to knowingly prescribe despite a severe drug interaction
+	InteractionOverride       bool   `gorm:"not null;default:false" json:"interaction_override"`
+	InteractionOverrideReason string `gorm:"type:text" json:"interaction_override_reason,omitempty"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
 
 	// Relationships
 	Patient Patient `gorm:"foreignKey:PatientID" json:"patient,omitempty"`
 	Doctor  User    `gorm:"foreignKey:DoctorID" json:"doctor,omitempty"`
+
+	FormularyItem *FormularyItem `gorm:"foreignKey:FormularyItemID" json:"formulary_item,omitempty"`
+}
+
+// PrescriptionVersion is an immutable snapshot of a prescription taken each
+// time it is created or changed.
+type PrescriptionVersion struct {
+	ID             uuid.UUID `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	PrescriptionID uuid.UUID `gorm:"not null;uniqueIndex:idx_prescription_version" json:"prescription_id"`
+	Version        int       `gorm:"not null;uniqueIndex:idx_prescription_version" json:"version"`
+	ChangeType     string    `gorm:"type:text CHECK (change_type IN ('created','updated','discontinued','completed'));not null" json:"change_type"`
+	ChangedBy      uuid.UUID `gorm:"not null" json:"changed_by"`
+
+	Medication         string     `gorm:"not null" json:"medication"`
+	Dosage             string     `gorm:"not null" json:"dosage"`
+	Instructions       string     `gorm:"type:text" json:"instructions"`
+	FormularyItemID    *uuid.UUID `gorm:"type:uuid" json:"formulary_item_id,omitempty"`
+	FreeText           bool       `json:"free_text"`
+	Dose               float64    `gorm:"type:numeric(10,3)" json:"dose,omitempty"`
+	DoseUnit           string     `json:"dose_unit,omitempty"`
+	Frequency          string     `json:"frequency,omitempty"`
+	Route              string     `json:"route,omitempty"`
+	DurationDays       int        `json:"duration_days,omitempty"`
+	Quantity           float64    `gorm:"type:numeric(10,2)" json:"quantity,omitempty"`
+	Status             string     `gorm:"not null" json:"status"`
+	DiscontinuedReason string     `gorm:"type:text" json:"discontinued_reason,omitempty"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// PrescriptionFrequencies maps the accepted frequency codes to their wording on printouts.
+var PrescriptionFrequencies = map[string]string{
+	"OD":   "once daily",
+	"BID":  "twice daily",
+	"TID":  "three times daily",
+	"QID":  "four times daily",
+	"Q4H":  "every 4 hours",
+	"Q6H":  "every 6 hours",
+	"Q8H":  "every 8 hours",
+	"Q12H": "every 12 hours",
+	"QHS":  "at bedtime",
+	"QW":   "once weekly",
+	"PRN":  "as needed",
+	"STAT": "immediately, once",
+}
+
+// DoseUnits lists the accepted units for Prescription.DoseUnit.
+var DoseUnits = map[string]bool{
+	"mg": true, "g": true, "mcg": true, "ml": true, "iu": true, "unit": true,
+	"tablet": true, "capsule": true, "puff": true, "drop": true, "patch": true,
+	"sachet": true, "application": true,
 }
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Referral sends a patient to another doctor of the hospital or to an
+// external provider. Internal referrals name a TargetDoctorID; external ones
+// carry the provider's details instead.
+type Referral struct {
+	ID                uuid.UUID  `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	PatientID         uuid.UUID  `gorm:"not null;index" json:"patient_id"`
+	ReferringDoctorID uuid.UUID  `gorm:"not null;index" json:"referring_doctor_id"`
+	TargetDoctorID    *uuid.UUID `gorm:"type:uuid;index" json:"target_doctor_id,omitempty"`
+
+	// External provider, set when TargetDoctorID is empty
+	ExternalProvider     string `json:"external_provider,omitempty"`
+	ExternalOrganization string `json:"external_organization,omitempty"`
+	ExternalSpecialty    string `json:"external_specialty,omitempty"`
+	ExternalPhone        string `json:"external_phone,omitempty"`
+	ExternalEmail        string `json:"external_email,omitempty"`
+
+	Reason        string     `gorm:"type:text;not null" json:"reason"`
+	Urgency       string     `gorm:"type:text CHECK (urgency IN ('routine','urgent','emergency'));default:'routine'" json:"urgency"`
+	Status        string     `gorm:"type:text CHECK (status IN ('pending','accepted','declined','cancelled','completed'));default:'pending';index" json:"status"`
+	DeclineReason string     `gorm:"type:text" json:"decline_reason,omitempty"`
+	RespondedAt   *time.Time `json:"responded_at,omitempty"`
+	AppointmentID *uuid.UUID `gorm:"type:uuid" json:"appointment_id,omitempty"` // First appointment booked from the referral
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relationships
+	Patient         *Patient          `gorm:"foreignKey:PatientID" json:"patient,omitempty"`
+	ReferringDoctor *User             `gorm:"foreignKey:ReferringDoctorID" json:"referring_doctor,omitempty"`
+	TargetDoctor    *User             `gorm:"foreignKey:TargetDoctorID" json:"target_doctor,omitempty"`
+	Appointment     *Appointment      `gorm:"foreignKey:AppointmentID" json:"appointment,omitempty"`
+	Documents       []PatientDocument `gorm:"many2many:referral_documents" json:"documents,omitempty"`
+}
+
+// IsInternal reports whether the referral is to a doctor of this hospital.
+func (r *Referral) IsInternal() bool {
+	return r.TargetDoctorID != nil
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefillRequest is a request, logged at the front desk, for another issue of a
+// repeat prescription. The prescribing doctor approves or denies it.
+type RefillRequest struct {
+	ID                      uuid.UUID  `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	PrescriptionID          uuid.UUID  `gorm:"not null;index" json:"prescription_id"` // The original prescription
+	PatientID               uuid.UUID  `gorm:"not null;index" json:"patient_id"`
+	DoctorID                uuid.UUID  `gorm:"not null;index" json:"doctor_id"` // Prescriber who reviews the request
+	RequestedBy             uuid.UUID  `gorm:"not null" json:"requested_by"`
+	Status                  string     `gorm:"type:text CHECK (status IN ('pending','approved','denied'));default:'pending'" json:"status"`
+	Notes                   string     `gorm:"type:text" json:"notes,omitempty"`
+	DenialReason            string     `gorm:"type:text" json:"denial_reason,omitempty"`
+	ReviewedAt              *time.Time `json:"reviewed_at,omitempty"`
+	ResultingPrescriptionID *uuid.UUID `gorm:"type:uuid" json:"resulting_prescription_id,omitempty"`
+	CreatedAt               time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt               time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	// Relationships
+	Prescription Prescription `gorm:"foreignKey:PrescriptionID" json:"prescription,omitempty"`
+	Patient      Patient      `gorm:"foreignKey:PatientID" json:"patient,omitempty"`
+}
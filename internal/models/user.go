@@ -5,14 +5,15 @@ import (
 )
 
 type User struct {
-	ID           uuid.UUID `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
-	Name         string    `gorm:"not null" json:"name"`
-	Email        string    `gorm:"uniqueIndex;not null" json:"email"`
-	Role         string    `gorm:"type:text CHECK (role IN ('receptionist','doctor'));not null" json:"role"`
-	PasswordHash string    `gorm:"not null" json:"-"`
+	ID            uuid.UUID `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	Name          string    `gorm:"not null" json:"name"`
+	Email         string    `gorm:"uniqueIndex;not null" json:"email"`
+	Role          string    `gorm:"type:text CHECK (role IN ('receptionist','doctor','nurse'));not null" json:"role"`
+	PasswordHash  string    `gorm:"not null" json:"-"`
+	LicenseNumber string    `json:"license_number,omitempty"` // Medical council registration number, printed on prescriptions
 
 	// Relationships
-	Patients      []Patient      `gorm:"foreignKey:UserID" json:"patients,omitempty"`
-	Prescriptions []Prescription `gorm:"foreignKey:DoctorID" json:"prescriptions,omitempty"`
-	Appointments  []Appointment  `gorm:"foreignKey:DoctorID" json:"appointments,omitempty"`
+	CareTeams     []CareTeamMember `gorm:"foreignKey:DoctorID" json:"care_teams,omitempty"`
+	Prescriptions []Prescription   `gorm:"foreignKey:DoctorID" json:"prescriptions,omitempty"`
+	Appointments  []Appointment    `gorm:"foreignKey:DoctorID" json:"appointments,omitempty"`
 }
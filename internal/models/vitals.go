@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Vitals is one set of vital sign measurements, stored in metric units.
+// Any measurement that wasn't taken is left nil.
+type Vitals struct {
+	ID            uuid.UUID  `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	PatientID     uuid.UUID  `gorm:"not null;index:idx_vitals_patient_time" json:"patient_id"`
+	AppointmentID *uuid.UUID `gorm:"type:uuid;index" json:"appointment_id,omitempty"` // Encounter or check-in
+	RecordedBy    uuid.UUID  `gorm:"not null" json:"recorded_by"`
+	RecordedAt    time.Time  `gorm:"not null;index:idx_vitals_patient_time" json:"recorded_at"`
+
+	SystolicBP      *float64 `gorm:"column:systolic_bp" json:"systolic_bp,omitempty"`   // mmHg
+	DiastolicBP     *float64 `gorm:"column:diastolic_bp" json:"diastolic_bp,omitempty"` // mmHg
+	HeartRate       *float64 `json:"heart_rate,omitempty"`                              // beats/min
+	TemperatureC    *float64 `json:"temperature_c,omitempty"`                           // °C
+	SpO2            *float64 `gorm:"column:spo2" json:"spo2,omitempty"`                 // %
+	RespiratoryRate *float64 `json:"respiratory_rate,omitempty"`                        // breaths/min
+	HeightCm        *float64 `json:"height_cm,omitempty"`
+	WeightKg        *float64 `json:"weight_kg,omitempty"`
+	BMI             *float64 `gorm:"column:bmi" json:"bmi,omitempty"` // Computed from height and weight
+
+	Notes     string    `gorm:"type:text" json:"notes,omitempty"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	// Relationships
+	Patient *Patient `gorm:"foreignKey:PatientID" json:"patient,omitempty"`
+}
@@ -9,39 +9,63 @@ import (
 	"hospital/internal/models"
 )
 
-func SeedUsers(db *gorm.DB) {
-
-	var count int64
-	db.Model(&models.User{}).Where("email IN ?", []string{"doc@example.com", "reception@example.com"}).Count(&count)
-
-	if count >= 2 {
-		log.Println(" Seed users already exist,,,Skipping")
-		return
-	}
+type seedUser struct {
+	user     models.User
+	password string
+}
 
-	log.Println(" Seeding initial users")
+func SeedUsers(db *gorm.DB) {
 
-	users := []models.User{
+	seeds := []seedUser{
 		{
-			Name:         "Dr. Strange",
-			Email:        "doc@example.com",
-			Role:         "doctor",
-			PasswordHash: hashPassword("doc123"),
+			user: models.User{
+				Name:          "Dr. Strange",
+				Email:         "doc@example.com",
+				Role:          "doctor",
+				LicenseNumber: "MC-000001",
+			},
+			password: "doc123",
 		},
 		{
-			Name:         "Receptionist Amy",
-			Email:        "reception@example.com",
-			Role:         "receptionist",
-			PasswordHash: hashPassword("recep123"),
+			user: models.User{
+				Name:  "Receptionist Amy",
+				Email: "reception@example.com",
+				Role:  "receptionist",
+			},
+			password: "recep123",
+		},
+		{
+			user: models.User{
+				Name:  "Nurse Joy",
+				Email: "nurse@example.com",
+				Role:  "nurse",
+			},
+			password: "nurse123",
 		},
 	}
 
-	for _, user := range users {
+	// Each user is checked on its own so users added here later also reach existing databases
+	seeded := 0
+	for _, seed := range seeds {
+		var count int64
+		db.Model(&models.User{}).Where("email = ?", seed.user.Email).Count(&count)
+		if count > 0 {
+			continue
+		}
+
+		user := seed.user
+		user.PasswordHash = hashPassword(seed.password)
 		if err := db.Create(&user).Error; err != nil {
 			log.Printf("Failed to seed user %s: %v\n", user.Email, err)
+			continue
 		}
+		seeded++
 	}
 
+	if seeded == 0 {
+		log.Println(" Seed users already exist,,,Skipping")
+		return
+	}
 	log.Println("User seeding complete.")
 }
 
@@ -0,0 +1,530 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"hospital/internal/config"
+	"hospital/internal/database"
+	"hospital/internal/models"
+)
+
+// AdmissionService manages the wards, rooms and beds and the inpatient
+// admissions that occupy them. The bed of an admission in progress is always
+// occupied; beds a patient leaves go to cleaning.
+type AdmissionService interface {
+	GetWards(includeInactive bool) ([]models.Ward, error)
+	CreateWard(ward *models.Ward) error
+	UpdateWard(wardID uuid.UUID, ward *models.Ward) (*models.Ward, error)
+	CreateRoom(wardID uuid.UUID, room *models.Room) error
+	CreateBed(roomID uuid.UUID, bed *models.Bed) error
+	SetBedStatus(userID, bedID uuid.UUID, status, note string) (*models.Bed, error)
+	GetBedBoard(wardID, doctorID *uuid.UUID) ([]WardOccupancy, error)
+
+	Admit(userID uuid.UUID, admission *models.Admission) error
+	GetAdmissions(filter AdmissionFilter, page, limit int) ([]models.Admission, int64, error)
+	GetAdmission(admissionID uuid.UUID) (*models.Admission, error)
+	Transfer(userID, admissionID, bedID uuid.UUID, reason string) (*models.Admission, error)
+	Discharge(userID, admissionID uuid.UUID, dischargedAt *time.Time, disposition, notes string) (*models.Admission, error)
+}
+
+// AdmissionFilter narrows an admission listing; empty fields match everything.
+type AdmissionFilter struct {
+	PatientID *uuid.UUID
+	DoctorID  *uuid.UUID // Attending doctor
+	WardID    *uuid.UUID // Ward of the current bed
+	Status    string
+}
+
+// WardOccupancy is one ward on the bed board: its bed counts by status and
+// its rooms with every bed and the admission in it.
+type WardOccupancy struct {
+	WardID   uuid.UUID     `json:"ward_id"`
+	Code     string        `json:"code"`
+	Name     string        `json:"name"`
+	Beds     int           `json:"beds"`
+	Free     int           `json:"free"`
+	Occupied int           `json:"occupied"`
+	Cleaning int           `json:"cleaning"`
+	Blocked  int           `json:"blocked"`
+	Rooms    []models.Room `json:"rooms"`
+}
+
+type admissionService struct {
+	db  *database.DB
+	cfg config.Config
+}
+
+func NewAdmissionService(db *database.DB, cfg config.Config) AdmissionService {
+	return &admissionService{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+func (s *admissionService) GetWards(includeInactive bool) ([]models.Ward, error) {
+	query := s.db.Conn.Preload("Rooms", func(db *gorm.DB) *gorm.DB { return db.Order("number") }).
+		Preload("Rooms.Beds", func(db *gorm.DB) *gorm.DB { return db.Order("label") }).Order("code")
+	if !includeInactive {
+		query = query.Where("active")
+	}
+
+	var wards []models.Ward
+	if err := query.Find(&wards).Error; err != nil {
+		return nil, err
+	}
+	return wards, nil
+}
+
+func (s *admissionService) CreateWard(ward *models.Ward) error {
+	ward.ID = uuid.Nil
+	ward.Active = true
+	if err := validateWard(ward); err != nil {
+		return err
+	}
+	return s.db.Conn.Omit(clause.Associations).Create(ward).Error
+}
+
+func (s *admissionService) UpdateWard(wardID uuid.UUID, update *models.Ward) (*models.Ward, error) {
+	var ward models.Ward
+	if err := s.db.Conn.First(&ward, "id = ?", wardID).Error; err != nil {
+		return nil, err
+	}
+	if err := validateWard(update); err != nil {
+		return nil, err
+	}
+	if !update.Active && ward.Active {
+		var admitted int64
+		if err := s.db.Conn.Model(&models.Admission{}).Joins("JOIN beds ON beds.id = admissions.bed_id").
+			Where("beds.ward_id = ? AND admissions.status = ?", wardID, "admitted").Count(&admitted).Error; err != nil {
+			return nil, err
+		}
+		if admitted > 0 {
+			return nil, errors.New("a ward with patients in it cannot be deactivated")
+		}
+	}
+
+	ward.Code = update.Code
+	ward.Name = update.Name
+	ward.Specialty = update.Specialty
+	ward.Floor = update.Floor
+	ward.Active = update.Active
+	if err := s.db.Conn.Omit(clause.Associations).Save(&ward).Error; err != nil {
+		return nil, err
+	}
+	return &ward, nil
+}
+
+func validateWard(ward *models.Ward) error {
+	ward.Code = strings.TrimSpace(ward.Code)
+	ward.Name = strings.TrimSpace(ward.Name)
+	if ward.Code == "" || ward.Name == "" {
+		return errors.New("code and name are required")
+	}
+	return nil
+}
+
+func (s *admissionService) CreateRoom(wardID uuid.UUID, room *models.Room) error {
+	room.Number = strings.TrimSpace(room.Number)
+	if room.Number == "" {
+		return errors.New("number is required")
+	}
+	if err := s.db.Conn.Select("id").First(&models.Ward{}, "id = ?", wardID).Error; err != nil {
+		return err
+	}
+
+	room.ID = uuid.Nil
+	room.WardID = wardID
+	return s.db.Conn.Omit(clause.Associations).Create(room).Error
+}
+
+func (s *admissionService) CreateBed(roomID uuid.UUID, bed *models.Bed) error {
+	bed.Label = strings.TrimSpace(bed.Label)
+	if bed.Label == "" {
+		return errors.New("label is required")
+	}
+	var room models.Room
+	if err := s.db.Conn.First(&room, "id = ?", roomID).Error; err != nil {
+		return err
+	}
+
+	bed.ID = uuid.Nil
+	bed.RoomID = roomID
+	bed.WardID = room.WardID
+	bed.Status = "free"
+	return s.db.Conn.Omit(clause.Associations).Create(bed).Error
+}
+
+// SetBedStatus moves a bed between free, cleaning and blocked. Beds only
+// become occupied or stop being occupied through admissions.
+func (s *admissionService) SetBedStatus(userID, bedID uuid.UUID, status, note string) (*models.Bed, error) {
+	if !models.BedStatuses[status] {
+		return nil, errors.New("status must be one of free, cleaning or blocked")
+	}
+	if status == "occupied" {
+		return nil, errors.New("beds are occupied by admitting a patient")
+	}
+	note = strings.TrimSpace(note)
+	if status == "blocked" && note == "" {
+		return nil, errors.New("a note is required to block a bed")
+	}
+
+	var bed models.Bed
+	err := s.db.Conn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&bed, "id = ?", bedID).Error; err != nil {
+			return err
+		}
+		if bed.Status == "occupied" {
+			return errors.New("bed is occupied; transfer or discharge the patient first")
+		}
+		previous := bed.Status
+		bed.Status = status
+		bed.StatusNote = note
+		if err := tx.Model(&bed).Updates(map[string]any{"status": status, "status_note": note}).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, userID, "bed.status_changed", "bed", bed.ID, map[string]any{
+			"from": previous,
+			"to":   status,
+			"note": note,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &bed, nil
+}
+
+// GetBedBoard reports the occupancy of every active ward, or of one ward.
+// When doctorID is set, only admissions the doctor attends or whose patient
+// they can access are shown; other occupied beds are counted but left empty.
+func (s *admissionService) GetBedBoard(wardID, doctorID *uuid.UUID) ([]WardOccupancy, error) {
+	query := s.db.Conn.Where("active").Order("code").
+		Preload("Rooms", func(db *gorm.DB) *gorm.DB { return db.Order("number") }).
+		Preload("Rooms.Beds", func(db *gorm.DB) *gorm.DB { return db.Order("label") }).
+		Preload("Rooms.Beds.Admission", "status = ?", "admitted").
+		Preload("Rooms.Beds.Admission.Patient").
+		Preload("Rooms.Beds.Admission.AttendingDoctor")
+	if wardID != nil {
+		query = query.Where("id = ?", *wardID)
+	}
+
+	var wards []models.Ward
+	if err := query.Find(&wards).Error; err != nil {
+		return nil, err
+	}
+	if doctorID != nil {
+		if err := s.hideInaccessibleAdmissions(wards, *doctorID); err != nil {
+			return nil, err
+		}
+	}
+
+	board := make([]WardOccupancy, 0, len(wards))
+	for _, ward := range wards {
+		occupancy := WardOccupancy{WardID: ward.ID, Code: ward.Code, Name: ward.Name, Rooms: ward.Rooms}
+		for _, room := range ward.Rooms {
+			for _, bed := range room.Beds {
+				occupancy.Beds++
+				switch bed.Status {
+				case "free":
+					occupancy.Free++
+				case "occupied":
+					occupancy.Occupied++
+				case "cleaning":
+					occupancy.Cleaning++
+				case "blocked":
+					occupancy.Blocked++
+				}
+			}
+		}
+		board = append(board, occupancy)
+	}
+	return board, nil
+}
+
+// Admit admits a patient into a free bed.
+func (s *admissionService) Admit(userID uuid.UUID, admission *models.Admission) error {
+	admission.Reason = strings.TrimSpace(admission.Reason)
+	if admission.Reason == "" {
+		return errors.New("reason is required")
+	}
+	now := time.Now()
+	if admission.AdmittedAt.IsZero() {
+		admission.AdmittedAt = now
+	}
+	if admission.AdmittedAt.After(now) {
+		return errors.New("admitted_at must not be in the future")
+	}
+
+	admission.ID = uuid.Nil
+	admission.Status = "admitted"
+	admission.AdmittedBy = userID
+	admission.DischargedAt = nil
+	admission.DischargedBy = nil
+	return s.db.Conn.Transaction(func(tx *gorm.DB) error {
+		// Lock the patient so concurrent admissions of the same patient are serialized
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+			First(&models.Patient{}, "id = ?", admission.PatientID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("patient not found")
+			}
+			return err
+		}
+		if err := tx.Select("id").First(&models.User{}, "id = ? AND role = ?", admission.AttendingDoctorID, "doctor").Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("doctor not found")
+			}
+			return err
+		}
+		var admitted int64
+		if err := tx.Model(&models.Admission{}).Where("patient_id = ? AND status = ?", admission.PatientID, "admitted").
+			Count(&admitted).Error; err != nil {
+			return err
+		}
+		if admitted > 0 {
+			return errors.New("patient is already admitted")
+		}
+
+		bed, err := lockFreeBed(tx, admission.BedID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Omit(clause.Associations).Create(admission).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(bed).Updates(map[string]any{"status": "occupied", "status_note": ""}).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, userID, "admission.created", "admission", admission.ID, map[string]any{
+			"patient_id": admission.PatientID,
+			"bed_id":     admission.BedID,
+			"doctor_id":  admission.AttendingDoctorID,
+		})
+	})
+}
+
+// hideInaccessibleAdmissions removes from the beds of wards every admission
+// that the doctor neither attends nor may see the patient of.
+func (s *admissionService) hideInaccessibleAdmissions(wards []models.Ward, doctorID uuid.UUID) error {
+	var patientIDs []uuid.UUID
+	for _, ward := range wards {
+		for _, room := range ward.Rooms {
+			for _, bed := range room.Beds {
+				if bed.Admission != nil && bed.Admission.AttendingDoctorID != doctorID {
+					patientIDs = append(patientIDs, bed.Admission.PatientID)
+				}
+			}
+		}
+	}
+	if len(patientIDs) == 0 {
+		return nil
+	}
+
+	var accessible []uuid.UUID
+	if err := s.db.Conn.Model(&models.Patient{}).Scopes(doctorPatients(doctorID)).
+		Where("patients.id IN ?", patientIDs).Pluck("patients.id", &accessible).Error; err != nil {
+		return err
+	}
+	allowed := make(map[uuid.UUID]bool, len(accessible))
+	for _, id := range accessible {
+		allowed[id] = true
+	}
+
+	for w := range wards {
+		for r := range wards[w].Rooms {
+			beds := wards[w].Rooms[r].Beds
+			for b := range beds {
+				admission := beds[b].Admission
+				if admission != nil && admission.AttendingDoctorID != doctorID && !allowed[admission.PatientID] {
+					beds[b].Admission = nil
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (s *admissionService) GetAdmissions(filter AdmissionFilter, page, limit int) ([]models.Admission, int64, error) {
+	query := s.db.Conn.Model(&models.Admission{})
+	if filter.PatientID != nil {
+		query = query.Where("patient_id = ?", *filter.PatientID)
+	}
+	if filter.DoctorID != nil {
+		query = query.Where("attending_doctor_id = ?", *filter.DoctorID)
+	}
+	if filter.WardID != nil {
+		query = query.Where("bed_id IN (?)", s.db.Conn.Model(&models.Bed{}).Select("id").Where("ward_id = ?", *filter.WardID))
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var admissions []models.Admission
+	if err := query.Preload("Patient").Preload("AttendingDoctor").Preload("Bed.Room").Preload("Bed.Ward").
+		Order("admitted_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&admissions).Error; err != nil {
+		return nil, 0, err
+	}
+	return admissions, total, nil
+}
+
+func (s *admissionService) GetAdmission(admissionID uuid.UUID) (*models.Admission, error) {
+	return loadAdmission(s.db.Conn, admissionID)
+}
+
+func loadAdmission(tx *gorm.DB, admissionID uuid.UUID) (*models.Admission, error) {
+	var admission models.Admission
+	if err := tx.Preload("Patient").Preload("AttendingDoctor").Preload("Bed.Room").Preload("Bed.Ward").
+		Preload("Transfers", func(db *gorm.DB) *gorm.DB { return db.Order("transferred_at") }).
+		Preload("Transfers.FromBed").Preload("Transfers.ToBed").
+		First(&admission, "id = ?", admissionID).Error; err != nil {
+		return nil, err
+	}
+	return &admission, nil
+}
+
+// Transfer moves an admission to another free bed. The bed it leaves goes to
+// cleaning.
+func (s *admissionService) Transfer(userID, admissionID, bedID uuid.UUID, reason string) (*models.Admission, error) {
+	var admission *models.Admission
+	err := s.db.Conn.Transaction(func(tx *gorm.DB) error {
+		locked, err := lockAdmittedAdmission(tx, admissionID)
+		if err != nil {
+			return err
+		}
+		if locked.BedID == bedID {
+			return errors.New("patient is already in this bed")
+		}
+		bed, err := lockFreeBed(tx, bedID)
+		if err != nil {
+			return err
+		}
+
+		transfer := models.BedTransfer{
+			AdmissionID:   admissionID,
+			FromBedID:     locked.BedID,
+			ToBedID:       bedID,
+			Reason:        strings.TrimSpace(reason),
+			TransferredBy: userID,
+			TransferredAt: time.Now(),
+		}
+		if err := tx.Create(&transfer).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Bed{}).Where("id = ?", locked.BedID).
+			Updates(map[string]any{"status": "cleaning", "status_note": ""}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(locked).Update("bed_id", bedID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(bed).Updates(map[string]any{"status": "occupied", "status_note": ""}).Error; err != nil {
+			return err
+		}
+		if err := recordAudit(tx, userID, "admission.transferred", "admission", admissionID, map[string]any{
+			"from_bed_id": transfer.FromBedID,
+			"to_bed_id":   bedID,
+			"reason":      transfer.Reason,
+		}); err != nil {
+			return err
+		}
+		admission, err = loadAdmission(tx, admissionID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return admission, nil
+}
+
+// Discharge ends an admission, at dischargedAt or now, and sends its bed to
+// cleaning.
+func (s *admissionService) Discharge(userID, admissionID uuid.UUID, dischargedAt *time.Time, disposition, notes string) (*models.Admission, error) {
+	now := time.Now()
+	at := now
+	if dischargedAt != nil {
+		at = *dischargedAt
+	}
+	if at.After(now) {
+		return nil, errors.New("discharged_at must not be in the future")
+	}
+
+	var admission *models.Admission
+	err := s.db.Conn.Transaction(func(tx *gorm.DB) error {
+		locked, err := lockAdmittedAdmission(tx, admissionID)
+		if err != nil {
+			return err
+		}
+		if at.Before(locked.AdmittedAt) {
+			return errors.New("discharged_at must not be before admitted_at")
+		}
+
+		if err := tx.Model(locked).Updates(map[string]any{
+			"status":          "discharged",
+			"discharged_at":   at,
+			"discharged_by":   userID,
+			"disposition":     strings.TrimSpace(disposition),
+			"discharge_notes": strings.TrimSpace(notes),
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Bed{}).Where("id = ?", locked.BedID).
+			Updates(map[string]any{"status": "cleaning", "status_note": ""}).Error; err != nil {
+			return err
+		}
+		if err := recordAudit(tx, userID, "admission.discharged", "admission", admissionID, map[string]any{
+			"bed_id":      locked.BedID,
+			"disposition": strings.TrimSpace(disposition),
+		}); err != nil {
+			return err
+		}
+		admission, err = loadAdmission(tx, admissionID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return admission, nil
+}
+
+// lockAdmittedAdmission locks an admission that is still in progress.
+func lockAdmittedAdmission(tx *gorm.DB, admissionID uuid.UUID) (*models.Admission, error) {
+	var admission models.Admission
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&admission, "id = ?", admissionID).Error; err != nil {
+		return nil, err
+	}
+	if admission.Status != "admitted" {
+		return nil, errors.New("patient has already been discharged")
+	}
+	return &admission, nil
+}
+
+// lockFreeBed locks a bed on an active ward that is free to take a patient.
+func lockFreeBed(tx *gorm.DB, bedID uuid.UUID) (*models.Bed, error) {
+	var bed models.Bed
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&bed, "id = ?", bedID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("bed not found")
+		}
+		return nil, err
+	}
+	var ward models.Ward
+	if err := tx.First(&ward, "id = ?", bed.WardID).Error; err != nil {
+		return nil, err
+	}
+	if !ward.Active {
+		return nil, errors.New("ward is not active")
+	}
+	if bed.Status != "free" {
+		return nil, errors.New("bed is " + bed.Status)
+	}
+	return &bed, nil
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"hospital/internal/models"
+)
+
+var (
+	allergySeverities = map[string]bool{"mild": true, "moderate": true, "severe": true}
+	allergyStatuses   = map[string]bool{"active": true, "inactive": true, "resolved": true}
+)
+
+// AllergyConflict describes a recorded allergy that a medication matches.
+type AllergyConflict struct {
+	AllergyID uuid.UUID `json:"allergy_id"`
+	Substance string    `json:"substance"`
+	Reaction  string    `json:"reaction,omitempty"`
+	Severity  string    `json:"severity"`
+	DrugClass string    `json:"drug_class,omitempty"` // Set when matched through the drug class mapping
+}
+
+// AllergyConflictError is returned when a prescription matches one of the
+// patient's active allergies and no override was given.
+type AllergyConflictError struct {
+	Conflicts []AllergyConflict
+}
+
+func (e *AllergyConflictError) Error() string {
+	return "medication conflicts with the patient's recorded allergies"
+}
+
+func (s *doctorService) CreateAllergy(doctorID, patientID uuid.UUID, allergy *models.PatientAllergy) error {
+	if _, err := s.findPatientForDoctor(doctorID, patientID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("patient not found")
+		}
+		return err
+	}
+
+	allergy.Substance = strings.TrimSpace(allergy.Substance)
+	if allergy.Substance == "" {
+		return errors.New("substance is required")
+	}
+	if allergy.Severity == "" {
+		allergy.Severity = "moderate"
+	}
+	if !allergySeverities[allergy.Severity] {
+		return errors.New("severity must be one of mild, moderate or severe")
+	}
+	if allergy.Status == "" {
+		allergy.Status = "active"
+	}
+	if !allergyStatuses[allergy.Status] {
+		return errors.New("status must be one of active, inactive or resolved")
+	}
+
+	allergy.ID = uuid.Nil
+	allergy.PatientID = patientID
+	allergy.RecordedBy = doctorID
+	return s.db.Conn.Create(allergy).Error
+}
+
+func (s *doctorService) GetAllergiesByPatient(doctorID, patientID uuid.UUID) ([]models.PatientAllergy, error) {
+	if _, err := s.findPatientForDoctor(doctorID, patientID); err != nil {
+		return nil, err
+	}
+
+	var allergies []models.PatientAllergy
+	if err := s.db.Conn.Where("patient_id = ?", patientID).Order("created_at DESC").Find(&allergies).Error; err != nil {
+		return nil, err
+	}
+	return allergies, nil
+}
+
+func (s *doctorService) UpdateAllergy(doctorID, allergyID uuid.UUID, input *models.PatientAllergy) (*models.PatientAllergy, error) {
+	var allergy models.PatientAllergy
+	if err := s.db.Conn.First(&allergy, "id = ?", allergyID).Error; err != nil {
+		return nil, err
+	}
+	if _, err := s.findPatientForDoctor(doctorID, allergy.PatientID); err != nil {
+		return nil, err
+	}
+
+	if substance := strings.TrimSpace(input.Substance); substance != "" {
+		allergy.Substance = substance
+	}
+	if input.Reaction != "" {
+		allergy.Reaction = input.Reaction
+	}
+	if input.Severity != "" {
+		if !allergySeverities[input.Severity] {
+			return nil, errors.New("severity must be one of mild, moderate or severe")
+		}
+		allergy.Severity = input.Severity
+	}
+	if input.Status != "" {
+		if !allergyStatuses[input.Status] {
+			return nil, errors.New("status must be one of active, inactive or resolved")
+		}
+		allergy.Status = input.Status
+	}
+
+	if err := s.db.Conn.Save(&allergy).Error; err != nil {
+		return nil, err
+	}
+	return &allergy, nil
+}
+
+// checkAllergies returns the patient's active allergies the medication matches,
+// either by name or through a shared drug class.
+func (s *doctorService) checkAllergies(tx *gorm.DB, patientID uuid.UUID, medication string) ([]AllergyConflict, error) {
+	var allergies []models.PatientAllergy
+	if err := tx.Where("patient_id = ? AND status = ?", patientID, "active").Find(&allergies).Error; err != nil {
+		return nil, err
+	}
+
+	medication = normalizeDrugName(medication)
+	medicationClasses := make(map[string]bool)
+	for _, class := range s.drugClasses(medication) {
+		medicationClasses[class] = true
+	}
+
+	var conflicts []AllergyConflict
+	for _, allergy := range allergies {
+		substance := normalizeDrugName(allergy.Substance)
+		if substance == "" {
+			continue
+		}
+
+		conflict := AllergyConflict{
+			AllergyID: allergy.ID,
+			Substance: allergy.Substance,
+			Reaction:  allergy.Reaction,
+			Severity:  allergy.Severity,
+		}
+		if strings.Contains(medication, substance) {
+			conflicts = append(conflicts, conflict)
+			continue
+		}
+		for _, class := range s.drugClasses(substance) {
+			if medicationClasses[class] {
+				conflict.DrugClass = class
+				conflicts = append(conflicts, conflict)
+				break
+			}
+		}
+	}
+	return conflicts, nil
+}
+
+// drugClasses returns the configured classes a drug name or class name belongs to.
+// Class names are returned in sorted order so results are stable.
+func (s *doctorService) drugClasses(name string) []string {
+	var classes []string
+	for class, members := range s.cfg.ClinicalConfig.DrugClasses {
+		class = normalizeDrugName(class)
+		if strings.Contains(name, class) {
+			classes = append(classes, class)
+			continue
+		}
+		for _, member := range members {
+			if member = normalizeDrugName(member); member != "" && strings.Contains(name, member) {
+				classes = append(classes, class)
+				break
+			}
+		}
+	}
+	sort.Strings(classes)
+	return classes
+}
+
+func normalizeDrugName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
@@ -0,0 +1,23 @@
+package services
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"hospital/internal/models"
+)
+
+// recordAudit writes an audit event inside the caller's transaction so the
+// event is only kept if the audited change is committed.
+func recordAudit(tx *gorm.DB, userID uuid.UUID, action, entityType string, entityID uuid.UUID, details map[string]any) error {
+	event := models.AuditEvent{
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Details:    details,
+	}
+	if userID != uuid.Nil {
+		event.UserID = &userID
+	}
+	return tx.Create(&event).Error
+}
@@ -0,0 +1,711 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"hospital/internal/config"
+	"hospital/internal/database"
+	"hospital/internal/models"
+)
+
+// BillingService covers the front desk's financial work: the price list,
+// charges, invoices and the payments received against them.
+type BillingService interface {
+	GetPrices(includeInactive bool) ([]models.ServicePrice, error)
+	CreatePrice(price *models.ServicePrice) error
+	UpdatePrice(priceID uuid.UUID, price *models.ServicePrice) (*models.ServicePrice, error)
+
+	GetCharges(patientID uuid.UUID, unbilledOnly bool) ([]models.Charge, error)
+	CreateCharge(receptionistID, patientID uuid.UUID, charge *models.Charge) error
+	DeleteCharge(receptionistID, patientID, chargeID uuid.UUID) error
+
+	CreateInvoice(receptionistID, patientID uuid.UUID, chargeIDs []uuid.UUID, notes string) (*models.Invoice, error)
+	GetInvoices(filter InvoiceFilter, page, limit int) ([]models.Invoice, int64, error)
+	GetInvoice(invoiceID uuid.UUID) (*models.Invoice, error)
+	VoidInvoice(receptionistID, invoiceID uuid.UUID, reason string) (*models.Invoice, error)
+	RecordPayment(receptionistID, invoiceID uuid.UUID, payment *models.Payment) (*models.Invoice, error)
+	RefundPayment(receptionistID, paymentID uuid.UUID, amount int64, reason string) (*models.Invoice, error)
+}
+
+// InvoiceFilter narrows an invoice listing; empty fields match everything.
+type InvoiceFilter struct {
+	PatientID *uuid.UUID
+	Status    string
+}
+
+type billingService struct {
+	db  *database.DB
+	cfg config.Config
+}
+
+func NewBillingService(db *database.DB, cfg config.Config) BillingService {
+	return &billingService{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+func (s *billingService) GetPrices(includeInactive bool) ([]models.ServicePrice, error) {
+	query := s.db.Conn.Preload("Doctor").Order("code")
+	if !includeInactive {
+		query = query.Where("active")
+	}
+	var prices []models.ServicePrice
+	if err := query.Find(&prices).Error; err != nil {
+		return nil, err
+	}
+	return prices, nil
+}
+
+func (s *billingService) CreatePrice(price *models.ServicePrice) error {
+	price.ID = uuid.Nil
+	price.Active = true
+	if err := s.validatePrice(price); err != nil {
+		return err
+	}
+	return s.db.Conn.Omit(clause.Associations).Create(price).Error
+}
+
+func (s *billingService) UpdatePrice(priceID uuid.UUID, update *models.ServicePrice) (*models.ServicePrice, error) {
+	var price models.ServicePrice
+	if err := s.db.Conn.First(&price, "id = ?", priceID).Error; err != nil {
+		return nil, err
+	}
+	update.ID = priceID
+	if err := s.validatePrice(update); err != nil {
+		return nil, err
+	}
+
+	// Charges keep the amounts they were captured with, so changing a price
+	// only affects what is charged from now on
+	price.Code = update.Code
+	price.Name = update.Name
+	price.ProcedureCode = update.ProcedureCode
+	price.AppointmentType = update.AppointmentType
+	price.DoctorID = update.DoctorID
+	price.UnitPrice = update.UnitPrice
+	price.TaxRate = update.TaxRate
+	price.Active = update.Active
+	if err := s.db.Conn.Omit(clause.Associations).Save(&price).Error; err != nil {
+		return nil, err
+	}
+	return &price, nil
+}
+
+func (s *billingService) validatePrice(price *models.ServicePrice) error {
+	price.Code = strings.ToUpper(strings.TrimSpace(price.Code))
+	price.Name = strings.TrimSpace(price.Name)
+	price.ProcedureCode = strings.ToUpper(strings.TrimSpace(price.ProcedureCode))
+	if price.Code == "" || price.Name == "" {
+		return errors.New("code and name are required")
+	}
+	if price.AppointmentType != "" && !models.AppointmentTypes[price.AppointmentType] {
+		return errors.New("appointment_type must be one of consultation, follow_up, procedure or emergency")
+	}
+	if price.DoctorID != nil {
+		if price.AppointmentType == "" {
+			return errors.New("a doctor's fee must be for an appointment type")
+		}
+		var count int64
+		if err := s.db.Conn.Model(&models.User{}).Where("id = ? AND role = 'doctor'", *price.DoctorID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return errors.New("doctor not found")
+		}
+	}
+	if price.UnitPrice < 0 {
+		return errors.New("unit_price must not be negative")
+	}
+	if err := validateTaxRate(price.TaxRate); err != nil {
+		return err
+	}
+
+	var count int64
+	if err := s.db.Conn.Model(&models.ServicePrice{}).Where("code = ? AND id <> ?", price.Code, price.ID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("a price with this code already exists")
+	}
+
+	// Only one active price may apply to an appointment type and doctor
+	if price.Active && price.AppointmentType != "" {
+		query := s.db.Conn.Model(&models.ServicePrice{}).
+			Where("active AND appointment_type = ? AND id <> ?", price.AppointmentType, price.ID)
+		if price.DoctorID != nil {
+			query = query.Where("doctor_id = ?", *price.DoctorID)
+		} else {
+			query = query.Where("doctor_id IS NULL")
+		}
+		if err := query.Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return errors.New("another active price already applies to this appointment type and doctor")
+		}
+	}
+	return nil
+}
+
+func (s *billingService) GetCharges(patientID uuid.UUID, unbilledOnly bool) ([]models.Charge, error) {
+	if err := s.db.Conn.Select("id").First(&models.Patient{}, "id = ?", patientID).Error; err != nil {
+		return nil, err
+	}
+
+	query := s.db.Conn.Where("patient_id = ?", patientID)
+	if unbilledOnly {
+		query = query.Where("invoice_id IS NULL")
+	}
+	var charges []models.Charge
+	if err := query.Order("created_at").Find(&charges).Error; err != nil {
+		return nil, err
+	}
+	return charges, nil
+}
+
+// CreateCharge adds a charge by hand. When it refers to a price list entry
+// the description, price and tax come from the price list; otherwise they
+// must be given.
+func (s *billingService) CreateCharge(receptionistID, patientID uuid.UUID, charge *models.Charge) error {
+	if err := s.db.Conn.Select("id").First(&models.Patient{}, "id = ?", patientID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("patient not found")
+		}
+		return err
+	}
+
+	charge.ID = uuid.Nil
+	charge.PatientID = patientID
+	charge.InvoiceID = nil
+	charge.CreatedBy = receptionistID
+	if charge.Quantity == 0 {
+		charge.Quantity = 1
+	}
+	if charge.Quantity < 0 {
+		return errors.New("quantity must be greater than zero")
+	}
+
+	if charge.ServicePriceID != nil {
+		var price models.ServicePrice
+		if err := s.db.Conn.First(&price, "id = ? AND active", *charge.ServicePriceID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("price not found")
+			}
+			return err
+		}
+		if strings.TrimSpace(charge.Description) == "" {
+			charge.Description = price.Name
+		}
+		charge.ProcedureCode = price.ProcedureCode
+		charge.UnitPrice = price.UnitPrice
+		charge.TaxRate = price.TaxRate
+	} else if charge.UnitPrice < 0 {
+		return errors.New("unit_price must not be negative")
+	}
+	charge.ProcedureCode = strings.ToUpper(strings.TrimSpace(charge.ProcedureCode))
+	charge.Description = strings.TrimSpace(charge.Description)
+	if charge.Description == "" {
+		return errors.New("description is required")
+	}
+	if err := validateTaxRate(charge.TaxRate); err != nil {
+		return err
+	}
+
+	if charge.AppointmentID != nil {
+		var count int64
+		if err := s.db.Conn.Model(&models.Appointment{}).
+			Where("id = ? AND patient_id = ?", *charge.AppointmentID, patientID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return errors.New("appointment not found")
+		}
+	}
+
+	return s.db.Conn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(charge).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, receptionistID, "charge.created", "charge", charge.ID, map[string]any{
+			"patient_id": patientID,
+			"amount":     charge.UnitPrice * int64(charge.Quantity),
+		})
+	})
+}
+
+// DeleteCharge removes a charge entered in error. Invoiced charges are part
+// of the invoice and can only be released by voiding it.
+func (s *billingService) DeleteCharge(receptionistID, patientID, chargeID uuid.UUID) error {
+	return s.db.Conn.Transaction(func(tx *gorm.DB) error {
+		var charge models.Charge
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&charge, "id = ? AND patient_id = ?", chargeID, patientID).Error; err != nil {
+			return err
+		}
+		if charge.InvoiceID != nil {
+			return errors.New("invoiced charges cannot be deleted")
+		}
+		if err := tx.Delete(&charge).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, receptionistID, "charge.deleted", "charge", charge.ID, map[string]any{
+			"patient_id":  patientID,
+			"description": charge.Description,
+			"amount":      charge.UnitPrice * int64(charge.Quantity),
+		})
+	})
+}
+
+// captureAppointmentCharges charges the fee for a completed appointment: the
+// doctor's own price for the appointment type if there is one, otherwise the
+// general price. Nothing is charged when the price list has no entry for the
+// type or the fee was captured before, so completing twice is harmless.
+func captureAppointmentCharges(tx *gorm.DB, userID uuid.UUID, appointment *models.Appointment) error {
+	var price models.ServicePrice
+	err := tx.Where("active AND appointment_type = ? AND (doctor_id = ? OR doctor_id IS NULL)",
+		appointment.Type, appointment.DoctorID).
+		Order("doctor_id IS NULL").First(&price).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var captured int64
+	if err := tx.Model(&models.Charge{}).Where("appointment_id = ? AND service_price_id IS NOT NULL", appointment.ID).
+		Count(&captured).Error; err != nil {
+		return err
+	}
+	if captured > 0 {
+		return nil
+	}
+
+	charge := models.Charge{
+		PatientID:      appointment.PatientID,
+		AppointmentID:  &appointment.ID,
+		ServicePriceID: &price.ID,
+		Description:    price.Name,
+		ProcedureCode:  price.ProcedureCode,
+		Quantity:       1,
+		UnitPrice:      price.UnitPrice,
+		TaxRate:        price.TaxRate,
+		CreatedBy:      userID,
+	}
+	if err := tx.Create(&charge).Error; err != nil {
+		return err
+	}
+	return recordAudit(tx, userID, "charge.captured", "charge", charge.ID, map[string]any{
+		"patient_id":     appointment.PatientID,
+		"appointment_id": appointment.ID,
+		"amount":         charge.UnitPrice,
+	})
+}
+
+// CreateInvoice bills the given unbilled charges, or all of the patient's
+// unbilled charges when none are given.
+func (s *billingService) CreateInvoice(receptionistID, patientID uuid.UUID, chargeIDs []uuid.UUID, notes string) (*models.Invoice, error) {
+	if err := s.db.Conn.Select("id").First(&models.Patient{}, "id = ?", patientID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("patient not found")
+		}
+		return nil, err
+	}
+
+	var invoice models.Invoice
+	err := s.db.Conn.Transaction(func(tx *gorm.DB) error {
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("patient_id = ? AND invoice_id IS NULL", patientID)
+		if len(chargeIDs) > 0 {
+			query = query.Where("id IN ?", chargeIDs)
+		}
+		var charges []models.Charge
+		if err := query.Order("created_at").Find(&charges).Error; err != nil {
+			return err
+		}
+		if len(charges) == 0 {
+			return errors.New("patient has no unbilled charges")
+		}
+		if len(chargeIDs) > 0 && len(charges) != countDistinct(chargeIDs) {
+			return errors.New("some charges were not found or are already invoiced")
+		}
+
+		now := time.Now()
+		number, fiscalYear, err := nextInvoiceNumber(tx, s.cfg.BillingConfig, now)
+		if err != nil {
+			return err
+		}
+		terms := s.cfg.BillingConfig.PaymentTermsDays
+		if terms < 0 {
+			terms = 0
+		}
+		invoice = models.Invoice{
+			Number:     number,
+			FiscalYear: fiscalYear,
+			PatientID:  patientID,
+			Status:     "issued",
+			IssuedAt:   now,
+			DueDate:    models.NewDate(now.AddDate(0, 0, terms)),
+			Currency:   s.cfg.BillingConfig.Currency,
+			Notes:      strings.TrimSpace(notes),
+			CreatedBy:  receptionistID,
+		}
+		for _, charge := range charges {
+			subtotal := charge.UnitPrice * int64(charge.Quantity)
+			tax := taxAmount(subtotal, charge.TaxRate)
+			invoice.Lines = append(invoice.Lines, models.InvoiceLine{
+				ChargeID:    &charge.ID,
+				Description: charge.Description,
+				Quantity:    charge.Quantity,
+				UnitPrice:   charge.UnitPrice,
+				TaxRate:     charge.TaxRate,
+				Subtotal:    subtotal,
+				Tax:         tax,
+				Total:       subtotal + tax,
+			})
+			invoice.Subtotal += subtotal
+			invoice.Tax += tax
+		}
+		invoice.Total = invoice.Subtotal + invoice.Tax
+		if invoice.Total == 0 {
+			invoice.Status = "paid"
+		}
+
+		lines := invoice.Lines
+		if err := tx.Omit(clause.Associations).Create(&invoice).Error; err != nil {
+			return err
+		}
+		for i := range lines {
+			lines[i].InvoiceID = invoice.ID
+		}
+		if err := tx.Create(&lines).Error; err != nil {
+			return err
+		}
+		invoice.Lines = lines
+
+		ids := make([]uuid.UUID, len(charges))
+		for i, charge := range charges {
+			ids[i] = charge.ID
+		}
+		if err := tx.Model(&models.Charge{}).Where("id IN ?", ids).Update("invoice_id", invoice.ID).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, receptionistID, "invoice.issued", "invoice", invoice.ID, map[string]any{
+			"number": invoice.Number,
+			"total":  invoice.Total,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+func (s *billingService) GetInvoices(filter InvoiceFilter, page, limit int) ([]models.Invoice, int64, error) {
+	query := s.db.Conn.Model(&models.Invoice{})
+	if filter.PatientID != nil {
+		query = query.Where("patient_id = ?", *filter.PatientID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var invoices []models.Invoice
+	if err := query.Preload("Patient").Order("issued_at DESC").
+		Offset((page - 1) * limit).Limit(limit).Find(&invoices).Error; err != nil {
+		return nil, 0, err
+	}
+	return invoices, total, nil
+}
+
+func (s *billingService) GetInvoice(invoiceID uuid.UUID) (*models.Invoice, error) {
+	return loadInvoice(s.db.Conn, invoiceID)
+}
+
+func loadInvoice(tx *gorm.DB, invoiceID uuid.UUID) (*models.Invoice, error) {
+	var invoice models.Invoice
+	if err := tx.Preload("Patient").
+		Preload("Lines").
+		Preload("Payments", func(db *gorm.DB) *gorm.DB { return db.Order("received_at") }).
+		Preload("Refunds", func(db *gorm.DB) *gorm.DB { return db.Order("refunded_at") }).
+		First(&invoice, "id = ?", invoiceID).Error; err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+// VoidInvoice cancels an invoice issued in error. Its charges become unbilled
+// again so they can be put on a corrected invoice; the number is not reused.
+func (s *billingService) VoidInvoice(receptionistID, invoiceID uuid.UUID, reason string) (*models.Invoice, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.New("a reason is required to void an invoice")
+	}
+
+	var invoice *models.Invoice
+	err := s.db.Conn.Transaction(func(tx *gorm.DB) error {
+		var locked models.Invoice
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&locked, "id = ?", invoiceID).Error; err != nil {
+			return err
+		}
+		if locked.Status == "void" {
+			return errors.New("invoice is already void")
+		}
+		if locked.AmountPaid > 0 {
+			return errors.New("invoices with payments cannot be voided")
+		}
+		if err := tx.Model(&locked).Updates(map[string]any{"status": "void", "void_reason": reason}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Charge{}).Where("invoice_id = ?", invoiceID).Update("invoice_id", nil).Error; err != nil {
+			return err
+		}
+		if err := recordAudit(tx, receptionistID, "invoice.voided", "invoice", invoiceID, map[string]any{
+			"number": locked.Number,
+			"reason": reason,
+		}); err != nil {
+			return err
+		}
+		var err error
+		invoice, err = loadInvoice(tx, invoiceID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return invoice, nil
+}
+
+// RecordPayment takes a payment against an invoice. Payments may be partial
+// but never more than the outstanding balance. Insurance payments name the
+// patient's policy that paid.
+func (s *billingService) RecordPayment(receptionistID, invoiceID uuid.UUID, payment *models.Payment) (*models.Invoice, error) {
+	if !models.PaymentMethods[payment.Method] {
+		return nil, errors.New("method must be one of cash, card or insurance")
+	}
+	if payment.Amount <= 0 {
+		return nil, errors.New("amount must be greater than zero")
+	}
+	if payment.Method == "insurance" && payment.InsurancePolicyID == nil {
+		return nil, errors.New("insurance payments require insurance_policy_id")
+	}
+	if payment.Method != "insurance" {
+		payment.InsurancePolicyID = nil
+	}
+	now := time.Now()
+	if payment.ReceivedAt.IsZero() {
+		payment.ReceivedAt = now
+	}
+	if payment.ReceivedAt.After(now) {
+		return nil, errors.New("received_at must not be in the future")
+	}
+
+	var invoice *models.Invoice
+	err := s.db.Conn.Transaction(func(tx *gorm.DB) error {
+		if err := applyPayment(tx, receptionistID, invoiceID, payment); err != nil {
+			return err
+		}
+		var err error
+		invoice, err = loadInvoice(tx, invoiceID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return invoice, nil
+}
+
+// applyPayment stores a validated payment against an invoice and updates the
+// invoice's paid amount and status.
+func applyPayment(tx *gorm.DB, userID, invoiceID uuid.UUID, payment *models.Payment) error {
+	var locked models.Invoice
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&locked, "id = ?", invoiceID).Error; err != nil {
+		return err
+	}
+	if locked.Status == "void" {
+		return errors.New("payments cannot be recorded against a void invoice")
+	}
+	if payment.Amount > locked.Balance() {
+		return fmt.Errorf("payment exceeds the outstanding balance of %s", formatAmount(locked.Balance()))
+	}
+	if payment.InsurancePolicyID != nil {
+		var count int64
+		if err := tx.Model(&models.InsurancePolicy{}).
+			Where("id = ? AND patient_id = ?", *payment.InsurancePolicyID, locked.PatientID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return errors.New("insurance policy not found for this patient")
+		}
+	}
+
+	payment.ID = uuid.Nil
+	payment.InvoiceID = invoiceID
+	payment.ReceivedBy = userID
+	payment.Reference = strings.TrimSpace(payment.Reference)
+	if err := tx.Create(payment).Error; err != nil {
+		return err
+	}
+
+	locked.AmountPaid += payment.Amount
+	status := "partially_paid"
+	if locked.Balance() == 0 {
+		status = "paid"
+	}
+	if err := tx.Model(&locked).Updates(map[string]any{"amount_paid": locked.AmountPaid, "status": status}).Error; err != nil {
+		return err
+	}
+	return recordAudit(tx, userID, "payment.recorded", "invoice", invoiceID, map[string]any{
+		"payment_id": payment.ID,
+		"method":     payment.Method,
+		"amount":     payment.Amount,
+	})
+}
+
+// RefundPayment gives back part or all of a payment. The refund is paid out by
+// the payment's method and counts in the current shift of whoever issues it,
+// even when the payment itself is in an earlier, closed shift.
+func (s *billingService) RefundPayment(receptionistID, paymentID uuid.UUID, amount int64, reason string) (*models.Invoice, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.New("a reason is required for a refund")
+	}
+	if amount <= 0 {
+		return nil, errors.New("amount must be greater than zero")
+	}
+
+	var invoice *models.Invoice
+	err := s.db.Conn.Transaction(func(tx *gorm.DB) error {
+		var payment models.Payment
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&payment, "id = ?", paymentID).Error; err != nil {
+			return err
+		}
+		var refunded int64
+		if err := tx.Model(&models.Refund{}).Where("payment_id = ?", paymentID).
+			Select("COALESCE(SUM(amount), 0)").Scan(&refunded).Error; err != nil {
+			return err
+		}
+		if amount > payment.Amount-refunded {
+			return fmt.Errorf("refund exceeds the %s still refundable on this payment", formatAmount(payment.Amount-refunded))
+		}
+
+		var locked models.Invoice
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&locked, "id = ?", payment.InvoiceID).Error; err != nil {
+			return err
+		}
+		refund := models.Refund{
+			PaymentID:  paymentID,
+			InvoiceID:  payment.InvoiceID,
+			Method:     payment.Method,
+			Amount:     amount,
+			Reason:     reason,
+			RefundedBy: receptionistID,
+			RefundedAt: time.Now(),
+		}
+		if err := tx.Create(&refund).Error; err != nil {
+			return err
+		}
+
+		locked.AmountPaid -= amount
+		status := "partially_paid"
+		if locked.AmountPaid == 0 {
+			status = "issued"
+		}
+		if err := tx.Model(&locked).Updates(map[string]any{"amount_paid": locked.AmountPaid, "status": status}).Error; err != nil {
+			return err
+		}
+		if err := recordAudit(tx, receptionistID, "payment.refunded", "invoice", locked.ID, map[string]any{
+			"payment_id": paymentID,
+			"refund_id":  refund.ID,
+			"amount":     amount,
+			"reason":     reason,
+		}); err != nil {
+			return err
+		}
+		var err error
+		invoice, err = loadInvoice(tx, locked.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return invoice, nil
+}
+
+// nextInvoiceNumber issues the next number in the fiscal year containing t.
+func nextInvoiceNumber(tx *gorm.DB, cfg config.BillingConfig, t time.Time) (string, int, error) {
+	year := fiscalYear(t, cfg.FiscalYearStartMonth)
+	var value int64
+	if err := tx.Raw(`INSERT INTO invoice_sequences (fiscal_year, last_value) VALUES (?, 1)
+		ON CONFLICT (fiscal_year) DO UPDATE SET last_value = invoice_sequences.last_value + 1
+		RETURNING last_value`, year).Scan(&value).Error; err != nil {
+		return "", 0, err
+	}
+	prefix := cfg.InvoicePrefix
+	if prefix == "" {
+		prefix = "INV"
+	}
+	return fmt.Sprintf("%s-%d-%06d", prefix, year, value), year, nil
+}
+
+// fiscalYear names the fiscal year containing t after the calendar year it
+// starts in.
+func fiscalYear(t time.Time, startMonth int) int {
+	if startMonth < 1 || startMonth > 12 {
+		startMonth = 1
+	}
+	if int(t.Month()) < startMonth {
+		return t.Year() - 1
+	}
+	return t.Year()
+}
+
+// taxAmount is the tax on an amount at a percentage rate, rounded half away
+// from zero to the nearest minor unit. Rates are stored with two decimals, so
+// the sum is done in basis points to keep exact halves from rounding down.
+func taxAmount(amount int64, rate float64) int64 {
+	tax := amount * int64(math.Round(rate*100))
+	if tax < 0 {
+		return -((-tax + 5000) / 10000)
+	}
+	return (tax + 5000) / 10000
+}
+
+func validateTaxRate(rate float64) error {
+	if rate < 0 || rate > 100 {
+		return errors.New("tax_rate must be between 0 and 100")
+	}
+	return nil
+}
+
+// formatAmount renders minor units as a decimal amount, e.g. 1250 as "12.50".
+func formatAmount(amount int64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, amount/100, amount%100)
+}
+
+func countDistinct(ids []uuid.UUID) int {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		seen[id] = true
+	}
+	return len(seen)
+}
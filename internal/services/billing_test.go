@@ -0,0 +1,79 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTaxAmount(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount int64
+		rate   float64
+		want   int64
+	}{
+		{"no tax", 12550, 0, 0},
+		{"whole result", 10000, 23, 2300},
+		{"rounds down below half", 1234, 10, 123},
+		{"rounds half up", 1005, 10, 101},
+		{"rounds half up with fractional rate", 1000, 0.15, 2},
+		{"fractional rate", 1999, 7.5, 150},
+		{"exact half that floats round down", 5000, 0.57, 29},
+		{"another float half", 3000, 1.15, 35},
+		{"rounds half away from zero for refunds", -1005, 10, -101},
+		{"full rate", 999, 100, 999},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := taxAmount(tt.amount, tt.rate); got != tt.want {
+				t.Errorf("taxAmount(%d, %v) = %d, want %d", tt.amount, tt.rate, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFiscalYear(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 12, 0, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name       string
+		t          time.Time
+		startMonth int
+		want       int
+	}{
+		{"calendar year", date(2026, time.March, 1), 1, 2026},
+		{"calendar year end", date(2026, time.December, 31), 1, 2026},
+		{"before an April start", date(2026, time.March, 31), 4, 2025},
+		{"on an April start", date(2026, time.April, 1), 4, 2026},
+		{"after an April start", date(2027, time.January, 15), 4, 2026},
+		{"December start", date(2026, time.November, 30), 12, 2025},
+		{"unset start month is January", date(2026, time.January, 1), 0, 2026},
+		{"invalid start month is January", date(2026, time.June, 1), 13, 2026},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fiscalYear(tt.t, tt.startMonth); got != tt.want {
+				t.Errorf("fiscalYear(%s, %d) = %d, want %d", tt.t.Format("2006-01-02"), tt.startMonth, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatAmount(t *testing.T) {
+	tests := []struct {
+		amount int64
+		want   string
+	}{
+		{0, "0.00"},
+		{5, "0.05"},
+		{1250, "12.50"},
+		{-1250, "-12.50"},
+		{-5, "-0.05"},
+	}
+	for _, tt := range tests {
+		if got := formatAmount(tt.amount); got != tt.want {
+			t.Errorf("formatAmount(%d) = %q, want %q", tt.amount, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"hospital/internal/models"
+)
+
+// preloadCareTeam loads the care-team memberships of patients that are in
+// effect now, together with their doctors.
+func preloadCareTeam(db *gorm.DB) *gorm.DB {
+	now := time.Now()
+	return db.Preload("CareTeam", "effective_from <= ? AND (effective_to IS NULL OR effective_to > ?)", now, now).
+		Preload("CareTeam.Doctor")
+}
+
+func (s *ReceptionistService) GetCareTeam(patientID uuid.UUID, includeEnded bool) ([]models.CareTeamMember, error) {
+	if err := s.db.Conn.Select("id").First(&models.Patient{}, "id = ?", patientID).Error; err != nil {
+		return nil, err
+	}
+
+	query := s.db.Conn.Preload("Doctor").Where("patient_id = ?", patientID)
+	if !includeEnded {
+		now := time.Now()
+		query = query.Where("effective_to IS NULL OR effective_to > ?", now)
+	}
+
+	var members []models.CareTeamMember
+	if err := query.Order("effective_from DESC").Find(&members).Error; err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// AddCareTeamMember adds a doctor to a patient's care team. A new primary
+// doctor takes over from the open-ended primary membership, which is ended
+// when the new one takes effect; any other overlap between primary
+// memberships is rejected.
+func (s *ReceptionistService) AddCareTeamMember(receptionistID, patientID uuid.UUID, member *models.CareTeamMember) error {
+	if !models.CareTeamRoles[member.Role] {
+		return errors.New("role must be one of primary, consulting or covering")
+	}
+	if member.EffectiveFrom.IsZero() {
+		member.EffectiveFrom = time.Now()
+	}
+	if member.EffectiveTo != nil && !member.EffectiveTo.After(member.EffectiveFrom) {
+		return errors.New("effective_to must be after effective_from")
+	}
+
+	return s.db.Conn.Transaction(func(tx *gorm.DB) error {
+		// Lock the patient so concurrent changes to the same care team are serialized
+		var patient models.Patient
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&patient, "id = ?", patientID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("patient not found")
+			}
+			return err
+		}
+		var doctor models.User
+		if err := tx.First(&doctor, "id = ? AND role = ?", member.DoctorID, "doctor").Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("doctor not found")
+			}
+			return err
+		}
+
+		var overlapping []models.CareTeamMember
+		if err := overlappingMemberships(tx, patientID, member.EffectiveFrom, member.EffectiveTo).
+			Find(&overlapping).Error; err != nil {
+			return err
+		}
+		for _, other := range overlapping {
+			if other.DoctorID == member.DoctorID {
+				return errors.New("doctor is already on the care team for this period")
+			}
+			if member.Role != "primary" || other.Role != "primary" {
+				continue
+			}
+			if other.EffectiveTo != nil || !other.EffectiveFrom.Before(member.EffectiveFrom) {
+				return errors.New("patient already has a primary doctor for this period")
+			}
+			if err := tx.Model(&other).Update("effective_to", member.EffectiveFrom).Error; err != nil {
+				return err
+			}
+		}
+
+		member.ID = uuid.Nil
+		member.PatientID = patientID
+		if err := tx.Omit(clause.Associations).Create(member).Error; err != nil {
+			return err
+		}
+		member.Doctor = &doctor
+		return recordAudit(tx, receptionistID, "care_team.member_added", "patient", patientID, map[string]any{
+			"member_id": member.ID,
+			"doctor_id": member.DoctorID,
+			"role":      member.Role,
+		})
+	})
+}
+
+// EndCareTeamMember ends a membership at endAt, which must not be before it
+// took effect. A membership can only be cut short, never extended, so ending
+// it cannot overlap a primary doctor who took over afterwards.
+func (s *ReceptionistService) EndCareTeamMember(receptionistID, patientID, memberID uuid.UUID, endAt time.Time) (*models.CareTeamMember, error) {
+	var member models.CareTeamMember
+	err := s.db.Conn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&member, "id = ? AND patient_id = ?", memberID, patientID).Error; err != nil {
+			return err
+		}
+		if member.EffectiveTo != nil && !member.EffectiveTo.After(time.Now()) {
+			return errors.New("membership has already ended")
+		}
+		if endAt.Before(member.EffectiveFrom) {
+			return errors.New("end date must not be before the membership took effect")
+		}
+		if member.EffectiveTo != nil && endAt.After(*member.EffectiveTo) {
+			return errors.New("end date must not be after the membership's current end date")
+		}
+		if err := tx.Model(&member).Update("effective_to", endAt).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, receptionistID, "care_team.member_ended", "patient", patientID, map[string]any{
+			"member_id": member.ID,
+			"doctor_id": member.DoctorID,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// overlappingMemberships selects a patient's memberships that are in effect at
+// some point between from and to. A nil to means open-ended.
+func overlappingMemberships(tx *gorm.DB, patientID uuid.UUID, from time.Time, to *time.Time) *gorm.DB {
+	query := tx.Where("patient_id = ? AND (effective_to IS NULL OR effective_to > ?)", patientID, from)
+	if to != nil {
+		query = query.Where("effective_from < ?", *to)
+	}
+	return query
+}
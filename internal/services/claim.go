@@ -0,0 +1,570 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"hospital/internal/config"
+	"hospital/internal/database"
+	"hospital/internal/importer"
+	"hospital/internal/models"
+)
+
+// maxClaimDiagnoses is the number of diagnosis codes a professional claim can carry.
+const maxClaimDiagnoses = 12
+
+// claimTransitions lists the statuses a claim may move to from each status
+// when the payer's response is recorded by hand. Remittances may also mark
+// submitted, accepted or denied claims as paid.
+var claimTransitions = map[string][]string{
+	"submitted": {"accepted", "denied"},
+	"accepted":  {"denied"},
+}
+
+// ClaimService drafts insurance claims for completed encounters, tracks them
+// through the payer's responses and reconciles remittance files against them.
+type ClaimService interface {
+	CreateClaim(userID, appointmentID uuid.UUID, policyID *uuid.UUID) (*models.Claim, error)
+	GetClaims(filter ClaimFilter, page, limit int) ([]models.Claim, int64, error)
+	GetClaim(claimID uuid.UUID) (*models.Claim, error)
+	DeleteClaim(userID, claimID uuid.UUID) error
+	SubmitClaim(userID, claimID uuid.UUID) (*models.Claim, error)
+	RecordClaimResponse(userID, claimID uuid.UUID, status, reason string) (*models.Claim, error)
+	ResolveFollowUp(userID, claimID uuid.UUID, note string) (*models.Claim, error)
+
+	ImportRemittance(userID uuid.UUID, fileName string, r io.Reader) (*models.RemittanceBatch, error)
+	GetRemittance(batchID uuid.UUID) (*models.RemittanceBatch, error)
+}
+
+// ClaimFilter narrows a claim listing; empty fields match everything.
+type ClaimFilter struct {
+	PatientID     *uuid.UUID
+	Status        string
+	NeedsFollowUp bool
+}
+
+type claimService struct {
+	db  *database.DB
+	cfg config.Config
+}
+
+func NewClaimService(db *database.DB, cfg config.Config) ClaimService {
+	return &claimService{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// CreateClaim drafts a claim for a completed encounter from the diagnoses made
+// in it and its charges. Without a policy the patient's primary policy on the
+// day of the encounter is billed.
+func (s *claimService) CreateClaim(userID, appointmentID uuid.UUID, policyID *uuid.UUID) (*models.Claim, error) {
+	var appointment models.Appointment
+	if err := s.db.Conn.First(&appointment, "id = ?", appointmentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("appointment not found")
+		}
+		return nil, err
+	}
+	if appointment.Status != "completed" {
+		return nil, errors.New("only completed encounters can be claimed")
+	}
+	serviceDate := models.NewDate(appointment.AppointmentDate)
+
+	var policy models.InsurancePolicy
+	query := s.db.Conn.Where("patient_id = ? AND valid_from <= ? AND (valid_to IS NULL OR valid_to >= ?)",
+		appointment.PatientID, serviceDate, serviceDate)
+	if policyID != nil {
+		query = query.Where("id = ?", *policyID)
+	}
+	if err := query.Order("priority").First(&policy).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("patient has no insurance policy covering the encounter date")
+		}
+		return nil, err
+	}
+
+	var diagnoses []models.Diagnosis
+	if err := s.db.Conn.Where("appointment_id = ?", appointmentID).Order("created_at").Find(&diagnoses).Error; err != nil {
+		return nil, err
+	}
+	var codes []string
+	seen := make(map[string]bool, len(diagnoses))
+	for _, diagnosis := range diagnoses {
+		if !seen[diagnosis.Code] {
+			seen[diagnosis.Code] = true
+			codes = append(codes, diagnosis.Code)
+		}
+	}
+	if len(codes) == 0 {
+		return nil, errors.New("the encounter has no diagnoses to claim")
+	}
+	if len(codes) > maxClaimDiagnoses {
+		return nil, fmt.Errorf("a claim can carry at most %d diagnoses", maxClaimDiagnoses)
+	}
+
+	var charges []models.Charge
+	if err := s.db.Conn.Where("appointment_id = ?", appointmentID).Order("created_at").Find(&charges).Error; err != nil {
+		return nil, err
+	}
+	if len(charges) == 0 {
+		return nil, errors.New("the encounter has no charges to claim")
+	}
+
+	claim := models.Claim{
+		PatientID:         appointment.PatientID,
+		AppointmentID:     appointmentID,
+		InsurancePolicyID: policy.ID,
+		PayerID:           policy.PayerID,
+		Status:            "draft",
+		ServiceDate:       serviceDate,
+		CreatedBy:         userID,
+	}
+	for i, code := range codes {
+		claim.Diagnoses = append(claim.Diagnoses, models.ClaimDiagnosis{Sequence: i + 1, Code: code})
+	}
+	for i, charge := range charges {
+		if charge.ProcedureCode == "" {
+			return nil, fmt.Errorf("charge %q has no procedure code", charge.Description)
+		}
+		charged := charge.UnitPrice * int64(charge.Quantity)
+		claim.Lines = append(claim.Lines, models.ClaimLine{
+			ChargeID:      &charges[i].ID,
+			ProcedureCode: charge.ProcedureCode,
+			Description:   charge.Description,
+			Quantity:      charge.Quantity,
+			Charged:       charged,
+		})
+		claim.TotalCharged += charged
+	}
+	// Insurance payments are posted to the invoice when all charges are on one
+	if invoiceID := charges[0].InvoiceID; invoiceID != nil {
+		claim.InvoiceID = invoiceID
+		for _, charge := range charges[1:] {
+			if charge.InvoiceID == nil || *charge.InvoiceID != *invoiceID {
+				claim.InvoiceID = nil
+				break
+			}
+		}
+	}
+
+	err := s.db.Conn.Transaction(func(tx *gorm.DB) error {
+		var open int64
+		if err := tx.Model(&models.Claim{}).
+			Where("appointment_id = ? AND insurance_policy_id = ? AND status <> 'denied'", appointmentID, policy.ID).
+			Count(&open).Error; err != nil {
+			return err
+		}
+		if open > 0 {
+			return errors.New("a claim for this encounter and policy already exists")
+		}
+
+		var seq int64
+		if err := tx.Raw("SELECT nextval('claim_number_seq')").Scan(&seq).Error; err != nil {
+			return err
+		}
+		claim.Number = fmt.Sprintf("CLM%08d", seq)
+		if err := tx.Create(&claim).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, userID, "claim.created", "claim", claim.ID, map[string]any{
+			"number":         claim.Number,
+			"appointment_id": appointmentID,
+			"total_charged":  claim.TotalCharged,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &claim, nil
+}
+
+func (s *claimService) GetClaims(filter ClaimFilter, page, limit int) ([]models.Claim, int64, error) {
+	query := s.db.Conn.Model(&models.Claim{})
+	if filter.PatientID != nil {
+		query = query.Where("patient_id = ?", *filter.PatientID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.NeedsFollowUp {
+		query = query.Where("needs_follow_up")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var claims []models.Claim
+	if err := query.Preload("Patient").Preload("Policy.Payer").Order("created_at DESC").
+		Offset((page - 1) * limit).Limit(limit).Find(&claims).Error; err != nil {
+		return nil, 0, err
+	}
+	return claims, total, nil
+}
+
+func (s *claimService) GetClaim(claimID uuid.UUID) (*models.Claim, error) {
+	return loadClaim(s.db.Conn, claimID)
+}
+
+func loadClaim(tx *gorm.DB, claimID uuid.UUID) (*models.Claim, error) {
+	var claim models.Claim
+	if err := tx.Preload("Patient").
+		Preload("Policy.Payer").
+		Preload("Diagnoses", func(db *gorm.DB) *gorm.DB { return db.Order("sequence") }).
+		Preload("Lines").
+		First(&claim, "id = ?", claimID).Error; err != nil {
+		return nil, err
+	}
+	return &claim, nil
+}
+
+// DeleteClaim discards a draft claim, e.g. to redraft it after the encounter's
+// diagnoses or charges were corrected.
+func (s *claimService) DeleteClaim(userID, claimID uuid.UUID) error {
+	return s.db.Conn.Transaction(func(tx *gorm.DB) error {
+		claim, err := lockClaim(tx, claimID)
+		if err != nil {
+			return err
+		}
+		if claim.Status != "draft" {
+			return errors.New("only draft claims can be deleted")
+		}
+		if err := tx.Where("claim_id = ?", claimID).Delete(&models.ClaimDiagnosis{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("claim_id = ?", claimID).Delete(&models.ClaimLine{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(claim).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, userID, "claim.deleted", "claim", claimID, map[string]any{"number": claim.Number})
+	})
+}
+
+// SubmitClaim marks a draft claim as sent to the payer. The claim file itself
+// is exchanged with the clearinghouse outside this system.
+func (s *claimService) SubmitClaim(userID, claimID uuid.UUID) (*models.Claim, error) {
+	return s.updateClaim(userID, claimID, func(claim *models.Claim) (map[string]any, error) {
+		if claim.Status != "draft" {
+			return nil, errors.New("only draft claims can be submitted")
+		}
+		return map[string]any{"status": "submitted", "submitted_at": time.Now()}, nil
+	}, nil)
+}
+
+// RecordClaimResponse records the payer accepting or denying a claim outside
+// a remittance, e.g. from a clearinghouse acknowledgement. Denials are flagged
+// for follow-up.
+func (s *claimService) RecordClaimResponse(userID, claimID uuid.UUID, status, reason string) (*models.Claim, error) {
+	reason = strings.TrimSpace(reason)
+	if status == "denied" && reason == "" {
+		return nil, errors.New("a reason is required to deny a claim")
+	}
+	return s.updateClaim(userID, claimID, func(claim *models.Claim) (map[string]any, error) {
+		allowed := false
+		for _, next := range claimTransitions[claim.Status] {
+			allowed = allowed || next == status
+		}
+		if !allowed {
+			return nil, fmt.Errorf("a %s claim cannot become %s", claim.Status, status)
+		}
+		updates := map[string]any{"status": status, "adjudicated_at": time.Now()}
+		if status == "denied" {
+			updates["denial_reason"] = reason
+			updates["needs_follow_up"] = true
+			updates["follow_up_reason"] = "Denied by payer: " + reason
+		}
+		return updates, nil
+	}, map[string]any{"reason": reason})
+}
+
+// ResolveFollowUp clears a claim's follow-up flag once someone has dealt with it.
+func (s *claimService) ResolveFollowUp(userID, claimID uuid.UUID, note string) (*models.Claim, error) {
+	return s.updateClaim(userID, claimID, func(claim *models.Claim) (map[string]any, error) {
+		if !claim.NeedsFollowUp {
+			return nil, errors.New("claim does not need follow-up")
+		}
+		return map[string]any{"needs_follow_up": false, "follow_up_reason": ""}, nil
+	}, map[string]any{"note": strings.TrimSpace(note)})
+}
+
+// updateClaim applies the changes returned by change to a locked claim and
+// audits them as claim.<new status>, or claim.updated when the status stays.
+func (s *claimService) updateClaim(userID, claimID uuid.UUID, change func(claim *models.Claim) (map[string]any, error), details map[string]any) (*models.Claim, error) {
+	var updated *models.Claim
+	err := s.db.Conn.Transaction(func(tx *gorm.DB) error {
+		claim, err := lockClaim(tx, claimID)
+		if err != nil {
+			return err
+		}
+		updates, err := change(claim)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(claim).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		action := "claim.updated"
+		if status, ok := updates["status"].(string); ok {
+			action = "claim." + status
+		}
+		auditDetails := map[string]any{"number": claim.Number}
+		for key, value := range details {
+			auditDetails[key] = value
+		}
+		if err := recordAudit(tx, userID, action, "claim", claimID, auditDetails); err != nil {
+			return err
+		}
+		updated, err = loadClaim(tx, claimID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func lockClaim(tx *gorm.DB, claimID uuid.UUID) (*models.Claim, error) {
+	var claim models.Claim
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&claim, "id = ?", claimID).Error; err != nil {
+		return nil, err
+	}
+	return &claim, nil
+}
+
+// ImportRemittance reconciles a remittance file against submitted claims.
+// Each row is matched to a claim by its number. Payments are posted to the
+// claim's invoice, denials are recorded, and claims paid less than charged
+// minus the patient's share are flagged as underpaid. Rows that cannot be
+// matched or parsed are kept on the batch for review rather than failing the
+// whole file, and rows already imported are skipped.
+func (s *claimService) ImportRemittance(userID uuid.UUID, fileName string, r io.Reader) (*models.RemittanceBatch, error) {
+	batch := models.RemittanceBatch{FileName: fileName, ImportedBy: userID}
+	err := s.db.Conn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&batch).Error; err != nil {
+			return err
+		}
+		err := importer.ReadRemittance(r, func(row importer.RemittanceRow) error {
+			line := models.RemittanceLine{
+				BatchID:               batch.ID,
+				Line:                  row.Line,
+				ClaimNumber:           row.ClaimNumber,
+				PayerClaimID:          row.PayerClaimID,
+				StatusCode:            row.StatusCode,
+				Charged:               row.Charged,
+				Paid:                  row.Paid,
+				PatientResponsibility: row.PatientResponsibility,
+				AdjustmentCode:        row.AdjustmentCode,
+				CheckNumber:           row.CheckNumber,
+				PaidDate:              row.PaidDate,
+			}
+			if row.Err != nil {
+				line.Outcome = "rejected"
+				line.Message = row.Err.Error()
+			} else if err := reconcileRemittanceLine(tx, userID, &line); err != nil {
+				return err
+			}
+
+			batch.Rows++
+			switch line.Outcome {
+			case "matched":
+				batch.Matched++
+			case "unmatched", "rejected":
+				batch.Unmatched++
+			}
+			if line.Flag != "" {
+				batch.Flagged++
+			}
+			return tx.Create(&line).Error
+		})
+		if err != nil {
+			return err
+		}
+		if batch.Rows == 0 {
+			return errors.New("remittance file has no rows")
+		}
+		if err := tx.Model(&batch).Select("rows", "matched", "unmatched", "flagged").Updates(&batch).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, userID, "remittance.imported", "remittance_batch", batch.ID, map[string]any{
+			"file_name": fileName,
+			"rows":      batch.Rows,
+			"matched":   batch.Matched,
+			"flagged":   batch.Flagged,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetRemittance(batch.ID)
+}
+
+func (s *claimService) GetRemittance(batchID uuid.UUID) (*models.RemittanceBatch, error) {
+	var batch models.RemittanceBatch
+	if err := s.db.Conn.Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("line") }).
+		First(&batch, "id = ?", batchID).Error; err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// reconcileRemittanceLine applies one parsed remittance row to its claim and
+// sets the line's outcome, flag and message. Only database failures are
+// returned as errors.
+func reconcileRemittanceLine(tx *gorm.DB, userID uuid.UUID, line *models.RemittanceLine) error {
+	var claim models.Claim
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&claim, "number = ?", line.ClaimNumber).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		line.Outcome = "unmatched"
+		line.Message = "no claim with this number"
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	line.ClaimID = &claim.ID
+
+	var imported int64
+	if err := tx.Model(&models.RemittanceLine{}).
+		Where("claim_id = ? AND status_code = ? AND check_number = ? AND paid = ? AND outcome = 'matched'",
+			claim.ID, line.StatusCode, line.CheckNumber, line.Paid).
+		Count(&imported).Error; err != nil {
+		return err
+	}
+	if imported > 0 {
+		line.Outcome = "duplicate"
+		line.Message = "already imported"
+		return nil
+	}
+
+	denied := line.StatusCode == "4"
+	processed := line.StatusCode == "1" || line.StatusCode == "2" || line.StatusCode == "3"
+	switch {
+	case !denied && !processed:
+		line.Outcome = "rejected"
+		line.Message = "unsupported claim status code " + line.StatusCode
+		return nil
+	case claim.Status == "draft" || claim.Status == "paid":
+		line.Outcome = "rejected"
+		line.Message = "claim is " + claim.Status
+		return nil
+	}
+
+	now := time.Now()
+	updates := map[string]any{"adjudicated_at": now}
+	if line.PayerClaimID != "" {
+		updates["payer_claim_id"] = line.PayerClaimID
+	}
+	line.Outcome = "matched"
+
+	if denied {
+		reason := "Denied by payer"
+		if line.AdjustmentCode != "" {
+			reason += " (" + line.AdjustmentCode + ")"
+		}
+		updates["status"] = "denied"
+		updates["denial_reason"] = line.AdjustmentCode
+		updates["needs_follow_up"] = true
+		updates["follow_up_reason"] = reason
+		line.Flag = "denied"
+		line.Message = reason
+	} else {
+		paidAt := now
+		if line.PaidDate != nil {
+			paidAt = line.PaidDate.Time
+		}
+		claim.AmountPaid += line.Paid
+		updates["status"] = "paid"
+		updates["amount_paid"] = claim.AmountPaid
+		updates["patient_responsibility"] = line.PatientResponsibility
+		updates["paid_at"] = paidAt
+
+		var followUp []string
+		if expected := claim.TotalCharged - line.PatientResponsibility; claim.AmountPaid < expected {
+			line.Flag = "underpaid"
+			followUp = append(followUp, fmt.Sprintf("Underpaid by %s", formatAmount(expected-claim.AmountPaid)))
+		}
+		if line.Paid > 0 {
+			note, err := postRemittancePayment(tx, userID, &claim, line, paidAt)
+			if err != nil {
+				return err
+			}
+			if note != "" {
+				followUp = append(followUp, note)
+			}
+		}
+		if len(followUp) > 0 {
+			updates["needs_follow_up"] = true
+			updates["follow_up_reason"] = strings.Join(followUp, "; ")
+			line.Message = strings.Join(followUp, "; ")
+		}
+	}
+
+	if err := tx.Model(&claim).Updates(updates).Error; err != nil {
+		return err
+	}
+	return recordAudit(tx, userID, "claim."+updates["status"].(string), "claim", claim.ID, map[string]any{
+		"number":       claim.Number,
+		"paid":         line.Paid,
+		"check_number": line.CheckNumber,
+	})
+}
+
+// postRemittancePayment records the insurer's payment on the claim's invoice.
+// It returns a follow-up note instead of an error when the payment cannot be
+// posted in full, so the rest of the remittance still goes through.
+func postRemittancePayment(tx *gorm.DB, userID uuid.UUID, claim *models.Claim, line *models.RemittanceLine, paidAt time.Time) (string, error) {
+	if claim.InvoiceID == nil {
+		// The encounter may have been invoiced after the claim was drafted
+		var invoiceIDs []uuid.UUID
+		lines := tx.Model(&models.ClaimLine{}).Select("charge_id").Where("claim_id = ?", claim.ID)
+		if err := tx.Model(&models.Charge{}).Distinct("invoice_id").
+			Where("id IN (?) AND invoice_id IS NOT NULL", lines).Pluck("invoice_id", &invoiceIDs).Error; err != nil {
+			return "", err
+		}
+		if len(invoiceIDs) != 1 {
+			return "Payment of " + formatAmount(line.Paid) + " not posted: claim has no single invoice", nil
+		}
+		claim.InvoiceID = &invoiceIDs[0]
+		if err := tx.Model(claim).Update("invoice_id", invoiceIDs[0]).Error; err != nil {
+			return "", err
+		}
+	}
+	var invoice models.Invoice
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&invoice, "id = ?", *claim.InvoiceID).Error; err != nil {
+		return "", err
+	}
+	if invoice.Status == "void" {
+		return "Payment of " + formatAmount(line.Paid) + " not posted: invoice " + invoice.Number + " is void", nil
+	}
+
+	amount := min(line.Paid, invoice.Balance())
+	note := ""
+	if amount < line.Paid {
+		note = fmt.Sprintf("Payment exceeds the balance of invoice %s; %s not posted", invoice.Number, formatAmount(line.Paid-amount))
+	}
+	if amount == 0 {
+		return note, nil
+	}
+	payment := models.Payment{
+		Method:            "insurance",
+		Amount:            amount,
+		Reference:         line.CheckNumber,
+		InsurancePolicyID: &claim.InsurancePolicyID,
+		ReceivedAt:        paidAt,
+	}
+	if err := applyPayment(tx, userID, invoice.ID, &payment); err != nil {
+		return "", err
+	}
+	return note, nil
+}
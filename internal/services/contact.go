@@ -0,0 +1,162 @@
+package services
+
+import (
+	"errors"
+	"net/mail"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"hospital/internal/models"
+)
+
+// NotificationRecipient is a person a patient notification can be sent to.
+type NotificationRecipient struct {
+	Name      string     `json:"name"`
+	Phone     string     `json:"phone,omitempty"`
+	Email     string     `json:"email,omitempty"`
+	ContactID *uuid.UUID `json:"contact_id,omitempty"` // Empty for the patient
+}
+
+func (s *ReceptionistService) GetContacts(patientID uuid.UUID) ([]models.PatientContact, error) {
+	if err := s.db.Conn.Select("id").First(&models.Patient{}, "id = ?", patientID).Error; err != nil {
+		return nil, err
+	}
+
+	var contacts []models.PatientContact
+	if err := s.db.Conn.Where("patient_id = ?", patientID).
+		Order("priority, created_at").Find(&contacts).Error; err != nil {
+		return nil, err
+	}
+	return contacts, nil
+}
+
+func (s *ReceptionistService) CreateContact(patientID uuid.UUID, contact *models.PatientContact) error {
+	if err := s.db.Conn.Select("id").First(&models.Patient{}, "id = ?", patientID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("patient not found")
+		}
+		return err
+	}
+	if err := normalizeContact(contact); err != nil {
+		return err
+	}
+
+	contact.ID = uuid.Nil
+	contact.PatientID = patientID
+	return s.db.Conn.Create(contact).Error
+}
+
+func (s *ReceptionistService) UpdateContact(patientID, contactID uuid.UUID, update *models.PatientContact) (*models.PatientContact, error) {
+	var contact models.PatientContact
+	if err := s.db.Conn.First(&contact, "id = ? AND patient_id = ?", contactID, patientID).Error; err != nil {
+		return nil, err
+	}
+	if err := normalizeContact(update); err != nil {
+		return nil, err
+	}
+
+	contact.Name = update.Name
+	contact.Relationship = update.Relationship
+	contact.Phone = update.Phone
+	contact.Email = update.Email
+	contact.Priority = update.Priority
+	contact.MayReceiveClinicalInfo = update.MayReceiveClinicalInfo
+	contact.LegalGuardian = update.LegalGuardian
+	contact.Notes = update.Notes
+	if err := s.db.Conn.Save(&contact).Error; err != nil {
+		return nil, err
+	}
+	return &contact, nil
+}
+
+func (s *ReceptionistService) DeleteContact(patientID, contactID uuid.UUID) error {
+	result := s.db.Conn.Where("id = ? AND patient_id = ?", contactID, patientID).Delete(&models.PatientContact{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// NotificationRecipients returns who a notification about the patient goes
+// to: the patient, followed by contacts in priority order. Notifications with
+// clinical content only go to contacts who may receive clinical information
+// and to legal guardians. Others go to every contact.
+func NotificationRecipients(db *gorm.DB, patientID uuid.UUID, clinical bool) ([]NotificationRecipient, error) {
+	var patient models.Patient
+	if err := db.Scopes(preloadContacts).First(&patient, "id = ?", patientID).Error; err != nil {
+		return nil, err
+	}
+	return notificationRecipients(&patient, clinical), nil
+}
+
+// notificationRecipients applies the contact flags to a patient whose
+// contacts are loaded in priority order.
+func notificationRecipients(patient *models.Patient, clinical bool) []NotificationRecipient {
+	recipients := []NotificationRecipient{{Name: patient.Name, Phone: patient.Phone, Email: patient.Email}}
+	for i := range patient.Contacts {
+		contact := &patient.Contacts[i]
+		if clinical && !contact.MayReceiveClinicalInfo && !contact.LegalGuardian {
+			continue
+		}
+		recipients = append(recipients, NotificationRecipient{
+			Name:      contact.Name,
+			Phone:     contact.Phone,
+			Email:     contact.Email,
+			ContactID: &contact.ID,
+		})
+	}
+	return recipients
+}
+
+// preloadClinicalContacts loads the contacts a doctor may share clinical
+// information with, for clinical views of a patient.
+func preloadClinicalContacts(db *gorm.DB) *gorm.DB {
+	return db.Preload("Contacts", func(db *gorm.DB) *gorm.DB {
+		return db.Where("may_receive_clinical_info OR legal_guardian").Order("priority, created_at")
+	})
+}
+
+// preloadContacts loads every contact of a patient in priority order.
+func preloadContacts(db *gorm.DB) *gorm.DB {
+	return db.Preload("Contacts", func(db *gorm.DB) *gorm.DB {
+		return db.Order("priority, created_at")
+	})
+}
+
+func normalizeContact(contact *models.PatientContact) error {
+	contact.Name = strings.TrimSpace(contact.Name)
+	contact.Relationship = strings.ToLower(strings.TrimSpace(contact.Relationship))
+	contact.Phone = strings.TrimSpace(contact.Phone)
+	contact.Email = strings.TrimSpace(contact.Email)
+
+	if contact.Name == "" {
+		return errors.New("name is required")
+	}
+	if !models.ContactRelationships[contact.Relationship] {
+		return errors.New("relationship must be one of spouse, partner, parent, child, sibling, guardian, relative, friend, caregiver or other")
+	}
+	if contact.Phone == "" && contact.Email == "" {
+		return errors.New("phone or email is required")
+	}
+	if contact.Email != "" {
+		if _, err := mail.ParseAddress(contact.Email); err != nil {
+			return errors.New("email is not a valid address")
+		}
+	}
+	if contact.Priority == 0 {
+		contact.Priority = 1
+	}
+	if contact.Priority < 1 {
+		return errors.New("priority must be 1 or greater")
+	}
+	// A legal guardian makes decisions for the patient, so is always told
+	if contact.LegalGuardian {
+		contact.MayReceiveClinicalInfo = true
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+
+	"hospital/internal/models"
+)
+
+func TestNotificationRecipients(t *testing.T) {
+	patient := &models.Patient{
+		Name:  "Ana Silva",
+		Phone: "+351 910 000 000",
+		Contacts: []models.PatientContact{
+			{ID: uuid.New(), Name: "Guardian", LegalGuardian: true},
+			{ID: uuid.New(), Name: "Consenting spouse", MayReceiveClinicalInfo: true},
+			{ID: uuid.New(), Name: "Neighbour"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		clinical bool
+		want     []string
+	}{
+		{"non-clinical goes to every contact", false, []string{"Ana Silva", "Guardian", "Consenting spouse", "Neighbour"}},
+		{"clinical skips contacts without consent", true, []string{"Ana Silva", "Guardian", "Consenting spouse"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recipients := notificationRecipients(patient, tt.clinical)
+			if len(recipients) != len(tt.want) {
+				t.Fatalf("got %d recipients, want %d", len(recipients), len(tt.want))
+			}
+			for i, name := range tt.want {
+				if recipients[i].Name != name {
+					t.Errorf("recipient %d = %q, want %q", i, recipients[i].Name, name)
+				}
+			}
+			if recipients[0].ContactID != nil {
+				t.Error("the patient should not have a contact ID")
+			}
+			for _, r := range recipients[1:] {
+				if r.ContactID == nil {
+					t.Errorf("contact %q has no contact ID", r.Name)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"hospital/internal/models"
+)
+
+var diagnosisStatuses = map[string]bool{"active": true, "resolved": true, "chronic": true}
+
+func (s *doctorService) SearchICD10Codes(query string, limit int) ([]models.ICD10Code, error) {
+	var codes []models.ICD10Code
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return codes, nil
+	}
+
+	// Codes are matched by prefix, descriptions by full-text search. Code
+	// matches are listed first since they are the more precise hit.
+	prefix := escapeLike(strings.ToUpper(query)) + "%"
+	err := s.db.Conn.
+		Where("code LIKE ? OR to_tsvector('english', description) @@ plainto_tsquery('english', ?)", prefix, query).
+		Order(clause.OrderBy{Expression: gorm.Expr("CASE WHEN code LIKE ? THEN 0 ELSE 1 END, code", prefix)}).
+		Limit(limit).
+		Find(&codes).Error
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (s *doctorService) CreateDiagnosis(doctorID, patientID uuid.UUID, diagnosis *models.Diagnosis) error {
+	if _, err := s.findPatientForDoctor(doctorID, patientID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("patient not found")
+		}
+		return err
+	}
+
+	diagnosis.Code = strings.ToUpper(strings.TrimSpace(diagnosis.Code))
+	var code models.ICD10Code
+	if err := s.db.Conn.First(&code, "code = ?", diagnosis.Code).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("unknown ICD-10 code")
+		}
+		return err
+	}
+
+	if diagnosis.Status == "" {
+		diagnosis.Status = "active"
+	}
+	if !diagnosisStatuses[diagnosis.Status] {
+		return errors.New("status must be one of active, resolved or chronic")
+	}
+
+	if diagnosis.AppointmentID != nil {
+		var appointment models.Appointment
+		if err := s.db.Conn.Where("id = ? AND patient_id = ?", *diagnosis.AppointmentID, patientID).
+			First(&appointment).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("appointment not found for this patient")
+			}
+			return err
+		}
+	}
+
+	diagnosis.ID = uuid.Nil
+	diagnosis.PatientID = patientID
+	diagnosis.DoctorID = doctorID
+	if diagnosis.Status == "resolved" && diagnosis.ResolvedAt == nil {
+		now := time.Now()
+		diagnosis.ResolvedAt = &now
+	}
+	if err := s.db.Conn.Create(diagnosis).Error; err != nil {
+		return err
+	}
+	diagnosis.ICD10 = code
+	return nil
+}
+
+func (s *doctorService) GetDiagnosesByPatient(doctorID, patientID uuid.UUID, status string) ([]models.Diagnosis, error) {
+	if _, err := s.findPatientForDoctor(doctorID, patientID); err != nil {
+		return nil, err
+	}
+
+	query := s.db.Conn.Preload("ICD10").Where("patient_id = ?", patientID)
+	switch status {
+	case "":
+	case "problem":
+		// The problem list is everything that still needs attention
+		query = query.Where("status IN ?", []string{"active", "chronic"})
+	default:
+		if !diagnosisStatuses[status] {
+			return nil, errors.New("status must be one of active, resolved, chronic or problem")
+		}
+		query = query.Where("status = ?", status)
+	}
+
+	var diagnoses []models.Diagnosis
+	if err := query.Order("created_at DESC").Find(&diagnoses).Error; err != nil {
+		return nil, err
+	}
+	return diagnoses, nil
+}
+
+func (s *doctorService) UpdateDiagnosis(doctorID, diagnosisID uuid.UUID, status, notes string) (*models.Diagnosis, error) {
+	var diagnosis models.Diagnosis
+	if err := s.db.Conn.First(&diagnosis, "id = ?", diagnosisID).Error; err != nil {
+		return nil, err
+	}
+	if _, err := s.findPatientForDoctor(doctorID, diagnosis.PatientID); err != nil {
+		return nil, err
+	}
+
+	if status != "" {
+		if !diagnosisStatuses[status] {
+			return nil, errors.New("status must be one of active, resolved or chronic")
+		}
+		if status == "resolved" && diagnosis.Status != "resolved" {
+			now := time.Now()
+			diagnosis.ResolvedAt = &now
+		} else if status != "resolved" {
+			diagnosis.ResolvedAt = nil
+		}
+		diagnosis.Status = status
+	}
+	if notes != "" {
+		diagnosis.Notes = notes
+	}
+
+	if err := s.db.Conn.Save(&diagnosis).Error; err != nil {
+		return nil, err
+	}
+	if err := s.db.Conn.Preload("ICD10").First(&diagnosis, "id = ?", diagnosis.ID).Error; err != nil {
+		return nil, err
+	}
+	return &diagnosis, nil
+}
+
+// escapeLike escapes the LIKE wildcards in user input.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
@@ -0,0 +1,342 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"hospital/internal/config"
+	"hospital/internal/database"
+	"hospital/internal/models"
+)
+
+// DischargeSummaryService drafts, edits and signs the discharge summaries of
+// admissions. Only the attending doctor may change a summary; it does no
+// other access checks, so callers authorize reads themselves.
+type DischargeSummaryService interface {
+	CreateDischargeSummary(doctorID, admissionID uuid.UUID) (*models.DischargeSummary, error)
+	GetDischargeSummary(admissionID uuid.UUID) (*models.DischargeSummary, error)
+	UpdateDischargeSummary(doctorID, admissionID uuid.UUID, update *models.DischargeSummary) (*models.DischargeSummary, error)
+	SignDischargeSummary(doctorID, admissionID uuid.UUID) (*models.DischargeSummary, error)
+}
+
+type dischargeSummaryService struct {
+	db  *database.DB
+	cfg config.Config
+}
+
+func NewDischargeSummaryService(db *database.DB, cfg config.Config) DischargeSummaryService {
+	return &dischargeSummaryService{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// CreateDischargeSummary drafts the summary of a discharged admission from
+// what was recorded for the patient during the stay.
+func (s *dischargeSummaryService) CreateDischargeSummary(doctorID, admissionID uuid.UUID) (*models.DischargeSummary, error) {
+	var summary *models.DischargeSummary
+	err := s.db.Conn.Transaction(func(tx *gorm.DB) error {
+		var admission models.Admission
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&admission, "id = ?", admissionID).Error; err != nil {
+			return err
+		}
+		if admission.AttendingDoctorID != doctorID {
+			return gorm.ErrRecordNotFound
+		}
+		if admission.Status != "discharged" {
+			return errors.New("the patient must be discharged before the summary is written")
+		}
+		var existing int64
+		if err := tx.Model(&models.DischargeSummary{}).Where("admission_id = ?", admissionID).Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return errors.New("a discharge summary already exists for this admission")
+		}
+
+		draft, err := draftDischargeSummary(tx, &admission)
+		if err != nil {
+			return err
+		}
+		if err := tx.Omit(clause.Associations).Create(draft).Error; err != nil {
+			return err
+		}
+		if err := recordAudit(tx, doctorID, "discharge_summary.created", "discharge_summary", draft.ID, map[string]any{
+			"admission_id": admissionID,
+		}); err != nil {
+			return err
+		}
+		summary, err = loadDischargeSummary(tx, admissionID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// draftDischargeSummary collects the records of the stay. Prescriptions are
+// not linked to admissions, so the ones written during the stay that had
+// ended by discharge count as given in hospital and the rest as discharge
+// medications.
+func draftDischargeSummary(tx *gorm.DB, admission *models.Admission) (*models.DischargeSummary, error) {
+	start, end := admission.AdmittedAt, *admission.DischargedAt
+	summary := models.DischargeSummary{
+		AdmissionID:          admission.ID,
+		PatientID:            admission.PatientID,
+		DoctorID:             admission.AttendingDoctorID,
+		Status:               "draft",
+		AdmissionReason:      admission.Reason,
+		Instructions:         admission.DischargeNotes,
+		Diagnoses:            []models.SummaryDiagnosis{},
+		Procedures:           []models.SummaryProcedure{},
+		MedicationsGiven:     []models.SummaryMedication{},
+		DischargeMedications: []models.SummaryMedication{},
+		FollowUps:            []models.SummaryFollowUp{},
+	}
+
+	var diagnoses []models.Diagnosis
+	if err := tx.Preload("ICD10").Where("patient_id = ? AND created_at >= ? AND created_at <= ?", admission.PatientID, start, end).
+		Order("created_at").Find(&diagnoses).Error; err != nil {
+		return nil, err
+	}
+	for _, diagnosis := range diagnoses {
+		summary.Diagnoses = append(summary.Diagnoses, models.SummaryDiagnosis{
+			Code:        diagnosis.Code,
+			Description: diagnosis.ICD10.Description,
+			Status:      diagnosis.Status,
+		})
+	}
+
+	var charges []models.Charge
+	if err := tx.Where("patient_id = ? AND procedure_code <> '' AND created_at >= ? AND created_at <= ?", admission.PatientID, start, end).
+		Order("created_at").Find(&charges).Error; err != nil {
+		return nil, err
+	}
+	for _, charge := range charges {
+		performedAt := charge.CreatedAt
+		summary.Procedures = append(summary.Procedures, models.SummaryProcedure{
+			Code:        charge.ProcedureCode,
+			Description: charge.Description,
+			PerformedAt: &performedAt,
+		})
+	}
+
+	var prescriptions []models.Prescription
+	if err := tx.Where("patient_id = ? AND created_at >= ? AND created_at <= ?", admission.PatientID, start, end).
+		Order("created_at").Find(&prescriptions).Error; err != nil {
+		return nil, err
+	}
+	for _, prescription := range prescriptions {
+		medication := models.SummaryMedication{
+			PrescriptionID: &prescription.ID,
+			Medication:     prescription.Medication,
+			Dosage:         prescription.Dosage,
+			Instructions:   prescription.Instructions,
+		}
+		if prescription.EndedAt != nil && !prescription.EndedAt.After(end) {
+			summary.MedicationsGiven = append(summary.MedicationsGiven, medication)
+		} else if prescription.Status == "active" {
+			summary.DischargeMedications = append(summary.DischargeMedications, medication)
+		}
+	}
+
+	var appointments []models.Appointment
+	if err := tx.Where("patient_id = ? AND status = ? AND appointment_date > ? AND created_at >= ?",
+		admission.PatientID, "scheduled", end, start).Order("appointment_date").Find(&appointments).Error; err != nil {
+		return nil, err
+	}
+	for _, appointment := range appointments {
+		summary.FollowUps = append(summary.FollowUps, models.SummaryFollowUp{
+			DoctorID:      appointment.DoctorID,
+			Date:          appointment.AppointmentDate,
+			Type:          appointment.Type,
+			Notes:         appointment.Notes,
+			AppointmentID: &appointment.ID,
+		})
+	}
+	return &summary, nil
+}
+
+func (s *dischargeSummaryService) GetDischargeSummary(admissionID uuid.UUID) (*models.DischargeSummary, error) {
+	return loadDischargeSummary(s.db.Conn, admissionID)
+}
+
+func loadDischargeSummary(tx *gorm.DB, admissionID uuid.UUID) (*models.DischargeSummary, error) {
+	var summary models.DischargeSummary
+	if err := tx.Preload("Admission.Bed.Ward").Preload("Patient").Preload("Doctor").
+		First(&summary, "admission_id = ?", admissionID).Error; err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+// UpdateDischargeSummary replaces the content of a draft summary.
+func (s *dischargeSummaryService) UpdateDischargeSummary(doctorID, admissionID uuid.UUID, update *models.DischargeSummary) (*models.DischargeSummary, error) {
+	var summary *models.DischargeSummary
+	err := s.db.Conn.Transaction(func(tx *gorm.DB) error {
+		draft, err := lockDraftSummary(tx, doctorID, admissionID)
+		if err != nil {
+			return err
+		}
+		if err := validateFollowUps(tx, draft.PatientID, update.FollowUps); err != nil {
+			return err
+		}
+
+		// The lists are serialized to JSON, which only happens when updating
+		// from the struct
+		draft.AdmissionReason = strings.TrimSpace(update.AdmissionReason)
+		draft.HospitalCourse = strings.TrimSpace(update.HospitalCourse)
+		draft.DischargeCondition = strings.TrimSpace(update.DischargeCondition)
+		draft.Instructions = strings.TrimSpace(update.Instructions)
+		draft.Diagnoses = nonNil(update.Diagnoses)
+		draft.Procedures = nonNil(update.Procedures)
+		draft.MedicationsGiven = nonNil(update.MedicationsGiven)
+		draft.DischargeMedications = nonNil(update.DischargeMedications)
+		draft.FollowUps = nonNil(update.FollowUps)
+		if err := tx.Model(draft).Select("admission_reason", "hospital_course", "discharge_condition", "instructions",
+			"diagnoses", "procedures", "medications_given", "discharge_medications", "follow_ups").
+			Updates(draft).Error; err != nil {
+			return err
+		}
+		if err := recordAudit(tx, doctorID, "discharge_summary.updated", "discharge_summary", draft.ID, nil); err != nil {
+			return err
+		}
+		summary, err = loadDischargeSummary(tx, admissionID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// SignDischargeSummary signs the summary and books the follow-up appointments
+// that are not booked yet. The summary cannot be changed afterwards.
+func (s *dischargeSummaryService) SignDischargeSummary(doctorID, admissionID uuid.UUID) (*models.DischargeSummary, error) {
+	var summary *models.DischargeSummary
+	err := s.db.Conn.Transaction(func(tx *gorm.DB) error {
+		draft, err := lockDraftSummary(tx, doctorID, admissionID)
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(draft.HospitalCourse) == "" {
+			return errors.New("hospital_course is required before signing")
+		}
+		if err := validateFollowUps(tx, draft.PatientID, draft.FollowUps); err != nil {
+			return err
+		}
+
+		var booked []uuid.UUID
+		for i, followUp := range draft.FollowUps {
+			if followUp.AppointmentID != nil {
+				continue
+			}
+			notes := "Follow-up after discharge"
+			if followUp.Notes != "" {
+				notes += ": " + followUp.Notes
+			}
+			appointment := models.Appointment{
+				PatientID:       draft.PatientID,
+				DoctorID:        followUp.DoctorID,
+				AppointmentDate: followUp.Date,
+				Status:          "scheduled",
+				Type:            followUp.Type,
+				Notes:           notes,
+			}
+			if err := bookAppointment(tx, &appointment); err != nil {
+				return fmt.Errorf("follow-up on %s: %w", followUp.Date.Format(time.RFC3339), err)
+			}
+			draft.FollowUps[i].AppointmentID = &appointment.ID
+			booked = append(booked, appointment.ID)
+		}
+
+		signedAt := time.Now()
+		draft.Status = "signed"
+		draft.SignedAt = &signedAt
+		draft.FollowUps = nonNil(draft.FollowUps)
+		if err := tx.Model(draft).Select("status", "signed_at", "follow_ups").Updates(draft).Error; err != nil {
+			return err
+		}
+		if err := recordAudit(tx, doctorID, "discharge_summary.signed", "discharge_summary", draft.ID, map[string]any{
+			"appointment_ids": booked,
+		}); err != nil {
+			return err
+		}
+		summary, err = loadDischargeSummary(tx, admissionID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// lockDraftSummary locks the admission's summary for a change by its author.
+func lockDraftSummary(tx *gorm.DB, doctorID, admissionID uuid.UUID) (*models.DischargeSummary, error) {
+	var summary models.DischargeSummary
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&summary, "admission_id = ?", admissionID).Error; err != nil {
+		return nil, err
+	}
+	if summary.DoctorID != doctorID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if summary.Status != "draft" {
+		return nil, errors.New("a signed discharge summary cannot be changed")
+	}
+	return &summary, nil
+}
+
+// validateFollowUps checks follow-ups still to be booked and fills in the
+// details of the ones that are, from their appointments.
+func validateFollowUps(tx *gorm.DB, patientID uuid.UUID, followUps []models.SummaryFollowUp) error {
+	now := time.Now()
+	for i := range followUps {
+		followUp := &followUps[i]
+		if followUp.AppointmentID != nil {
+			var appointment models.Appointment
+			if err := tx.First(&appointment, "id = ? AND patient_id = ?", *followUp.AppointmentID, patientID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return errors.New("follow-up appointment not found")
+				}
+				return err
+			}
+			followUp.DoctorID = appointment.DoctorID
+			followUp.Date = appointment.AppointmentDate
+			followUp.Type = appointment.Type
+			continue
+		}
+
+		if followUp.Type == "" {
+			followUp.Type = "follow_up"
+		}
+		if !models.AppointmentTypes[followUp.Type] {
+			return errors.New("follow-up type must be one of consultation, follow_up, procedure or emergency")
+		}
+		if !followUp.Date.After(now) {
+			return errors.New("follow-up dates must be in the future")
+		}
+		followUp.Notes = strings.TrimSpace(followUp.Notes)
+		if err := tx.Select("id").First(&models.User{}, "id = ? AND role = ?", followUp.DoctorID, "doctor").Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("follow-up doctor not found")
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// nonNil stores empty lists as [] rather than null.
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
@@ -2,7 +2,6 @@ package services
 
 import (
 	"errors"
-	"fmt"
 	"hospital/internal/config"
 	"hospital/internal/database"
 	"hospital/internal/models"
@@ -10,16 +9,61 @@ import (
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type DoctorService interface {
 	GetPatients(doctorID uuid.UUID) ([]models.Patient, error)
 	CreatePrescription(patientID uuid.UUID, prescription *models.Prescription) error
-	UpdatePrescription(patientID uuid.UUID, prescription *models.Prescription) error
+	UpdatePrescription(doctorID, prescriptionID uuid.UUID, prescription *models.Prescription) (*models.Prescription, error)
+	GetPrescription(doctorID, prescriptionID uuid.UUID) (*models.Prescription, error)
+	DiscontinuePrescription(doctorID, prescriptionID uuid.UUID, reason string) (*models.Prescription, error)
+	CompletePrescription(doctorID, prescriptionID uuid.UUID) (*models.Prescription, error)
+	GetPrescriptionVersions(doctorID, prescriptionID uuid.UUID) ([]models.PrescriptionVersion, error)
 	GetAppointments(doctorID uuid.UUID) ([]models.Appointment, error)
 	GetAppointmentsByDate(doctorID uuid.UUID, date time.Time) ([]models.Appointment, error)
+	GetAppointment(doctorID, appointmentID uuid.UUID) (*models.Appointment, error)
 	GetPatientByID(doctorID, patientID uuid.UUID) (*models.Patient, error)
+	GetPatientByMRN(doctorID uuid.UUID, mrn string) (*models.Patient, error)
+	AuthorizePatient(doctorID, patientID uuid.UUID) error
 	GetPrescriptionsByPatient(doctorID, patientID uuid.UUID) ([]models.Prescription, error)
+
+	// Diagnosis operations
+	SearchICD10Codes(query string, limit int) ([]models.ICD10Code, error)
+	CreateDiagnosis(doctorID, patientID uuid.UUID, diagnosis *models.Diagnosis) error
+	GetDiagnosesByPatient(doctorID, patientID uuid.UUID, status string) ([]models.Diagnosis, error)
+	UpdateDiagnosis(doctorID, diagnosisID uuid.UUID, status, notes string) (*models.Diagnosis, error)
+
+	// Allergy operations
+	CreateAllergy(doctorID, patientID uuid.UUID, allergy *models.PatientAllergy) error
+	GetAllergiesByPatient(doctorID, patientID uuid.UUID) ([]models.PatientAllergy, error)
+	UpdateAllergy(doctorID, allergyID uuid.UUID, allergy *models.PatientAllergy) (*models.PatientAllergy, error)
+
+	// Formulary operations
+	SearchFormulary(query string, limit int) ([]models.FormularyItem, error)
+	CheckPrescription(doctorID, patientID uuid.UUID, prescription *models.Prescription) (*PrescriptionCheck, error)
+
+	// Refill operations
+	GetPendingRefills(doctorID uuid.UUID) ([]models.RefillRequest, error)
+	ApproveRefill(doctorID, refillID uuid.UUID) (*models.RefillRequest, error)
+	DenyRefill(doctorID, refillID uuid.UUID, reason string) (*models.RefillRequest, error)
+
+	// Lab operations
+	SearchLabTests(query string, limit int) ([]models.LabTest, error)
+	CreateLabOrder(doctorID, patientID uuid.UUID, order *models.LabOrder) error
+	GetLabOrdersByPatient(doctorID, patientID uuid.UUID, status string) ([]models.LabOrder, error)
+	GetUnreviewedLabResults(doctorID uuid.UUID) ([]models.LabOrder, error)
+	ReviewLabOrder(doctorID, orderID uuid.UUID) (*models.LabOrder, error)
+
+	// Referral operations
+	CreateReferral(doctorID, patientID uuid.UUID, referral *models.Referral, documentIDs []uuid.UUID) error
+	GetReferrals(doctorID uuid.UUID, direction, status string) ([]models.Referral, error)
+	GetReferral(doctorID, referralID uuid.UUID) (*models.Referral, error)
+	AcceptReferral(doctorID, referralID uuid.UUID) (*models.Referral, error)
+	DeclineReferral(doctorID, referralID uuid.UUID, reason string) (*models.Referral, error)
+	CancelReferral(doctorID, referralID uuid.UUID) (*models.Referral, error)
+	CompleteReferral(doctorID, referralID uuid.UUID) (*models.Referral, error)
+	BookReferralAppointment(doctorID, referralID uuid.UUID, date time.Time, notes string) (*models.Appointment, error)
 }
 
 type doctorService struct {
@@ -36,7 +80,7 @@ func NewDoctorService(db *database.DB, cfg config.Config) DoctorService {
 
 func (s *doctorService) GetPatients(doctorID uuid.UUID) ([]models.Patient, error) {
 	var patients []models.Patient
-	err := s.db.Conn.Where("user_id = ?", doctorID).Find(&patients).Error
+	err := s.db.Conn.Scopes(doctorPatients(doctorID)).Find(&patients).Error
 	if err != nil {
 		return nil, err
 	}
@@ -44,60 +88,42 @@ func (s *doctorService) GetPatients(doctorID uuid.UUID) ([]models.Patient, error
 }
 
 func (s *doctorService) CreatePrescription(patientID uuid.UUID, prescription *models.Prescription) error {
-	// Verify patient exists
-	var patient models.Patient
-	if err := s.db.Conn.First(&patient, "id = ?", patientID).Error; err != nil {
+	if _, err := s.findPatientForDoctor(prescription.DoctorID, patientID); err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return errors.New("patient not found")
 		}
 		return err
 	}
 	prescription.PatientID = patientID
-	return s.db.Conn.Create(prescription).Error
-}
 
-// func (s *doctorService) UpdatePrescription(patientID uuid.UUID, prescription *models.Prescription) error {
-// 	// Verify patient exists
-// 	var patient models.Patient
-// 	if err := s.db.Conn.First(&patient, "id = ?", patientID).Error; err != nil {
-// 		if errors.Is(err, gorm.ErrRecordNotFound) {
-// 			return errors.New("patient not found")
-// 		}
-// 		return err
-// 	}
-
-// 	return s.db.Conn.Model(prescription).Where("patient_id = ?", patientID).Updates(prescription).Error
-// }
-
-func (s *doctorService) UpdatePrescription(patientID uuid.UUID, prescription *models.Prescription) error {
-	// Verify patient exists
-	var patient models.Patient
-	if err := s.db.Conn.First(&patient, "id = ?", patientID).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("patient not found")
+	return s.db.Conn.Transaction(func(tx *gorm.DB) error {
+		if err := s.preparePrescription(tx, prescription); err != nil {
+			return err
 		}
-		return err
-	}
-	fmt.Println("this is the patient at service line 79", patient)
-	// Update prescription directly by conditions without using Model()
-	result := s.db.Conn.Model(&models.Prescription{}).
-		Where("patient_id = ? AND doctor_id = ?", patientID, prescription.DoctorID).
-		Updates(map[string]interface{}{
-			"medication":   prescription.Medication,
-			"dosage":       prescription.Dosage,
-			"instructions": prescription.Instructions,
-		})
-	fmt.Println("this is the result at service line 88", result)
-	if result.Error != nil {
-		return result.Error
-	}
-
-	// Check if any rows were affected
-	if result.RowsAffected == 0 {
-		return errors.New("prescription not found for this patient and doctor")
-	}
-
-	return nil
+		check, err := s.checkPrescriptionSafety(tx, prescription, uuid.Nil)
+		if err != nil {
+			return err
+		}
+		code, err := newVerificationCode()
+		if err != nil {
+			return err
+		}
+		prescription.ID = uuid.Nil
+		prescription.Status = "active"
+		prescription.Version = 1
+		prescription.VerificationCode = code
+		prescription.DispensedAt = nil
+		prescription.DispensedBy = nil
+		prescription.RefillOfID = nil
+		prescription.RefillNumber = 0
+		if err := tx.Omit(clause.Associations).Create(prescription).Error; err != nil {
+			return err
+		}
+		if err := recordPrescriptionVersion(tx, prescription, "created", prescription.DoctorID); err != nil {
+			return err
+		}
+		return auditOverrides(tx, prescription, check)
+	})
 }
 
 func (s *doctorService) GetAppointments(doctorID uuid.UUID) ([]models.Appointment, error) {
@@ -122,18 +148,88 @@ func (s *doctorService) GetAppointmentsByDate(doctorID uuid.UUID, date time.Time
 	return appointments, nil
 }
 
+// GetAppointment returns an appointment with the doctor or with one of the
+// doctor's patients.
+func (s *doctorService) GetAppointment(doctorID, appointmentID uuid.UUID) (*models.Appointment, error) {
+	var appointment models.Appointment
+	if err := s.db.Conn.Preload("Patient").First(&appointment, "id = ?", appointmentID).Error; err != nil {
+		return nil, err
+	}
+	if appointment.DoctorID != doctorID {
+		if _, err := s.findPatientForDoctor(doctorID, appointment.PatientID); err != nil {
+			return nil, err
+		}
+	}
+	return &appointment, nil
+}
+
 func (s *doctorService) GetPatientByID(doctorID, patientID uuid.UUID) (*models.Patient, error) {
 	var patient models.Patient
-	if err := s.db.Conn.Where("user_id = ? AND id = ?", doctorID, patientID).First(&patient).Error; err != nil {
+	if err := s.db.Conn.Scopes(doctorPatients(doctorID)).Where("patients.id = ?", patientID).
+		Preload("Diagnoses", func(db *gorm.DB) *gorm.DB { return db.Order("created_at DESC") }).
+		Preload("Diagnoses.ICD10").
+		Preload("Allergies", "status = ?", "active").
+		Scopes(preloadCareTeam, preloadClinicalContacts).
+		First(&patient).Error; err != nil {
+		return nil, err
+	}
+	return &patient, nil
+}
+
+// GetPatientByMRN looks up one of the doctor's patients by medical record number.
+func (s *doctorService) GetPatientByMRN(doctorID uuid.UUID, mrn string) (*models.Patient, error) {
+	var patient models.Patient
+	if err := s.db.Conn.Scopes(doctorPatients(doctorID)).Select("id").
+		Where("mrn = ?", normalizeMRN(mrn)).First(&patient).Error; err != nil {
+		return nil, err
+	}
+	return s.GetPatientByID(doctorID, patient.ID)
+}
+
+// AuthorizePatient reports whether the doctor may access the patient's records,
+// for services that leave access checks to their callers. It returns
+// gorm.ErrRecordNotFound when access is denied.
+func (s *doctorService) AuthorizePatient(doctorID, patientID uuid.UUID) error {
+	_, err := s.findPatientForDoctor(doctorID, patientID)
+	return err
+}
+
+// findPatientForDoctor loads a patient the doctor is allowed to access. It
+// returns gorm.ErrRecordNotFound when the patient does not exist or belongs to
+// another doctor, so callers can treat both cases as not found.
+func (s *doctorService) findPatientForDoctor(doctorID, patientID uuid.UUID) (*models.Patient, error) {
+	var patient models.Patient
+	if err := s.db.Conn.Scopes(doctorPatients(doctorID)).Where("patients.id = ?", patientID).First(&patient).Error; err != nil {
 		return nil, err
 	}
 	return &patient, nil
 }
 
+// GetPrescriptionsByPatient returns the prescriptions of every doctor for a
+// patient the requesting doctor has access to.
 func (s *doctorService) GetPrescriptionsByPatient(doctorID, patientID uuid.UUID) ([]models.Prescription, error) {
+	if _, err := s.findPatientForDoctor(doctorID, patientID); err != nil {
+		return nil, err
+	}
+
 	var prescriptions []models.Prescription
-	if err := s.db.Conn.Where("doctor_id = ? AND patient_id = ?", doctorID, patientID).Find(&prescriptions).Error; err != nil {
+	if err := s.db.Conn.Preload("Doctor").Where("patient_id = ?", patientID).
+		Order("created_at DESC").Find(&prescriptions).Error; err != nil {
 		return nil, err
 	}
 	return prescriptions, nil
 }
+
+// doctorPatients limits a patient query to the patients a doctor may access:
+// those with a care-team membership for the doctor in effect now, and those
+// referred to the doctor through an accepted referral.
+func doctorPatients(doctorID uuid.UUID) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		now := time.Now()
+		members := db.Session(&gorm.Session{NewDB: true}).Model(&models.CareTeamMember{}).Select("patient_id").
+			Where("doctor_id = ? AND effective_from <= ? AND (effective_to IS NULL OR effective_to > ?)", doctorID, now, now)
+		referred := db.Session(&gorm.Session{NewDB: true}).Model(&models.Referral{}).Select("patient_id").
+			Where("target_doctor_id = ? AND status IN ?", doctorID, []string{"accepted", "completed"})
+		return db.Where("patients.id IN (?) OR patients.id IN (?)", members, referred)
+	}
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"bytes"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"hospital/internal/config"
+	"hospital/internal/database"
+	"hospital/internal/documents"
+	"hospital/internal/models"
+)
+
+// DocumentService renders printable documents. It does no access checks of its
+// own; callers authorize the request against the role specific services first.
+type DocumentService interface {
+	PrescriptionPDF(prescriptionID uuid.UUID) ([]byte, error)
+	EncounterSummaryPDF(appointmentID uuid.UUID) ([]byte, error)
+	DischargeSummaryPDF(admissionID uuid.UUID) ([]byte, error)
+}
+
+type documentService struct {
+	db  *database.DB
+	cfg config.Config
+}
+
+func NewDocumentService(db *database.DB, cfg config.Config) DocumentService {
+	return &documentService{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// PrescriptionPDF prints the prescription together with the rest of the set it
+// was issued in, saving the set the first time it is printed.
+func (s *documentService) PrescriptionPDF(prescriptionID uuid.UUID) ([]byte, error) {
+	var prescription models.Prescription
+	if err := s.db.Conn.Preload("Patient").Preload("Doctor").
+		First(&prescription, "id = ?", prescriptionID).Error; err != nil {
+		return nil, err
+	}
+
+	var set []models.Prescription
+	err := s.db.Conn.Transaction(func(tx *gorm.DB) error {
+		var err error
+		set, err = printPrescriptionSet(tx, &prescription)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	err = documents.WritePrescription(&buf, documents.PrescriptionDocument{
+		Clinic:           s.cfg.ClinicConfig,
+		Doctor:           prescription.Doctor,
+		Patient:          prescription.Patient,
+		Prescriptions:    set,
+		IssuedAt:         prescription.CreatedAt,
+		VerificationCode: prescription.VerificationCode,
+		VerificationURL:  s.verificationURL(prescription.VerificationCode),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// EncounterSummaryPDF prints the diagnoses and prescriptions recorded for an
+// appointment.
+func (s *documentService) EncounterSummaryPDF(appointmentID uuid.UUID) ([]byte, error) {
+	var appointment models.Appointment
+	if err := s.db.Conn.Preload("Patient").Preload("Doctor").
+		First(&appointment, "id = ?", appointmentID).Error; err != nil {
+		return nil, err
+	}
+
+	var diagnoses []models.Diagnosis
+	if err := s.db.Conn.Preload("ICD10").Where("appointment_id = ?", appointmentID).
+		Order("created_at").Find(&diagnoses).Error; err != nil {
+		return nil, err
+	}
+
+	// Prescriptions are not linked to appointments, so take the ones the doctor
+	// wrote for the patient on the day of the visit.
+	date := appointment.AppointmentDate
+	startOfDay := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	var prescriptions []models.Prescription
+	if err := s.db.Conn.Where("patient_id = ? AND doctor_id = ? AND created_at >= ? AND created_at < ?",
+		appointment.PatientID, appointment.DoctorID, startOfDay, startOfDay.Add(24*time.Hour)).
+		Order("created_at").Find(&prescriptions).Error; err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	err := documents.WriteEncounterSummary(&buf, documents.EncounterSummaryDocument{
+		Clinic:        s.cfg.ClinicConfig,
+		Doctor:        appointment.Doctor,
+		Patient:       appointment.Patient,
+		Appointment:   appointment,
+		Diagnoses:     diagnoses,
+		Prescriptions: prescriptions,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DischargeSummaryPDF prints the discharge summary of an admission.
+func (s *documentService) DischargeSummaryPDF(admissionID uuid.UUID) ([]byte, error) {
+	summary, err := loadDischargeSummary(s.db.Conn, admissionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	err = documents.WriteDischargeSummary(&buf, documents.DischargeSummaryDocument{
+		Clinic:  s.cfg.ClinicConfig,
+		Summary: *summary,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *documentService) verificationURL(code string) string {
+	if s.cfg.ClinicConfig.PublicURL == "" {
+		return ""
+	}
+	return strings.TrimRight(s.cfg.ClinicConfig.PublicURL, "/") + "/verify/prescription/" + code
+}
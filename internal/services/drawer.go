@@ -0,0 +1,202 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"hospital/internal/config"
+	"hospital/internal/database"
+	"hospital/internal/models"
+)
+
+// DrawerService closes receptionists' cash drawers at the end of a shift.
+type DrawerService interface {
+	GetOpenDrawer(receptionistID uuid.UUID) (*DrawerSummary, error)
+	CloseDrawer(receptionistID uuid.UUID, openingFloat, countedCash int64, notes string) (*models.DrawerClose, error)
+	GetDrawerCloses(receptionistID *uuid.UUID, page, limit int) ([]models.DrawerClose, int64, error)
+	GetDrawerClose(closeID uuid.UUID) (*models.DrawerClose, error)
+}
+
+// MethodTotal is the number and sum of payments or refunds by one method.
+type MethodTotal struct {
+	Method string `json:"method"`
+	Count  int    `json:"count"`
+	Amount int64  `json:"amount"`
+}
+
+// DrawerSummary is what a receptionist has taken since their last close.
+type DrawerSummary struct {
+	ReceptionistID uuid.UUID     `json:"receptionist_id"`
+	PeriodStart    *time.Time    `json:"period_start,omitempty"`
+	Payments       []MethodTotal `json:"payments"`
+	Refunds        []MethodTotal `json:"refunds"`
+	// NetCash is cash payments minus cash refunds; the drawer should hold
+	// this plus the opening float
+	NetCash int64 `json:"net_cash"`
+}
+
+type drawerService struct {
+	db  *database.DB
+	cfg config.Config
+}
+
+func NewDrawerService(db *database.DB, cfg config.Config) DrawerService {
+	return &drawerService{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+func (s *drawerService) GetOpenDrawer(receptionistID uuid.UUID) (*DrawerSummary, error) {
+	payments := s.db.Conn.Model(&models.Payment{}).Where("received_by = ? AND drawer_close_id IS NULL", receptionistID)
+	refunds := s.db.Conn.Model(&models.Refund{}).Where("refunded_by = ? AND drawer_close_id IS NULL", receptionistID)
+	summary, err := drawerSummary(payments, refunds)
+	if err != nil {
+		return nil, err
+	}
+	summary.ReceptionistID = receptionistID
+	return summary, nil
+}
+
+// CloseDrawer ends the receptionist's shift. Payments and refunds are claimed
+// for the close before they are totalled, so one recorded while the close runs
+// either makes it into this close or waits for the next one.
+func (s *drawerService) CloseDrawer(receptionistID uuid.UUID, openingFloat, countedCash int64, notes string) (*models.DrawerClose, error) {
+	if openingFloat < 0 || countedCash < 0 {
+		return nil, errors.New("opening_float and counted_cash must not be negative")
+	}
+
+	closing := models.DrawerClose{
+		ReceptionistID: receptionistID,
+		ClosedAt:       time.Now(),
+		OpeningFloat:   openingFloat,
+		CountedCash:    countedCash,
+		Notes:          strings.TrimSpace(notes),
+	}
+	err := s.db.Conn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&closing).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Payment{}).Where("received_by = ? AND drawer_close_id IS NULL", receptionistID).
+			Update("drawer_close_id", closing.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Refund{}).Where("refunded_by = ? AND drawer_close_id IS NULL", receptionistID).
+			Update("drawer_close_id", closing.ID).Error; err != nil {
+			return err
+		}
+
+		summary, err := drawerSummary(
+			tx.Model(&models.Payment{}).Where("drawer_close_id = ?", closing.ID),
+			tx.Model(&models.Refund{}).Where("drawer_close_id = ?", closing.ID),
+		)
+		if err != nil {
+			return err
+		}
+		closing.PeriodStart = summary.PeriodStart
+		for _, total := range summary.Payments {
+			closing.PaymentCount += total.Count
+			switch total.Method {
+			case "cash":
+				closing.CashPayments = total.Amount
+			case "card":
+				closing.CardPayments = total.Amount
+			case "insurance":
+				closing.InsurancePayments = total.Amount
+			}
+		}
+		for _, total := range summary.Refunds {
+			closing.RefundCount += total.Count
+			switch total.Method {
+			case "cash":
+				closing.CashRefunds = total.Amount
+			case "card":
+				closing.CardRefunds = total.Amount
+			case "insurance":
+				closing.InsuranceRefunds = total.Amount
+			}
+		}
+		closing.ExpectedCash = openingFloat + summary.NetCash
+		closing.Variance = countedCash - closing.ExpectedCash
+		if err := tx.Save(&closing).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, receptionistID, "drawer.closed", "drawer_close", closing.ID, map[string]any{
+			"expected_cash": closing.ExpectedCash,
+			"counted_cash":  countedCash,
+			"variance":      closing.Variance,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &closing, nil
+}
+
+func (s *drawerService) GetDrawerCloses(receptionistID *uuid.UUID, page, limit int) ([]models.DrawerClose, int64, error) {
+	query := s.db.Conn.Model(&models.DrawerClose{})
+	if receptionistID != nil {
+		query = query.Where("receptionist_id = ?", *receptionistID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var closes []models.DrawerClose
+	if err := query.Preload("Receptionist").Order("closed_at DESC").
+		Offset((page - 1) * limit).Limit(limit).Find(&closes).Error; err != nil {
+		return nil, 0, err
+	}
+	return closes, total, nil
+}
+
+func (s *drawerService) GetDrawerClose(closeID uuid.UUID) (*models.DrawerClose, error) {
+	var closing models.DrawerClose
+	if err := s.db.Conn.Preload("Receptionist").First(&closing, "id = ?", closeID).Error; err != nil {
+		return nil, err
+	}
+	return &closing, nil
+}
+
+// drawerSummary totals the payments and refunds selected by the two queries
+// by method.
+func drawerSummary(payments, refunds *gorm.DB) (*DrawerSummary, error) {
+	summary := DrawerSummary{Payments: []MethodTotal{}, Refunds: []MethodTotal{}}
+	var firstPayment, firstRefund *time.Time
+	if err := payments.Session(&gorm.Session{}).Select("MIN(received_at)").Scan(&firstPayment).Error; err != nil {
+		return nil, err
+	}
+	if err := refunds.Session(&gorm.Session{}).Select("MIN(refunded_at)").Scan(&firstRefund).Error; err != nil {
+		return nil, err
+	}
+	summary.PeriodStart = firstPayment
+	if firstRefund != nil && (firstPayment == nil || firstRefund.Before(*firstPayment)) {
+		summary.PeriodStart = firstRefund
+	}
+
+	const totals = "method, COUNT(*) AS count, COALESCE(SUM(amount), 0) AS amount"
+	if err := payments.Session(&gorm.Session{}).Select(totals).Group("method").Order("method").
+		Scan(&summary.Payments).Error; err != nil {
+		return nil, err
+	}
+	if err := refunds.Session(&gorm.Session{}).Select(totals).Group("method").Order("method").
+		Scan(&summary.Refunds).Error; err != nil {
+		return nil, err
+	}
+	for _, total := range summary.Payments {
+		if total.Method == "cash" {
+			summary.NetCash += total.Amount
+		}
+	}
+	for _, total := range summary.Refunds {
+		if total.Method == "cash" {
+			summary.NetCash -= total.Amount
+		}
+	}
+	return &summary, nil
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"hospital/internal/config"
+	"hospital/internal/models"
+)
+
+// EligibilityRequest asks a payer whether a member is covered on a date.
+type EligibilityRequest struct {
+	PayerCode   string
+	MemberID    string
+	GroupNumber string
+	PatientName string
+	DateOfBirth *models.Date
+	ServiceDate models.Date
+}
+
+// EligibilityResult is the payer's answer. Status is eligible, ineligible or
+// unknown when the payer could not be asked.
+type EligibilityResult struct {
+	Status    string    `json:"status"`
+	Message   string    `json:"message,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// EligibilityChecker verifies coverage with payers, normally through a
+// clearinghouse.
+type EligibilityChecker interface {
+	CheckEligibility(ctx context.Context, req EligibilityRequest) (*EligibilityResult, error)
+}
+
+// NewEligibilityChecker returns the checker selected by insurance.eligibility_provider.
+func NewEligibilityChecker(cfg config.Config) (EligibilityChecker, error) {
+	switch cfg.InsuranceConfig.EligibilityProvider {
+	case "", "stub":
+		return stubEligibilityChecker{}, nil
+	default:
+		return nil, fmt.Errorf("unknown eligibility provider %q", cfg.InsuranceConfig.EligibilityProvider)
+	}
+}
+
+// stubEligibilityChecker stands in until a clearinghouse is connected. It
+// can't ask the payer, so every answer is unknown; only the policy dates held
+// locally rule coverage out.
+type stubEligibilityChecker struct{}
+
+func (stubEligibilityChecker) CheckEligibility(ctx context.Context, req EligibilityRequest) (*EligibilityResult, error) {
+	result := &EligibilityResult{Status: "unknown", Message: "Not verified with the payer, no clearinghouse is configured", CheckedAt: time.Now()}
+	if req.PayerCode == "" || req.MemberID == "" {
+		result.Message = "Payer code and member ID are required"
+	}
+	return result, nil
+}
@@ -0,0 +1,323 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"hospital/internal/config"
+	"hospital/internal/database"
+	"hospital/internal/fhir"
+	"hospital/internal/models"
+)
+
+// FHIRService loads the records behind the FHIR API. It applies the access
+// rules of the role APIs: receptionists see every record, and doctors see the
+// patients they may access, those patients' prescriptions, and appointments
+// with them or with those patients. Every doctor is visible to both. Other
+// roles get an "access denied" error.
+type FHIRService interface {
+	GetPatient(requester FHIRRequester, id uuid.UUID) (*models.Patient, error)
+	SearchPatients(requester FHIRRequester, search FHIRSearch) ([]models.Patient, int64, error)
+	GetPractitioner(requester FHIRRequester, id uuid.UUID) (*models.User, error)
+	SearchPractitioners(requester FHIRRequester, search FHIRSearch) ([]models.User, int64, error)
+	GetAppointment(requester FHIRRequester, id uuid.UUID) (*models.Appointment, error)
+	SearchAppointments(requester FHIRRequester, search FHIRSearch) ([]models.Appointment, int64, error)
+	GetMedicationRequest(requester FHIRRequester, id uuid.UUID) (*models.Prescription, error)
+	SearchMedicationRequests(requester FHIRRequester, search FHIRSearch) ([]models.Prescription, int64, error)
+
+	// Writes are open to receptionists only; see fhir_write.go.
+	CreatePatient(requester FHIRRequester, resource *fhir.Patient, ifNoneExist string) (*FHIRWriteResult, error)
+	UpdatePatient(requester FHIRRequester, id uuid.UUID, resource *fhir.Patient, ifMatch *int) (*FHIRWriteResult, error)
+	CreateAppointment(requester FHIRRequester, resource *fhir.Appointment) (*FHIRWriteResult, error)
+	UpdateAppointment(requester FHIRRequester, id uuid.UUID, resource *fhir.Appointment, ifMatch *int) (*FHIRWriteResult, error)
+	Transaction(requester FHIRRequester, bundle *fhir.TransactionBundle) ([]FHIRWriteResult, error)
+}
+
+// FHIRRequester is the logged in user a FHIR request is made for.
+type FHIRRequester struct {
+	UserID uuid.UUID
+	Role   string
+}
+
+// FHIRDateRange is the half-open range [From, To) matched by a date search
+// parameter. Either end may be open.
+type FHIRDateRange struct {
+	From *time.Time
+	To   *time.Time
+}
+
+// FHIRSearch holds parsed search parameters. Each resource type uses the ones
+// it supports; see fhir.SearchParams.
+type FHIRSearch struct {
+	// nil matches any ID and an empty slice none
+	IDs              []uuid.UUID
+	Name             string
+	IdentifierSystem string
+	IdentifierValue  string
+	// All ranges must match
+	Dates          []FHIRDateRange
+	PatientID      *uuid.UUID
+	PractitionerID *uuid.UUID
+
+	Offset int
+	Count  int
+}
+
+type fhirService struct {
+	db  *database.DB
+	cfg config.Config
+}
+
+func NewFHIRService(db *database.DB, cfg config.Config) FHIRService {
+	return &fhirService{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+func (s *fhirService) GetPatient(requester FHIRRequester, id uuid.UUID) (*models.Patient, error) {
+	query, err := s.patients(requester)
+	if err != nil {
+		return nil, err
+	}
+	var patient models.Patient
+	if err := query.Where("patients.id = ?", id).First(&patient).Error; err != nil {
+		return nil, err
+	}
+	return &patient, nil
+}
+
+func (s *fhirService) SearchPatients(requester FHIRRequester, search FHIRSearch) ([]models.Patient, int64, error) {
+	query, err := s.patients(requester)
+	if err != nil {
+		return nil, 0, err
+	}
+	if search.IDs != nil {
+		query = query.Where("patients.id IN ?", search.IDs)
+	}
+	if search.Name != "" {
+		query = query.Scopes(nameMatches("patients.name", search.Name))
+	}
+	if search.IdentifierValue != "" {
+		query = query.Scopes(patientIdentifierMatches(search.IdentifierSystem, search.IdentifierValue))
+	}
+	query = query.Scopes(datesMatch("patients.date_of_birth", search.Dates))
+
+	return searchPage[models.Patient](query, search, "patients.name, patients.id")
+}
+
+func (s *fhirService) GetPractitioner(requester FHIRRequester, id uuid.UUID) (*models.User, error) {
+	query, err := s.practitioners(requester)
+	if err != nil {
+		return nil, err
+	}
+	var doctor models.User
+	if err := query.Where("users.id = ?", id).First(&doctor).Error; err != nil {
+		return nil, err
+	}
+	return &doctor, nil
+}
+
+func (s *fhirService) SearchPractitioners(requester FHIRRequester, search FHIRSearch) ([]models.User, int64, error) {
+	query, err := s.practitioners(requester)
+	if err != nil {
+		return nil, 0, err
+	}
+	if search.IDs != nil {
+		query = query.Where("users.id IN ?", search.IDs)
+	}
+	if search.Name != "" {
+		query = query.Scopes(nameMatches("users.name", search.Name))
+	}
+	if search.IdentifierValue != "" {
+		if search.IdentifierSystem == "" || search.IdentifierSystem == fhir.SystemLicense {
+			query = query.Where("users.license_number = ?", strings.TrimSpace(search.IdentifierValue))
+		} else {
+			query = query.Where("false")
+		}
+	}
+
+	return searchPage[models.User](query, search, "users.name, users.id")
+}
+
+func (s *fhirService) GetAppointment(requester FHIRRequester, id uuid.UUID) (*models.Appointment, error) {
+	query, err := s.appointments(requester)
+	if err != nil {
+		return nil, err
+	}
+	var appointment models.Appointment
+	if err := query.Preload("Patient").Preload("Doctor").Where("appointments.id = ?", id).First(&appointment).Error; err != nil {
+		return nil, err
+	}
+	return &appointment, nil
+}
+
+func (s *fhirService) SearchAppointments(requester FHIRRequester, search FHIRSearch) ([]models.Appointment, int64, error) {
+	query, err := s.appointments(requester)
+	if err != nil {
+		return nil, 0, err
+	}
+	if search.IDs != nil {
+		query = query.Where("appointments.id IN ?", search.IDs)
+	}
+	if search.PatientID != nil {
+		query = query.Where("appointments.patient_id = ?", *search.PatientID)
+	}
+	if search.PractitionerID != nil {
+		query = query.Where("appointments.doctor_id = ?", *search.PractitionerID)
+	}
+	query = query.Scopes(datesMatch("appointments.appointment_date", search.Dates))
+
+	return searchPage[models.Appointment](query, search, "appointments.appointment_date, appointments.id", "Patient", "Doctor")
+}
+
+func (s *fhirService) GetMedicationRequest(requester FHIRRequester, id uuid.UUID) (*models.Prescription, error) {
+	query, err := s.prescriptions(requester)
+	if err != nil {
+		return nil, err
+	}
+	var prescription models.Prescription
+	if err := query.Preload("Patient").Preload("Doctor").Where("prescriptions.id = ?", id).First(&prescription).Error; err != nil {
+		return nil, err
+	}
+	return &prescription, nil
+}
+
+func (s *fhirService) SearchMedicationRequests(requester FHIRRequester, search FHIRSearch) ([]models.Prescription, int64, error) {
+	query, err := s.prescriptions(requester)
+	if err != nil {
+		return nil, 0, err
+	}
+	if search.IDs != nil {
+		query = query.Where("prescriptions.id IN ?", search.IDs)
+	}
+	if search.IdentifierValue != "" {
+		if search.IdentifierSystem == "" || search.IdentifierSystem == fhir.SystemVerificationCode {
+			query = query.Where("prescriptions.verification_code = ?", strings.ToUpper(strings.TrimSpace(search.IdentifierValue)))
+		} else {
+			query = query.Where("false")
+		}
+	}
+	if search.PatientID != nil {
+		query = query.Where("prescriptions.patient_id = ?", *search.PatientID)
+	}
+	if search.PractitionerID != nil {
+		query = query.Where("prescriptions.doctor_id = ?", *search.PractitionerID)
+	}
+	query = query.Scopes(datesMatch("prescriptions.created_at", search.Dates))
+
+	return searchPage[models.Prescription](query, search, "prescriptions.created_at DESC, prescriptions.id", "Patient", "Doctor")
+}
+
+// patients starts a query over the patients the requester may access, with
+// the care team loaded for the generalPractitioner.
+func (s *fhirService) patients(requester FHIRRequester) (*gorm.DB, error) {
+	query := s.db.Conn.Model(&models.Patient{}).Preload("CareTeam")
+	switch requester.Role {
+	case "receptionist":
+		return query, nil
+	case "doctor":
+		return query.Scopes(doctorPatients(requester.UserID)), nil
+	}
+	return nil, errors.New("access denied")
+}
+
+func (s *fhirService) practitioners(requester FHIRRequester) (*gorm.DB, error) {
+	if requester.Role != "receptionist" && requester.Role != "doctor" {
+		return nil, errors.New("access denied")
+	}
+	return s.db.Conn.Model(&models.User{}).Where("users.role = ?", "doctor"), nil
+}
+
+func (s *fhirService) appointments(requester FHIRRequester) (*gorm.DB, error) {
+	query := s.db.Conn.Model(&models.Appointment{})
+	switch requester.Role {
+	case "receptionist":
+		return query, nil
+	case "doctor":
+		return query.Where("appointments.doctor_id = ? OR appointments.patient_id IN (?)",
+			requester.UserID, s.doctorPatientIDs(requester.UserID)), nil
+	}
+	return nil, errors.New("access denied")
+}
+
+func (s *fhirService) prescriptions(requester FHIRRequester) (*gorm.DB, error) {
+	query := s.db.Conn.Model(&models.Prescription{})
+	switch requester.Role {
+	case "receptionist":
+		return query, nil
+	case "doctor":
+		return query.Where("prescriptions.patient_id IN (?)", s.doctorPatientIDs(requester.UserID)), nil
+	}
+	return nil, errors.New("access denied")
+}
+
+func (s *fhirService) doctorPatientIDs(doctorID uuid.UUID) *gorm.DB {
+	return s.db.Conn.Model(&models.Patient{}).Scopes(doctorPatients(doctorID)).Select("patients.id")
+}
+
+// searchPage counts the matches of a search and loads the requested page with
+// the given associations.
+func searchPage[T any](query *gorm.DB, search FHIRSearch, order string, preloads ...string) ([]T, int64, error) {
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	results := []T{}
+	if search.Count == 0 {
+		return results, total, nil
+	}
+	for _, preload := range preloads {
+		query = query.Preload(preload)
+	}
+	if err := query.Order(order).Offset(search.Offset).Limit(search.Count).Find(&results).Error; err != nil {
+		return nil, 0, err
+	}
+	return results, total, nil
+}
+
+// patientIdentifierMatches matches patients with the identifier. Without a
+// system the value may be any of them.
+func patientIdentifierMatches(system, value string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		value = strings.TrimSpace(value)
+		switch system {
+		case "":
+			return db.Where("patients.mrn = ? OR patients.national_id = ? OR patients.insurance_number = ?", normalizeMRN(value), value, value)
+		case fhir.SystemMRN:
+			return db.Where("patients.mrn = ?", normalizeMRN(value))
+		case fhir.SystemNationalID:
+			return db.Where("patients.national_id = ?", value)
+		case fhir.SystemInsuranceNumber:
+			return db.Where("patients.insurance_number = ?", value)
+		}
+		return db.Where("false")
+	}
+}
+
+// nameMatches matches names with a word starting with value, the way FHIR
+// string parameters match.
+func nameMatches(column, value string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		pattern := escapeLike(strings.TrimSpace(value))
+		return db.Where(column+" ILIKE ? OR "+column+" ILIKE ?", pattern+"%", "% "+pattern+"%")
+	}
+}
+
+func datesMatch(column string, ranges []FHIRDateRange) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		for _, r := range ranges {
+			if r.From != nil {
+				db = db.Where(column+" >= ?", *r.From)
+			}
+			if r.To != nil {
+				db = db.Where(column+" < ?", *r.To)
+			}
+		}
+		return db
+	}
+}
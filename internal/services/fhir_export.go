@@ -0,0 +1,403 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"hospital/internal/config"
+	"hospital/internal/database"
+	"hospital/internal/fhir"
+	"hospital/internal/models"
+	"hospital/internal/storage"
+)
+
+const (
+	// defaultExportLinkTTL and defaultExportRetention apply when the export
+	// settings are not configured.
+	defaultExportLinkTTL   = time.Hour
+	defaultExportRetention = 24 * time.Hour
+	// exportBatchSize is the number of records an export holds in memory.
+	exportBatchSize = 500
+)
+
+// ErrExportLinkInvalid is returned for a download link that was altered, has
+// expired, or points at an export that is gone.
+var ErrExportLinkInvalid = errors.New("the download link is invalid or has expired")
+
+// FHIRExportService runs FHIR bulk data exports of every record, for
+// receptionists, who may read every record. Exports run in the background one
+// at a time. Each resource type is streamed from the database into its own
+// NDJSON file in the blob store, a batch at a time.
+type FHIRExportService interface {
+	StartExport(requester FHIRRequester, request FHIRExportRequest) (*models.FHIRExport, error)
+	// GetExport returns an export started by the requester.
+	GetExport(requester FHIRRequester, id uuid.UUID) (*models.FHIRExport, error)
+	// CancelExport stops an export that is running, or deletes the files of
+	// one that has completed.
+	CancelExport(requester FHIRRequester, id uuid.UUID) error
+	// DownloadQuery returns the query string of a link to a file of a
+	// completed export, which works without logging in until it expires.
+	DownloadQuery(export *models.FHIRExport, resourceType string) url.Values
+	OpenFile(id uuid.UUID, resourceType string, query url.Values) (io.ReadCloser, error)
+}
+
+// FHIRExportRequest holds the parsed kick-off parameters.
+type FHIRExportRequest struct {
+	// Request is the kick-off URL, reported back in the status
+	Request string
+	// Types defaults to every resource type
+	Types []string
+	// Since limits the export to resources changed after it
+	Since *time.Time
+}
+
+type fhirExportService struct {
+	db    *database.DB
+	cfg   config.Config
+	store storage.BlobStore
+	// Holds a token while an export runs, so exports run one at a time
+	running chan struct{}
+
+	mu      sync.Mutex
+	cancels map[uuid.UUID]context.CancelFunc
+}
+
+func NewFHIRExportService(db *database.DB, cfg config.Config, store storage.BlobStore) FHIRExportService {
+	return &fhirExportService{
+		db:      db,
+		cfg:     cfg,
+		store:   store,
+		running: make(chan struct{}, 1),
+		cancels: make(map[uuid.UUID]context.CancelFunc),
+	}
+}
+
+// FailInterruptedExports marks exports that were running when the server
+// stopped as failed, so clients polling them stop waiting.
+func FailInterruptedExports(db *database.DB) error {
+	return db.Conn.Model(&models.FHIRExport{}).Where("status = ?", "in_progress").
+		Updates(map[string]any{"status": "failed", "progress": "", "error": "the export was interrupted by a server restart"}).Error
+}
+
+func (s *fhirExportService) StartExport(requester FHIRRequester, request FHIRExportRequest) (*models.FHIRExport, error) {
+	if requester.Role != "receptionist" {
+		return nil, errors.New("access denied")
+	}
+	s.purgeExpired()
+
+	export := models.FHIRExport{
+		RequestedBy:     requester.UserID,
+		Request:         request.Request,
+		Types:           request.Types,
+		Since:           request.Since,
+		Status:          "in_progress",
+		TransactionTime: time.Now(),
+	}
+	if len(export.Types) == 0 {
+		export.Types = fhir.ResourceTypes
+	}
+	if err := s.db.Conn.Create(&export).Error; err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.mu.Lock()
+	s.cancels[export.ID] = cancel
+	s.mu.Unlock()
+	go s.run(ctx, export)
+	return &export, nil
+}
+
+func (s *fhirExportService) GetExport(requester FHIRRequester, id uuid.UUID) (*models.FHIRExport, error) {
+	if requester.Role != "receptionist" {
+		return nil, errors.New("access denied")
+	}
+	var export models.FHIRExport
+	if err := s.db.Conn.Where("id = ? AND requested_by = ?", id, requester.UserID).First(&export).Error; err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (s *fhirExportService) CancelExport(requester FHIRRequester, id uuid.UUID) error {
+	if requester.Role != "receptionist" {
+		return errors.New("access denied")
+	}
+	var export models.FHIRExport
+	err := s.db.Conn.Transaction(func(tx *gorm.DB) error {
+		// Locked so a run finishing now either sees the cancellation or has
+		// already recorded its files
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND requested_by = ?", id, requester.UserID).First(&export).Error; err != nil {
+			return err
+		}
+		if export.Status != "in_progress" && export.Status != "completed" {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Model(&export).Updates(map[string]any{"status": "cancelled", "progress": ""}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	if export.Status == "completed" {
+		s.deleteFiles(export.Files)
+		return nil
+	}
+	s.mu.Lock()
+	cancel := s.cancels[id]
+	s.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	return nil
+}
+
+func (s *fhirExportService) DownloadQuery(export *models.FHIRExport, resourceType string) url.Values {
+	expires := time.Now().Add(s.linkTTL())
+	if export.ExpiresAt != nil && export.ExpiresAt.Before(expires) {
+		expires = *export.ExpiresAt
+	}
+	return url.Values{
+		"expires":   {strconv.FormatInt(expires.Unix(), 10)},
+		"signature": {s.signature(export.ID, resourceType, expires.Unix())},
+	}
+}
+
+func (s *fhirExportService) OpenFile(id uuid.UUID, resourceType string, query url.Values) (io.ReadCloser, error) {
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil || time.Now().Unix() > expires ||
+		!hmac.Equal([]byte(query.Get("signature")), []byte(s.signature(id, resourceType, expires))) {
+		return nil, ErrExportLinkInvalid
+	}
+
+	var export models.FHIRExport
+	if err := s.db.Conn.Where("id = ? AND status = ?", id, "completed").First(&export).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrExportLinkInvalid
+		}
+		return nil, err
+	}
+	for _, file := range export.Files {
+		if file.Type == resourceType {
+			return s.store.Get(context.Background(), file.StorageKey)
+		}
+	}
+	return nil, ErrExportLinkInvalid
+}
+
+// run exports each requested type in turn and records the files, or the
+// failure. The files of a failed or cancelled export are deleted.
+func (s *fhirExportService) run(ctx context.Context, export models.FHIRExport) {
+	defer func() {
+		s.mu.Lock()
+		delete(s.cancels, export.ID)
+		s.mu.Unlock()
+	}()
+	select {
+	case s.running <- struct{}{}:
+		defer func() { <-s.running }()
+	case <-ctx.Done():
+		return
+	}
+
+	var files []models.FHIRExportFile
+	var err error
+	for _, resourceType := range export.Types {
+		s.db.Conn.Model(&export).Update("progress", resourceType)
+		var file models.FHIRExportFile
+		if file, err = s.exportType(ctx, &export, resourceType); err != nil {
+			break
+		}
+		if file.Count > 0 {
+			files = append(files, file)
+		}
+	}
+	if err != nil {
+		s.deleteFiles(files)
+		if ctx.Err() != nil {
+			// CancelExport has recorded the cancellation
+			return
+		}
+		slog.Error("FHIR export failed", "export_id", export.ID, "error", err.Error())
+		s.db.Conn.Model(&export).Where("status = ?", "in_progress").
+			Updates(map[string]any{"status": "failed", "progress": "", "error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	expires := now.Add(s.retention())
+	export.Status = "completed"
+	export.Progress = ""
+	export.Files = files
+	export.CompletedAt = &now
+	export.ExpiresAt = &expires
+	result := s.db.Conn.Model(&export).Where("status = ?", "in_progress").
+		Select("status", "progress", "files", "completed_at", "expires_at").Updates(&export)
+	if result.Error != nil || result.RowsAffected == 0 {
+		// Cancelled just as it finished
+		s.deleteFiles(files)
+	}
+}
+
+// exportType streams the resources of one type into an NDJSON file. The
+// records are written to the upload as they are read, so neither the file nor
+// all the records are ever held in memory. An empty file is not kept.
+func (s *fhirExportService) exportType(ctx context.Context, export *models.FHIRExport, resourceType string) (models.FHIRExportFile, error) {
+	file := models.FHIRExportFile{
+		Type:       resourceType,
+		StorageKey: exportKey(export.ID, resourceType),
+	}
+	reader, writer := io.Pipe()
+	var count int64
+	written := make(chan error, 1)
+	go func() {
+		var err error
+		count, err = s.writeResources(ctx, writer, export, resourceType)
+		writer.CloseWithError(err)
+		written <- err
+	}()
+
+	err := s.store.Put(ctx, file.StorageKey, reader, -1, fhir.NDJSONContentType)
+	// Unblocks the writer if the upload stopped early
+	reader.CloseWithError(err)
+	if writeErr := <-written; err == nil {
+		err = writeErr
+	}
+	file.Count = count
+	if err != nil || file.Count == 0 {
+		s.deleteFiles([]models.FHIRExportFile{file})
+	}
+	return file, err
+}
+
+// writeResources writes the resources of one type as NDJSON, one line per
+// resource, and returns how many it wrote.
+func (s *fhirExportService) writeResources(ctx context.Context, w io.Writer, export *models.FHIRExport, resourceType string) (int64, error) {
+	buffered := bufio.NewWriter(w)
+	encoder := json.NewEncoder(buffered)
+	var count int64
+	write := func(resource fhir.Resource) error {
+		count++
+		return encoder.Encode(resource)
+	}
+	query := s.db.Conn.WithContext(ctx)
+	changed := func(column string) *gorm.DB {
+		if export.Since == nil {
+			return query
+		}
+		return query.Where(column+" > ?", *export.Since)
+	}
+
+	var err error
+	switch resourceType {
+	case "Patient":
+		err = exportBatches(changed("patients.updated_at").Preload("CareTeam"), func(patient *models.Patient) error {
+			return write(fhir.PatientResource(patient))
+		})
+	case "Practitioner":
+		// Users have no update time, so every doctor is exported whatever the _since
+		err = exportBatches(query.Where("users.role = ?", "doctor"), func(doctor *models.User) error {
+			return write(fhir.PractitionerResource(doctor))
+		})
+	case "Appointment":
+		err = exportBatches(changed("appointments.updated_at").Preload("Patient").Preload("Doctor"), func(appointment *models.Appointment) error {
+			return write(fhir.AppointmentResource(appointment))
+		})
+	case "MedicationRequest":
+		err = exportBatches(changed("prescriptions.updated_at").Preload("Patient").Preload("Doctor"), func(prescription *models.Prescription) error {
+			return write(fhir.MedicationRequestResource(prescription))
+		})
+	default:
+		err = fmt.Errorf("resource type %s cannot be exported", resourceType)
+	}
+	if err == nil {
+		err = buffered.Flush()
+	}
+	return count, err
+}
+
+// exportBatches loads the records matched by query in primary key order, a
+// batch at a time, and writes each.
+func exportBatches[T any](query *gorm.DB, write func(*T) error) error {
+	var batch []T
+	return query.FindInBatches(&batch, exportBatchSize, func(tx *gorm.DB, _ int) error {
+		for i := range batch {
+			if err := write(&batch[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+}
+
+// purgeExpired deletes the files of exports that have outlived the retention
+// period. A failed export may have written some files before failing, so its
+// possible files are deleted too.
+func (s *fhirExportService) purgeExpired() {
+	now := time.Now()
+	var exports []models.FHIRExport
+	if err := s.db.Conn.Where("(status = ? AND expires_at < ?) OR (status = ? AND created_at < ?)",
+		"completed", now, "failed", now.Add(-s.retention())).Find(&exports).Error; err != nil {
+		slog.Error("Failed to find expired FHIR exports", "error", err.Error())
+		return
+	}
+	for _, export := range exports {
+		var files []models.FHIRExportFile
+		for _, resourceType := range export.Types {
+			files = append(files, models.FHIRExportFile{Type: resourceType, StorageKey: exportKey(export.ID, resourceType)})
+		}
+		s.deleteFiles(files)
+		s.db.Conn.Model(&export).Update("status", "expired")
+	}
+}
+
+func (s *fhirExportService) deleteFiles(files []models.FHIRExportFile) {
+	for _, file := range files {
+		if err := s.store.Delete(context.Background(), file.StorageKey); err != nil {
+			slog.Error("Failed to delete blob", "key", file.StorageKey, "error", err.Error())
+		}
+	}
+}
+
+// signature signs a download link, so the link can't be changed to reach
+// another file or to work for longer.
+func (s *fhirExportService) signature(id uuid.UUID, resourceType string, expires int64) string {
+	mac := hmac.New(sha256.New, []byte("fhir-export:"+s.cfg.JwtConfig.JWTSecret))
+	fmt.Fprintf(mac, "%s/%s/%d", id, resourceType, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *fhirExportService) linkTTL() time.Duration {
+	if s.cfg.ExportConfig.LinkTTLMinutes > 0 {
+		return time.Duration(s.cfg.ExportConfig.LinkTTLMinutes) * time.Minute
+	}
+	return defaultExportLinkTTL
+}
+
+func (s *fhirExportService) retention() time.Duration {
+	if s.cfg.ExportConfig.RetentionHours > 0 {
+		return time.Duration(s.cfg.ExportConfig.RetentionHours) * time.Hour
+	}
+	return defaultExportRetention
+}
+
+func exportKey(id uuid.UUID, resourceType string) string {
+	return "fhir-exports/" + id.String() + "/" + resourceType + ".ndjson"
+}
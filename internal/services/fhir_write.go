@@ -0,0 +1,439 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"hospital/internal/fhir"
+	"hospital/internal/models"
+)
+
+// FHIRPreconditionError is returned when an If-Match version is not the
+// current one, or an If-None-Exist condition matches more than one record.
+type FHIRPreconditionError struct {
+	Reason string
+}
+
+func (e *FHIRPreconditionError) Error() string { return e.Reason }
+
+// FHIRRejectedError is returned when a valid resource breaks one of the
+// hospital's rules, such as a doctor being booked twice at the same time.
+type FHIRRejectedError struct {
+	Reason string
+}
+
+func (e *FHIRRejectedError) Error() string { return e.Reason }
+
+// FHIRWriteResult is the resource as stored by a create or update.
+type FHIRWriteResult struct {
+	Resource fhir.Resource
+	// Created is false when an update, or a conditional create that matched
+	// an existing record
+	Created      bool
+	LastModified time.Time
+}
+
+func (s *fhirService) CreatePatient(requester FHIRRequester, resource *fhir.Patient, ifNoneExist string) (*FHIRWriteResult, error) {
+	var result *FHIRWriteResult
+	err := s.write(requester, func(tx *gorm.DB) (err error) {
+		result, err = s.createPatient(tx, resource, ifNoneExist)
+		return err
+	})
+	return result, err
+}
+
+func (s *fhirService) UpdatePatient(requester FHIRRequester, id uuid.UUID, resource *fhir.Patient, ifMatch *int) (*FHIRWriteResult, error) {
+	var result *FHIRWriteResult
+	err := s.write(requester, func(tx *gorm.DB) (err error) {
+		result, err = s.updatePatient(tx, id, resource, ifMatch)
+		return err
+	})
+	return result, err
+}
+
+func (s *fhirService) CreateAppointment(requester FHIRRequester, resource *fhir.Appointment) (*FHIRWriteResult, error) {
+	var result *FHIRWriteResult
+	err := s.write(requester, func(tx *gorm.DB) (err error) {
+		result, err = s.createAppointment(tx, resource)
+		return err
+	})
+	return result, err
+}
+
+func (s *fhirService) UpdateAppointment(requester FHIRRequester, id uuid.UUID, resource *fhir.Appointment, ifMatch *int) (*FHIRWriteResult, error) {
+	var result *FHIRWriteResult
+	err := s.write(requester, func(tx *gorm.DB) (err error) {
+		result, err = s.updateAppointment(tx, requester.UserID, id, resource, ifMatch)
+		return err
+	})
+	return result, err
+}
+
+// Transaction applies the entries of a transaction Bundle in one database
+// transaction, so either all of them are applied or none. Creates are applied
+// before updates, and patients before appointments, so entries can refer to
+// resources created earlier in the Bundle by their fullUrl. The results are
+// in the order of the entries.
+func (s *fhirService) Transaction(requester FHIRRequester, bundle *fhir.TransactionBundle) ([]FHIRWriteResult, error) {
+	if requester.Role != "receptionist" {
+		return nil, errors.New("access denied")
+	}
+	if bundle.ResourceType != "Bundle" {
+		return nil, fhir.Invalid("Bundle.resourceType", "must be Bundle")
+	}
+	if bundle.Type != "transaction" {
+		return nil, fhir.Invalid("Bundle.type", "only transaction Bundles are supported")
+	}
+
+	requests := make([]transactionRequest, len(bundle.Entry))
+	for i := range bundle.Entry {
+		request, err := parseTransactionEntry(bundle.Entry[i])
+		if err != nil {
+			return nil, entryError(i, err)
+		}
+		requests[i] = request
+	}
+	order := make([]int, len(requests))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return requests[order[a]].rank() < requests[order[b]].rank()
+	})
+
+	results := make([]FHIRWriteResult, len(requests))
+	err := s.write(requester, func(tx *gorm.DB) error {
+		// Maps the fullUrl of each created resource to its path
+		created := map[string]string{}
+		for _, i := range order {
+			result, err := s.applyTransactionEntry(tx, requester, requests[i], created)
+			if err != nil {
+				return entryError(i, err)
+			}
+			if bundle.Entry[i].FullURL != "" && requests[i].method == "POST" {
+				created[bundle.Entry[i].FullURL] = result.Resource.Path()
+			}
+			results[i] = *result
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// write runs fn in a transaction if the requester may change records.
+func (s *fhirService) write(requester FHIRRequester, fn func(tx *gorm.DB) error) error {
+	if requester.Role != "receptionist" {
+		return errors.New("access denied")
+	}
+	return s.db.Conn.Transaction(fn)
+}
+
+// createPatient registers the patient unless ifNoneExist, an identifier
+// search such as identifier=system|value, matches one already registered.
+func (s *fhirService) createPatient(tx *gorm.DB, resource *fhir.Patient, ifNoneExist string) (*FHIRWriteResult, error) {
+	var patient models.Patient
+	doctorID, err := fhir.ApplyPatient(resource, &patient)
+	if err != nil {
+		return nil, err
+	}
+
+	if ifNoneExist != "" {
+		system, value, err := parseIdentifierCondition(ifNoneExist)
+		if err != nil {
+			return nil, err
+		}
+		// Concurrent creates with the same condition wait for each other, so
+		// only the first registers the patient
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "fhir-patient:"+system+"|"+value).Error; err != nil {
+			return nil, err
+		}
+		var matches []models.Patient
+		if err := tx.Scopes(patientIdentifierMatches(system, value)).Preload("CareTeam").Limit(2).Find(&matches).Error; err != nil {
+			return nil, err
+		}
+		switch len(matches) {
+		case 1:
+			return &FHIRWriteResult{Resource: fhir.PatientResource(&matches[0]), LastModified: matches[0].UpdatedAt}, nil
+		case 2:
+			return nil, &FHIRPreconditionError{Reason: "If-None-Exist matches more than one patient"}
+		}
+	}
+
+	if doctorID == nil {
+		return nil, fhir.Invalid("Patient.generalPractitioner", "the primary doctor is required to register a patient")
+	}
+	if err := tx.Where("id = ? AND role = ?", *doctorID, "doctor").First(&models.User{}).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &FHIRRejectedError{Reason: "generalPractitioner is not a doctor"}
+		}
+		return nil, err
+	}
+	if err := registerPatient(tx, s.cfg.PatientConfig.MRNFormat, &patient, *doctorID); err != nil {
+		return nil, rejected(err)
+	}
+	return &FHIRWriteResult{Resource: fhir.PatientResource(&patient), Created: true, LastModified: patient.UpdatedAt}, nil
+}
+
+func (s *fhirService) updatePatient(tx *gorm.DB, id uuid.UUID, resource *fhir.Patient, ifMatch *int) (*FHIRWriteResult, error) {
+	var existing models.Patient
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&existing).Error; err != nil {
+		return nil, err
+	}
+	if err := checkVersion(existing.Version, ifMatch); err != nil {
+		return nil, err
+	}
+	if err := tx.Where("patient_id = ?", id).Find(&existing.CareTeam).Error; err != nil {
+		return nil, err
+	}
+
+	// Details the FHIR resource doesn't carry, such as the blood group, are kept
+	patient := existing
+	doctorID, err := fhir.ApplyPatient(resource, &patient)
+	if err != nil {
+		return nil, err
+	}
+	if doctorID != nil {
+		if current, ok := existing.PrimaryDoctorID(); !ok || current != *doctorID {
+			return nil, &FHIRRejectedError{Reason: "the primary doctor is changed through the care team, not by updating the patient"}
+		}
+	}
+	if err := updatePatientRecord(tx, &existing, &patient); err != nil {
+		return nil, rejected(err)
+	}
+	return &FHIRWriteResult{Resource: fhir.PatientResource(&patient), LastModified: patient.UpdatedAt}, nil
+}
+
+func (s *fhirService) createAppointment(tx *gorm.DB, resource *fhir.Appointment) (*FHIRWriteResult, error) {
+	var appointment models.Appointment
+	if err := fhir.ApplyAppointment(resource, &appointment); err != nil {
+		return nil, err
+	}
+	if appointment.Status != "scheduled" {
+		return nil, fhir.Invalid("Appointment.status", "a new appointment must be booked")
+	}
+	if err := bookAppointment(tx, &appointment); err != nil {
+		return nil, rejected(err)
+	}
+	return appointmentResult(tx, appointment.ID, true)
+}
+
+func (s *fhirService) updateAppointment(tx *gorm.DB, userID, id uuid.UUID, resource *fhir.Appointment, ifMatch *int) (*FHIRWriteResult, error) {
+	var existing models.Appointment
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&existing).Error; err != nil {
+		return nil, err
+	}
+	if err := checkVersion(existing.Version, ifMatch); err != nil {
+		return nil, err
+	}
+
+	updated := existing
+	if err := fhir.ApplyAppointment(resource, &updated); err != nil {
+		return nil, err
+	}
+	// The role APIs only reschedule appointments, so neither may this
+	if updated.PatientID != existing.PatientID || updated.DoctorID != existing.DoctorID {
+		return nil, &FHIRRejectedError{Reason: "the patient and practitioner of an appointment cannot be changed"}
+	}
+	if updated.Type != existing.Type {
+		return nil, &FHIRRejectedError{Reason: "the type of an appointment cannot be changed"}
+	}
+	if err := rescheduleAppointment(tx, userID, &existing, updated.AppointmentDate, updated.Status, updated.Notes); err != nil {
+		return nil, rejected(err)
+	}
+	return appointmentResult(tx, id, false)
+}
+
+// appointmentResult reloads a saved appointment with the names of its
+// patient and doctor.
+func appointmentResult(tx *gorm.DB, id uuid.UUID, created bool) (*FHIRWriteResult, error) {
+	var appointment models.Appointment
+	if err := tx.Preload("Patient").Preload("Doctor").Where("id = ?", id).First(&appointment).Error; err != nil {
+		return nil, err
+	}
+	return &FHIRWriteResult{Resource: fhir.AppointmentResource(&appointment), Created: created, LastModified: appointment.UpdatedAt}, nil
+}
+
+func checkVersion(current int, ifMatch *int) error {
+	if ifMatch != nil && *ifMatch != current {
+		return &FHIRPreconditionError{Reason: fmt.Sprintf("version %d is not the current version %d", *ifMatch, current)}
+	}
+	return nil
+}
+
+// rejected reports an error from the checks shared with the role APIs, which
+// those APIs return as bad requests, as a broken rule.
+func rejected(err error) error {
+	return &FHIRRejectedError{Reason: err.Error()}
+}
+
+// parseIdentifierCondition reads an If-None-Exist condition, the only
+// search parameter of which may be identifier.
+func parseIdentifierCondition(condition string) (string, string, error) {
+	query, err := url.ParseQuery(strings.TrimPrefix(condition, "?"))
+	if err != nil || len(query) != 1 || len(query["identifier"]) != 1 {
+		return "", "", fhir.Invalid("", "If-None-Exist must be a single identifier search, e.g. identifier=system|value")
+	}
+	system, value, found := strings.Cut(query.Get("identifier"), "|")
+	if !found {
+		system, value = "", system
+	}
+	if strings.TrimSpace(value) == "" {
+		return "", "", fhir.Invalid("", "If-None-Exist must give an identifier value")
+	}
+	return system, value, nil
+}
+
+// transactionRequest is a parsed entry of a transaction Bundle.
+type transactionRequest struct {
+	method       string
+	resourceType string
+	// id is set for updates
+	id          uuid.UUID
+	ifMatch     *int
+	ifNoneExist string
+	patient     *fhir.Patient
+	appointment *fhir.Appointment
+}
+
+// rank orders requests creates first, then patients first.
+func (r transactionRequest) rank() int {
+	rank := 0
+	if r.method == "PUT" {
+		rank += 2
+	}
+	if r.resourceType == "Appointment" {
+		rank++
+	}
+	return rank
+}
+
+func parseTransactionEntry(entry fhir.TransactionEntry) (transactionRequest, error) {
+	var request transactionRequest
+	if entry.Request == nil {
+		return request, fhir.Invalid("request", "every entry needs a request")
+	}
+	request.method = entry.Request.Method
+	path := strings.Trim(entry.Request.URL, "/")
+	resourceType, id, hasID := strings.Cut(path, "/")
+	request.resourceType = resourceType
+
+	switch {
+	case request.method == "POST" && !hasID:
+		request.ifNoneExist = entry.Request.IfNoneExist
+	case request.method == "PUT" && hasID:
+		parsed, err := uuid.Parse(id)
+		if err != nil {
+			return request, fhir.Invalid("request.url", "unknown resource "+path)
+		}
+		request.id = parsed
+		if entry.Request.IfMatch != "" {
+			version, err := fhir.ParseETag(entry.Request.IfMatch)
+			if err != nil {
+				return request, fhir.Invalid("request.ifMatch", err.Error())
+			}
+			request.ifMatch = &version
+		}
+	default:
+		return request, fhir.Invalid("request", "only POST Type and PUT Type/id are supported")
+	}
+	if request.ifNoneExist != "" && resourceType != "Patient" {
+		return request, fhir.Invalid("request.ifNoneExist", "conditional create is only supported for Patient")
+	}
+
+	var err error
+	switch resourceType {
+	case "Patient":
+		request.patient = &fhir.Patient{}
+		err = json.Unmarshal(entry.Resource, request.patient)
+		if err == nil && request.method == "PUT" && request.patient.ID != id {
+			return request, fhir.Invalid("resource.id", "must match the id in request.url")
+		}
+	case "Appointment":
+		request.appointment = &fhir.Appointment{}
+		err = json.Unmarshal(entry.Resource, request.appointment)
+		if err == nil && request.method == "PUT" && request.appointment.ID != id {
+			return request, fhir.Invalid("resource.id", "must match the id in request.url")
+		}
+	default:
+		return request, fhir.Invalid("request.url", "only Patient and Appointment can be written")
+	}
+	if err != nil {
+		return request, fhir.Invalid("resource", "not a valid "+resourceType+": "+err.Error())
+	}
+	return request, nil
+}
+
+// applyTransactionEntry applies one request, first pointing references to
+// resources created earlier in the Bundle at them.
+func (s *fhirService) applyTransactionEntry(tx *gorm.DB, requester FHIRRequester, request transactionRequest, created map[string]string) (*FHIRWriteResult, error) {
+	resolve := func(reference *fhir.Reference) {
+		if reference != nil && created[reference.Reference] != "" {
+			reference.Reference = created[reference.Reference]
+		}
+	}
+	switch {
+	case request.patient != nil:
+		for i := range request.patient.GeneralPractitioner {
+			resolve(&request.patient.GeneralPractitioner[i])
+		}
+		if request.method == "POST" {
+			return s.createPatient(tx, request.patient, request.ifNoneExist)
+		}
+		return s.updatePatient(tx, request.id, request.patient, request.ifMatch)
+	default:
+		for i := range request.appointment.Participant {
+			resolve(request.appointment.Participant[i].Actor)
+		}
+		if request.method == "POST" {
+			return s.createAppointment(tx, request.appointment)
+		}
+		return s.updateAppointment(tx, requester.UserID, request.id, request.appointment, request.ifMatch)
+	}
+}
+
+// entryError places an error at an entry of the Bundle.
+func entryError(i int, err error) error {
+	var invalid *fhir.InvalidResource
+	if errors.As(err, &invalid) {
+		at := &fhir.InvalidResource{}
+		for _, issue := range invalid.Issues {
+			expression := make([]string, len(issue.Expression))
+			for j, e := range issue.Expression {
+				expression[j] = fmt.Sprintf("Bundle.entry[%d].%s", i, entryPath(e))
+			}
+			if len(expression) == 0 {
+				expression = []string{fmt.Sprintf("Bundle.entry[%d]", i)}
+			}
+			issue.Expression = expression
+			at.Issues = append(at.Issues, issue)
+		}
+		return at
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("Bundle.entry[%d]: the resource to update does not exist: %w", i, err)
+	}
+	return fmt.Errorf("Bundle.entry[%d]: %w", i, err)
+}
+
+// entryPath turns the path of an element of a resource, such as
+// Patient.name, into its path within an entry, resource.name.
+func entryPath(expression string) string {
+	for _, resourceType := range []string{"Patient.", "Appointment."} {
+		if strings.HasPrefix(expression, resourceType) {
+			return "resource." + strings.TrimPrefix(expression, resourceType)
+		}
+	}
+	return expression
+}
@@ -0,0 +1,253 @@
+package services
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"hospital/internal/config"
+	"hospital/internal/database"
+	"hospital/internal/models"
+)
+
+// ImmunizationService records vaccine doses and works out which doses are due
+// from the schedule in the vaccine catalog. It does no access checks of its
+// own; callers authorize the patient first.
+type ImmunizationService interface {
+	GetVaccines(includeInactive bool) ([]models.Vaccine, error)
+	RecordImmunization(userID, patientID uuid.UUID, immunization *models.Immunization) error
+	GetImmunizations(patientID uuid.UUID, asOf models.Date) (*ImmunizationRecord, error)
+	GetOverdueReport(asOf models.Date, vaccineCode string) ([]OverdueDoseRow, error)
+}
+
+// DoseStatus is the next dose of a vaccine a patient should get. It is
+// upcoming before DueOn, due until OverdueOn and overdue after that.
+type DoseStatus struct {
+	VaccineID   uuid.UUID   `json:"vaccine_id"`
+	VaccineCode string      `json:"vaccine_code"`
+	VaccineName string      `json:"vaccine_name"`
+	DoseNumber  int         `json:"dose_number"`
+	DueOn       models.Date `json:"due_on"`
+	OverdueOn   models.Date `json:"overdue_on"`
+	Status      string      `json:"status"`
+}
+
+// ImmunizationRecord is a patient's vaccination history and the next dose of
+// every scheduled vaccine, as of a date.
+type ImmunizationRecord struct {
+	PatientID   uuid.UUID             `json:"patient_id"`
+	DateOfBirth *models.Date          `json:"date_of_birth,omitempty"`
+	AsOf        models.Date           `json:"as_of"`
+	History     []models.Immunization `json:"history"`
+	Due         []DoseStatus          `json:"due"`
+	Overdue     []DoseStatus          `json:"overdue"`
+	Upcoming    []DoseStatus          `json:"upcoming"`
+	// Set when no schedule can be worked out
+	Note string `json:"note,omitempty"`
+}
+
+// OverdueDoseRow is one overdue dose on the clinic-wide outreach list.
+type OverdueDoseRow struct {
+	PatientID   uuid.UUID   `json:"patient_id"`
+	PatientName string      `json:"patient_name"`
+	MRN         string      `gorm:"column:mrn" json:"mrn"`
+	Phone       string      `json:"phone"`
+	Email       string      `json:"email"`
+	DateOfBirth models.Date `json:"date_of_birth"`
+	VaccineCode string      `json:"vaccine_code"`
+	VaccineName string      `json:"vaccine_name"`
+	DoseNumber  int         `json:"dose_number"`
+	DueOn       models.Date `json:"due_on"`
+	DaysOverdue int         `json:"days_overdue"`
+}
+
+func (OverdueDoseRow) CSVHeader() []string {
+	return []string{"patient_id", "patient_name", "mrn", "phone", "email", "date_of_birth", "vaccine_code", "vaccine_name", "dose_number", "due_on", "days_overdue"}
+}
+
+func (r OverdueDoseRow) CSVRecord() []string {
+	return []string{r.PatientID.String(), r.PatientName, r.MRN, r.Phone, r.Email, r.DateOfBirth.String(), r.VaccineCode,
+		r.VaccineName, strconv.Itoa(r.DoseNumber), r.DueOn.String(), strconv.Itoa(r.DaysOverdue)}
+}
+
+type immunizationService struct {
+	db  *database.DB
+	cfg config.Config
+}
+
+func NewImmunizationService(db *database.DB, cfg config.Config) ImmunizationService {
+	return &immunizationService{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+func (s *immunizationService) GetVaccines(includeInactive bool) ([]models.Vaccine, error) {
+	query := s.db.Conn.Preload("Schedule", func(db *gorm.DB) *gorm.DB { return db.Order("dose_number") }).Order("name")
+	if !includeInactive {
+		query = query.Where("active")
+	}
+
+	var vaccines []models.Vaccine
+	if err := query.Find(&vaccines).Error; err != nil {
+		return nil, err
+	}
+	return vaccines, nil
+}
+
+func (s *immunizationService) RecordImmunization(userID, patientID uuid.UUID, immunization *models.Immunization) error {
+	var patient models.Patient
+	if err := s.db.Conn.Select("id", "date_of_birth").First(&patient, "id = ?", patientID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("patient not found")
+		}
+		return err
+	}
+	var vaccine models.Vaccine
+	if err := s.db.Conn.First(&vaccine, "id = ? AND active", immunization.VaccineID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("vaccine not found")
+		}
+		return err
+	}
+
+	if immunization.AdministeredOn.IsZero() {
+		immunization.AdministeredOn = models.NewDate(time.Now())
+	}
+	if immunization.AdministeredOn.After(time.Now()) {
+		return errors.New("administered_on must not be in the future")
+	}
+	if patient.DateOfBirth != nil && immunization.AdministeredOn.Before(patient.DateOfBirth.Time) {
+		return errors.New("administered_on must not be before the date of birth")
+	}
+	if immunization.ExpiryDate != nil && immunization.ExpiryDate.Before(immunization.AdministeredOn.Time) {
+		return errors.New("the lot had expired on the day it was given")
+	}
+	immunization.LotNumber = strings.TrimSpace(immunization.LotNumber)
+	if immunization.LotNumber == "" {
+		return errors.New("lot_number is required")
+	}
+	if !models.ImmunizationSites[immunization.Site] {
+		return errors.New("site must be one of left_arm, right_arm, left_thigh, right_thigh, oral, nasal or other")
+	}
+	if immunization.Route == "" {
+		immunization.Route = vaccine.DefaultRoute
+	}
+	if !models.ImmunizationRoutes[immunization.Route] {
+		return errors.New("route must be one of intramuscular, subcutaneous, intradermal, oral or intranasal")
+	}
+
+	immunization.ID = uuid.Nil
+	immunization.PatientID = patientID
+	immunization.AdministeredBy = userID
+	return s.db.Conn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Vaccine", "Administrator").Create(immunization).Error; err != nil {
+			return err
+		}
+		immunization.Vaccine = &vaccine
+		return recordAudit(tx, userID, "immunization.recorded", "patient", patientID, map[string]any{
+			"immunization_id": immunization.ID,
+			"vaccine":         vaccine.Code,
+			"lot_number":      immunization.LotNumber,
+		})
+	})
+}
+
+func (s *immunizationService) GetImmunizations(patientID uuid.UUID, asOf models.Date) (*ImmunizationRecord, error) {
+	var patient models.Patient
+	if err := s.db.Conn.Select("id", "date_of_birth").First(&patient, "id = ?", patientID).Error; err != nil {
+		return nil, err
+	}
+
+	record := ImmunizationRecord{
+		PatientID:   patientID,
+		DateOfBirth: patient.DateOfBirth,
+		AsOf:        asOf,
+		History:     []models.Immunization{},
+		Due:         []DoseStatus{},
+		Overdue:     []DoseStatus{},
+		Upcoming:    []DoseStatus{},
+	}
+	if err := s.db.Conn.Preload("Vaccine").Preload("Administrator").Where("patient_id = ?", patientID).
+		Order("administered_on DESC, created_at DESC").Find(&record.History).Error; err != nil {
+		return nil, err
+	}
+	if patient.DateOfBirth == nil {
+		record.Note = "The date of birth is not recorded, so due doses cannot be worked out"
+		return &record, nil
+	}
+
+	var doses []DoseStatus
+	if err := s.db.Conn.Table("(?) AS d", nextDoses(s.db.Conn, asOf)).Where("d.patient_id = ?", patientID).
+		Order("d.due_on, d.vaccine_name").Scan(&doses).Error; err != nil {
+		return nil, err
+	}
+	for _, dose := range doses {
+		switch dose.Status {
+		case "due":
+			record.Due = append(record.Due, dose)
+		case "overdue":
+			record.Overdue = append(record.Overdue, dose)
+		default:
+			record.Upcoming = append(record.Upcoming, dose)
+		}
+	}
+	return &record, nil
+}
+
+// GetOverdueReport lists every patient's overdue doses for outreach, longest
+// overdue first, optionally for one vaccine.
+func (s *immunizationService) GetOverdueReport(asOf models.Date, vaccineCode string) ([]OverdueDoseRow, error) {
+	query := s.db.Conn.Table("(?) AS d", nextDoses(s.db.Conn, asOf)).
+		Select(`d.patient_id, p.name AS patient_name, p.mrn, p.phone, p.email, p.date_of_birth,
+			d.vaccine_code, d.vaccine_name, d.dose_number, d.due_on, CAST(@as_of AS date) - d.due_on AS days_overdue`,
+			map[string]any{"as_of": asOf}).
+		Joins("JOIN patients p ON p.id = d.patient_id").
+		Where("d.status = ?", "overdue")
+	if vaccineCode != "" {
+		query = query.Where("d.vaccine_code = ?", strings.ToUpper(vaccineCode))
+	}
+
+	rows := []OverdueDoseRow{}
+	if err := query.Order("days_overdue DESC, p.name").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// nextDoses selects the next scheduled dose of every active vaccine for every
+// patient with a date of birth, with its status on asOf. Patients who have had
+// every scheduled dose, or are past the age limit of the next one, have no
+// row for the vaccine.
+func nextDoses(db *gorm.DB, asOf models.Date) *gorm.DB {
+	return db.Raw(`WITH given AS (
+			SELECT patient_id, vaccine_id, COUNT(*) AS doses, MAX(administered_on) AS last_on
+			FROM immunizations
+			GROUP BY patient_id, vaccine_id
+		), next AS (
+			SELECT p.id AS patient_id, v.id AS vaccine_id, v.code AS vaccine_code, v.name AS vaccine_name, r.dose_number,
+				GREATEST(CAST(p.date_of_birth + make_interval(months => r.age_months, days => r.age_days) AS date),
+					g.last_on + r.min_interval_days) AS due_on,
+				r.grace_days,
+				CAST(p.date_of_birth + make_interval(months => r.max_age_months) AS date) AS age_limit_on
+			FROM patients p
+			CROSS JOIN vaccines v
+			JOIN vaccine_schedule_rules r ON r.vaccine_id = v.id
+			LEFT JOIN given g ON g.patient_id = p.id AND g.vaccine_id = v.id
+			WHERE v.active AND p.date_of_birth IS NOT NULL AND r.dose_number = COALESCE(g.doses, 0) + 1
+		)
+		SELECT patient_id, vaccine_id, vaccine_code, vaccine_name, dose_number, due_on,
+			due_on + grace_days AS overdue_on,
+			CASE
+				WHEN CAST(@as_of AS date) < due_on THEN 'upcoming'
+				WHEN CAST(@as_of AS date) <= due_on + grace_days THEN 'due'
+				ELSE 'overdue'
+			END AS status
+		FROM next
+		WHERE age_limit_on IS NULL OR CAST(@as_of AS date) < age_limit_on`,
+		map[string]any{"as_of": asOf})
+}
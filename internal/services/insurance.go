@@ -0,0 +1,321 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"hospital/internal/config"
+	"hospital/internal/database"
+	"hospital/internal/models"
+)
+
+var subscriberRelationships = map[string]bool{"self": true, "spouse": true, "parent": true, "child": true, "other": true}
+
+// InsuranceService manages insurance payers and the policies patients hold
+// with them.
+type InsuranceService interface {
+	GetPayers(includeInactive bool) ([]models.InsurancePayer, error)
+	CreatePayer(payer *models.InsurancePayer) error
+	UpdatePayer(payerID uuid.UUID, payer *models.InsurancePayer) (*models.InsurancePayer, error)
+
+	GetPolicies(patientID uuid.UUID) ([]models.InsurancePolicy, error)
+	CreatePolicy(patientID uuid.UUID, policy *models.InsurancePolicy) error
+	UpdatePolicy(patientID, policyID uuid.UUID, policy *models.InsurancePolicy) (*models.InsurancePolicy, error)
+	DeletePolicy(patientID, policyID uuid.UUID) error
+	ActivePolicies(patientID uuid.UUID, date models.Date) ([]models.InsurancePolicy, error)
+	CheckEligibility(patientID, policyID uuid.UUID, serviceDate models.Date) (*models.InsurancePolicy, error)
+}
+
+type insuranceService struct {
+	db          *database.DB
+	cfg         config.Config
+	eligibility EligibilityChecker
+}
+
+func NewInsuranceService(db *database.DB, cfg config.Config, eligibility EligibilityChecker) InsuranceService {
+	return &insuranceService{
+		db:          db,
+		cfg:         cfg,
+		eligibility: eligibility,
+	}
+}
+
+func (s *insuranceService) GetPayers(includeInactive bool) ([]models.InsurancePayer, error) {
+	query := s.db.Conn.Order("name")
+	if !includeInactive {
+		query = query.Where("active")
+	}
+	var payers []models.InsurancePayer
+	if err := query.Find(&payers).Error; err != nil {
+		return nil, err
+	}
+	return payers, nil
+}
+
+func (s *insuranceService) CreatePayer(payer *models.InsurancePayer) error {
+	if err := normalizePayer(payer); err != nil {
+		return err
+	}
+	if err := s.checkPayerUnique(payer); err != nil {
+		return err
+	}
+	payer.ID = uuid.Nil
+	payer.Active = true
+	return s.db.Conn.Create(payer).Error
+}
+
+func (s *insuranceService) UpdatePayer(payerID uuid.UUID, update *models.InsurancePayer) (*models.InsurancePayer, error) {
+	var payer models.InsurancePayer
+	if err := s.db.Conn.First(&payer, "id = ?", payerID).Error; err != nil {
+		return nil, err
+	}
+	if err := normalizePayer(update); err != nil {
+		return nil, err
+	}
+	update.ID = payerID
+	if err := s.checkPayerUnique(update); err != nil {
+		return nil, err
+	}
+
+	payer.Name = update.Name
+	payer.PayerCode = update.PayerCode
+	payer.Phone = update.Phone
+	payer.Email = update.Email
+	payer.Address = update.Address
+	payer.Active = update.Active
+	if err := s.db.Conn.Save(&payer).Error; err != nil {
+		return nil, err
+	}
+	return &payer, nil
+}
+
+func (s *insuranceService) checkPayerUnique(payer *models.InsurancePayer) error {
+	var count int64
+	if err := s.db.Conn.Model(&models.InsurancePayer{}).
+		Where("(lower(name) = lower(?) OR payer_code = ?) AND id <> ?", payer.Name, payer.PayerCode, payer.ID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("a payer with this name or payer code already exists")
+	}
+	return nil
+}
+
+func (s *insuranceService) GetPolicies(patientID uuid.UUID) ([]models.InsurancePolicy, error) {
+	if err := s.db.Conn.Select("id").First(&models.Patient{}, "id = ?", patientID).Error; err != nil {
+		return nil, err
+	}
+
+	var policies []models.InsurancePolicy
+	if err := s.db.Conn.Preload("Payer").Where("patient_id = ?", patientID).
+		Order("priority, valid_from DESC").Find(&policies).Error; err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+func (s *insuranceService) CreatePolicy(patientID uuid.UUID, policy *models.InsurancePolicy) error {
+	if err := s.db.Conn.Select("id").First(&models.Patient{}, "id = ?", patientID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("patient not found")
+		}
+		return err
+	}
+
+	policy.ID = uuid.Nil
+	policy.PatientID = patientID
+	policy.EligibilityStatus = ""
+	policy.EligibilityMessage = ""
+	policy.EligibilityCheckedAt = nil
+	if err := s.validatePolicy(policy); err != nil {
+		return err
+	}
+	if err := s.db.Conn.Omit(clause.Associations).Create(policy).Error; err != nil {
+		return err
+	}
+	return s.db.Conn.First(&policy.Payer, "id = ?", policy.PayerID).Error
+}
+
+func (s *insuranceService) UpdatePolicy(patientID, policyID uuid.UUID, update *models.InsurancePolicy) (*models.InsurancePolicy, error) {
+	var policy models.InsurancePolicy
+	if err := s.db.Conn.First(&policy, "id = ? AND patient_id = ?", policyID, patientID).Error; err != nil {
+		return nil, err
+	}
+
+	update.ID = policy.ID
+	update.PatientID = patientID
+	if err := s.validatePolicy(update); err != nil {
+		return nil, err
+	}
+
+	// A different member or payer invalidates the last eligibility check
+	if update.PayerID != policy.PayerID || update.MemberID != policy.MemberID {
+		policy.EligibilityStatus = ""
+		policy.EligibilityMessage = ""
+		policy.EligibilityCheckedAt = nil
+	}
+	policy.PayerID = update.PayerID
+	policy.MemberID = update.MemberID
+	policy.GroupNumber = update.GroupNumber
+	policy.PlanName = update.PlanName
+	policy.SubscriberName = update.SubscriberName
+	policy.SubscriberRelationship = update.SubscriberRelationship
+	policy.Priority = update.Priority
+	policy.ValidFrom = update.ValidFrom
+	policy.ValidTo = update.ValidTo
+	policy.CardFrontDocumentID = update.CardFrontDocumentID
+	policy.CardBackDocumentID = update.CardBackDocumentID
+	if err := s.db.Conn.Omit(clause.Associations).Save(&policy).Error; err != nil {
+		return nil, err
+	}
+	if err := s.db.Conn.First(&policy.Payer, "id = ?", policy.PayerID).Error; err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (s *insuranceService) DeletePolicy(patientID, policyID uuid.UUID) error {
+	result := s.db.Conn.Where("id = ? AND patient_id = ?", policyID, patientID).Delete(&models.InsurancePolicy{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ActivePolicies returns the patient's policies valid on the date, primary first.
+func (s *insuranceService) ActivePolicies(patientID uuid.UUID, date models.Date) ([]models.InsurancePolicy, error) {
+	var policies []models.InsurancePolicy
+	if err := s.db.Conn.Preload("Payer").
+		Where("patient_id = ? AND valid_from <= ? AND (valid_to IS NULL OR valid_to >= ?)", patientID, date, date).
+		Order("priority").Find(&policies).Error; err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+// CheckEligibility asks the payer whether the policy covers the service date
+// and keeps the answer on the policy. A policy whose own dates do not cover
+// the service date is ineligible without asking.
+func (s *insuranceService) CheckEligibility(patientID, policyID uuid.UUID, serviceDate models.Date) (*models.InsurancePolicy, error) {
+	var policy models.InsurancePolicy
+	if err := s.db.Conn.Preload("Payer").First(&policy, "id = ? AND patient_id = ?", policyID, patientID).Error; err != nil {
+		return nil, err
+	}
+	var patient models.Patient
+	if err := s.db.Conn.First(&patient, "id = ?", patientID).Error; err != nil {
+		return nil, err
+	}
+
+	var result *EligibilityResult
+	if !policy.CoversDate(serviceDate) {
+		result = &EligibilityResult{Status: "ineligible", Message: "Policy is not valid on " + serviceDate.String()}
+	} else {
+		var err error
+		result, err = s.eligibility.CheckEligibility(context.Background(), EligibilityRequest{
+			PayerCode:   policy.Payer.PayerCode,
+			MemberID:    policy.MemberID,
+			GroupNumber: policy.GroupNumber,
+			PatientName: patient.Name,
+			DateOfBirth: patient.DateOfBirth,
+			ServiceDate: serviceDate,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	checkedAt := result.CheckedAt
+	if checkedAt.IsZero() {
+		checkedAt = time.Now()
+	}
+	policy.EligibilityStatus = result.Status
+	policy.EligibilityMessage = result.Message
+	policy.EligibilityCheckedAt = &checkedAt
+	if err := s.db.Conn.Model(&policy).Select("eligibility_status", "eligibility_message", "eligibility_checked_at").
+		Updates(&policy).Error; err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (s *insuranceService) validatePolicy(policy *models.InsurancePolicy) error {
+	policy.MemberID = strings.TrimSpace(policy.MemberID)
+	if policy.MemberID == "" {
+		return errors.New("member_id is required")
+	}
+	if policy.SubscriberRelationship == "" {
+		policy.SubscriberRelationship = "self"
+	}
+	if !subscriberRelationships[policy.SubscriberRelationship] {
+		return errors.New("subscriber_relationship must be one of self, spouse, parent, child or other")
+	}
+	if policy.Priority == 0 {
+		policy.Priority = 1
+	}
+	if policy.Priority < 1 {
+		return errors.New("priority must be 1 or greater")
+	}
+	if policy.ValidFrom.IsZero() {
+		return errors.New("valid_from is required")
+	}
+	if policy.ValidTo != nil && policy.ValidTo.Before(policy.ValidFrom.Time) {
+		return errors.New("valid_to must not be before valid_from")
+	}
+
+	var payer models.InsurancePayer
+	if err := s.db.Conn.First(&payer, "id = ? AND active", policy.PayerID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("payer not found")
+		}
+		return err
+	}
+
+	for _, documentID := range []*uuid.UUID{policy.CardFrontDocumentID, policy.CardBackDocumentID} {
+		if documentID == nil {
+			continue
+		}
+		var count int64
+		if err := s.db.Conn.Model(&models.PatientDocument{}).
+			Where("id = ? AND patient_id = ?", *documentID, policy.PatientID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return errors.New("insurance card documents must be attached to this patient")
+		}
+	}
+
+	// Two policies cannot both be primary (or both secondary) on the same day
+	query := s.db.Conn.Model(&models.InsurancePolicy{}).
+		Where("patient_id = ? AND priority = ? AND id <> ?", policy.PatientID, policy.Priority, policy.ID).
+		Where("valid_to IS NULL OR valid_to >= ?", policy.ValidFrom)
+	if policy.ValidTo != nil {
+		query = query.Where("valid_from <= ?", *policy.ValidTo)
+	}
+	var overlapping int64
+	if err := query.Count(&overlapping).Error; err != nil {
+		return err
+	}
+	if overlapping > 0 {
+		return errors.New("another policy with this priority is valid during the same period")
+	}
+	return nil
+}
+
+func normalizePayer(payer *models.InsurancePayer) error {
+	payer.Name = strings.TrimSpace(payer.Name)
+	payer.PayerCode = strings.ToUpper(strings.TrimSpace(payer.PayerCode))
+	if payer.Name == "" || payer.PayerCode == "" {
+		return errors.New("name and payer_code are required")
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"hospital/internal/models"
+)
+
+// InteractionWarning describes an interaction between a medication and one of
+// the patient's active prescriptions.
+type InteractionWarning struct {
+	InteractionID  uuid.UUID `json:"interaction_id"`
+	Severity       string    `json:"severity"`
+	Message        string    `json:"message"`
+	PrescriptionID uuid.UUID `json:"prescription_id"` // The active prescription it interacts with
+	Medication     string    `json:"medication"`
+	PrescribedBy   uuid.UUID `json:"prescribed_by"`
+}
+
+// InteractionConflictError is returned when a prescription has a severe
+// interaction with an active prescription and no override was given.
+type InteractionConflictError struct {
+	Interactions []InteractionWarning
+}
+
+func (e *InteractionConflictError) Error() string {
+	return "medication has severe interactions with the patient's active prescriptions"
+}
+
+// PrescriptionCheck is the result of running the prescribing safety checks
+// without saving anything.
+type PrescriptionCheck struct {
+	Medication   string               `json:"medication"`
+	Allergies    []AllergyConflict    `json:"allergies"`
+	Interactions []InteractionWarning `json:"interactions"`
+	Blocking     bool                 `json:"blocking"` // Creating the prescription would need an override
+}
+
+func (s *doctorService) CheckPrescription(doctorID, patientID uuid.UUID, prescription *models.Prescription) (*PrescriptionCheck, error) {
+	if _, err := s.findPatientForDoctor(doctorID, patientID); err != nil {
+		return nil, err
+	}
+	if err := s.resolveMedication(s.db.Conn, prescription); err != nil {
+		return nil, err
+	}
+
+	allergies, err := s.checkAllergies(s.db.Conn, patientID, prescription.Medication)
+	if err != nil {
+		return nil, err
+	}
+	interactions, err := s.checkInteractions(s.db.Conn, patientID, prescription, uuid.Nil)
+	if err != nil {
+		return nil, err
+	}
+
+	check := &PrescriptionCheck{
+		Medication:   prescription.Medication,
+		Allergies:    allergies,
+		Interactions: interactions,
+		Blocking:     len(allergies) > 0 || hasSevereInteraction(interactions),
+	}
+	if check.Allergies == nil {
+		check.Allergies = []AllergyConflict{}
+	}
+	if check.Interactions == nil {
+		check.Interactions = []InteractionWarning{}
+	}
+	return check, nil
+}
+
+// activePrescriptions returns the patient's active prescriptions from every
+// doctor whose duration has not yet run out.
+func activePrescriptions(tx *gorm.DB, patientID uuid.UUID) ([]models.Prescription, error) {
+	var prescriptions []models.Prescription
+	err := tx.Preload("FormularyItem").
+		Where("patient_id = ? AND status = ?", patientID, "active").
+		Where("duration_days = 0 OR duration_days IS NULL OR created_at + duration_days * interval '1 day' > ?", time.Now()).
+		Find(&prescriptions).Error
+	return prescriptions, err
+}
+
+// checkInteractions returns the interaction rules between the prescription and
+// the patient's active prescriptions. excludeID leaves out a prescription that
+// is being replaced.
+func (s *doctorService) checkInteractions(tx *gorm.DB, patientID uuid.UUID, prescription *models.Prescription, excludeID uuid.UUID) ([]InteractionWarning, error) {
+	drug := drugName(prescription)
+
+	var rules []models.DrugInteraction
+	if err := tx.Where("? LIKE '%' || drug_a || '%' OR ? LIKE '%' || drug_b || '%'", drug, drug).
+		Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	active, err := activePrescriptions(tx, patientID)
+	if err != nil {
+		return nil, err
+	}
+
+	var warnings []InteractionWarning
+	for _, other := range active {
+		if other.ID == excludeID {
+			continue
+		}
+		otherDrug := drugName(&other)
+		for _, rule := range rules {
+			if (strings.Contains(drug, rule.DrugA) && strings.Contains(otherDrug, rule.DrugB)) ||
+				(strings.Contains(drug, rule.DrugB) && strings.Contains(otherDrug, rule.DrugA)) {
+				warnings = append(warnings, InteractionWarning{
+					InteractionID:  rule.ID,
+					Severity:       rule.Severity,
+					Message:        rule.Message,
+					PrescriptionID: other.ID,
+					Medication:     other.Medication,
+					PrescribedBy:   other.DoctorID,
+				})
+			}
+		}
+	}
+	return warnings, nil
+}
+
+func hasSevereInteraction(warnings []InteractionWarning) bool {
+	for _, w := range warnings {
+		if w.Severity == "severe" {
+			return true
+		}
+	}
+	return false
+}
+
+// drugName is the name interaction rules are matched against: the generic name
+// for formulary items, the normalised medication text otherwise.
+func drugName(p *models.Prescription) string {
+	if p.FormularyItem != nil {
+		return normalizeDrugName(p.FormularyItem.GenericName)
+	}
+	return normalizeDrugName(p.Medication)
+}
@@ -0,0 +1,351 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"hospital/internal/config"
+	"hospital/internal/database"
+	"hospital/internal/models"
+)
+
+var (
+	labOrderStatuses   = map[string]bool{"ordered": true, "collected": true, "resulted": true, "reviewed": true}
+	labOrderPriorities = map[string]bool{"routine": true, "urgent": true}
+)
+
+// LabService is used by the lab system, authenticated with a lab API key, to
+// work through orders and report results.
+type LabService interface {
+	GetWorklist(status string) ([]models.LabOrder, error)
+	CollectSpecimen(orderID, apiKeyID uuid.UUID, collectedAt *time.Time) (*models.LabOrder, error)
+	RecordResult(orderID, apiKeyID uuid.UUID, result *models.LabResult) (*models.LabOrder, error)
+}
+
+type labService struct {
+	db  *database.DB
+	cfg config.Config
+}
+
+func NewLabService(db *database.DB, cfg config.Config) LabService {
+	return &labService{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// Doctor side
+
+func (s *doctorService) SearchLabTests(query string, limit int) ([]models.LabTest, error) {
+	var tests []models.LabTest
+	q := s.db.Conn.Preload("ReferenceRanges").Where("active")
+	if query = strings.TrimSpace(query); query != "" {
+		pattern := "%" + escapeLike(query) + "%"
+		q = q.Where("code ILIKE ? OR name ILIKE ?", pattern, pattern)
+	}
+	if err := q.Order("name").Limit(limit).Find(&tests).Error; err != nil {
+		return nil, err
+	}
+	return tests, nil
+}
+
+func (s *doctorService) CreateLabOrder(doctorID, patientID uuid.UUID, order *models.LabOrder) error {
+	if _, err := s.findPatientForDoctor(doctorID, patientID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("patient not found")
+		}
+		return err
+	}
+
+	var test models.LabTest
+	if err := s.db.Conn.First(&test, "id = ? AND active", order.LabTestID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("unknown lab test")
+		}
+		return err
+	}
+
+	if order.Priority == "" {
+		order.Priority = "routine"
+	}
+	if !labOrderPriorities[order.Priority] {
+		return errors.New("priority must be one of routine or urgent")
+	}
+
+	if order.AppointmentID != nil {
+		var appointment models.Appointment
+		if err := s.db.Conn.Where("id = ? AND patient_id = ?", *order.AppointmentID, patientID).
+			First(&appointment).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("appointment not found for this patient")
+			}
+			return err
+		}
+	}
+
+	order.ID = uuid.Nil
+	order.PatientID = patientID
+	order.DoctorID = doctorID
+	order.Status = "ordered"
+	order.CollectedAt = nil
+	order.ResultedAt = nil
+	order.ReviewedAt = nil
+	order.ReviewedBy = nil
+	order.Result = nil
+	if err := s.db.Conn.Omit(clause.Associations).Create(order).Error; err != nil {
+		return err
+	}
+	order.LabTest = test
+	return nil
+}
+
+func (s *doctorService) GetLabOrdersByPatient(doctorID, patientID uuid.UUID, status string) ([]models.LabOrder, error) {
+	if _, err := s.findPatientForDoctor(doctorID, patientID); err != nil {
+		return nil, err
+	}
+
+	query := s.db.Conn.Preload("LabTest").Preload("Result").Where("patient_id = ?", patientID)
+	if status != "" {
+		if !labOrderStatuses[status] {
+			return nil, errors.New("status must be one of ordered, collected, resulted or reviewed")
+		}
+		query = query.Where("status = ?", status)
+	}
+
+	var orders []models.LabOrder
+	if err := query.Order("created_at DESC").Find(&orders).Error; err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// GetUnreviewedLabResults is the doctor's results inbox: resulted orders the
+// doctor placed, abnormal results first.
+func (s *doctorService) GetUnreviewedLabResults(doctorID uuid.UUID) ([]models.LabOrder, error) {
+	var orders []models.LabOrder
+	if err := s.db.Conn.Joins("Result").Preload("LabTest").Preload("Patient").
+		Where("lab_orders.doctor_id = ? AND lab_orders.status = ?", doctorID, "resulted").
+		Order(`CASE WHEN "Result".flag IN ('low','high') THEN 0 ELSE 1 END, lab_orders.resulted_at`).
+		Find(&orders).Error; err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// ReviewLabOrder records that the ordering doctor has seen the result.
+func (s *doctorService) ReviewLabOrder(doctorID, orderID uuid.UUID) (*models.LabOrder, error) {
+	var order models.LabOrder
+	err := s.db.Conn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&order, "id = ? AND doctor_id = ?", orderID, doctorID).Error; err != nil {
+			return err
+		}
+		if order.Status != "resulted" {
+			return errors.New("only resulted lab orders can be reviewed")
+		}
+
+		now := time.Now()
+		order.Status = "reviewed"
+		order.ReviewedAt = &now
+		order.ReviewedBy = &doctorID
+		return tx.Model(&order).Select("status", "reviewed_at", "reviewed_by", "updated_at").Updates(&order).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Conn.Preload("LabTest").Preload("Result").First(&order, "id = ?", order.ID).Error; err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// Lab system side
+
+// GetWorklist returns the orders still waiting on the lab, urgent ones first.
+// An empty status lists both ordered and collected orders.
+func (s *labService) GetWorklist(status string) ([]models.LabOrder, error) {
+	statuses := []string{"ordered", "collected"}
+	if status != "" {
+		if status != "ordered" && status != "collected" {
+			return nil, errors.New("status must be one of ordered or collected")
+		}
+		statuses = []string{status}
+	}
+
+	var orders []models.LabOrder
+	if err := s.db.Conn.Preload("LabTest").Preload("Patient").
+		Where("status IN ?", statuses).
+		Order("CASE WHEN priority = 'urgent' THEN 0 ELSE 1 END, created_at").
+		Find(&orders).Error; err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+func (s *labService) CollectSpecimen(orderID, apiKeyID uuid.UUID, collectedAt *time.Time) (*models.LabOrder, error) {
+	var order models.LabOrder
+	err := s.db.Conn.Transaction(func(tx *gorm.DB) error {
+		if err := lockLabOrder(tx, orderID, &order); err != nil {
+			return err
+		}
+		if order.Status != "ordered" {
+			return errors.New("specimen has already been collected")
+		}
+
+		now := time.Now()
+		if collectedAt == nil {
+			collectedAt = &now
+		}
+		if collectedAt.After(now) {
+			return errors.New("collected_at cannot be in the future")
+		}
+		order.Status = "collected"
+		order.CollectedAt = collectedAt
+		if err := tx.Model(&order).Select("status", "collected_at", "updated_at").Updates(&order).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, uuid.Nil, "lab_order.collected", "lab_order", order.ID, map[string]any{
+			"api_key_id": apiKeyID,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// RecordResult stores the result of an order and flags it against the
+// reference range that applies to the patient. A result reported for an order
+// that was never marked collected is taken as collected when resulted.
+func (s *labService) RecordResult(orderID, apiKeyID uuid.UUID, result *models.LabResult) (*models.LabOrder, error) {
+	var order models.LabOrder
+	err := s.db.Conn.Transaction(func(tx *gorm.DB) error {
+		if err := lockLabOrder(tx, orderID, &order); err != nil {
+			return err
+		}
+		if order.Status != "ordered" && order.Status != "collected" {
+			return errors.New("lab order already has a result")
+		}
+
+		var test models.LabTest
+		if err := tx.Preload("ReferenceRanges").First(&test, "id = ?", order.LabTestID).Error; err != nil {
+			return err
+		}
+		var patient models.Patient
+		if err := tx.First(&patient, "id = ?", order.PatientID).Error; err != nil {
+			return err
+		}
+
+		result.ValueText = strings.TrimSpace(result.ValueText)
+		if result.Value == nil && result.ValueText == "" {
+			return errors.New("value or value_text is required")
+		}
+		result.Unit = strings.TrimSpace(result.Unit)
+		if result.Unit == "" {
+			result.Unit = test.Unit
+		}
+		if result.Value != nil && test.Unit != "" && !strings.EqualFold(result.Unit, test.Unit) {
+			return errors.New("unit must be " + test.Unit)
+		}
+
+		result.ID = uuid.Nil
+		result.LabOrderID = order.ID
+		result.ReportedBy = apiKeyID
+		result.ReferenceLow, result.ReferenceHigh, result.Flag = nil, nil, ""
+		if result.Value != nil {
+			sex, age := patientSexAndAge(&patient)
+			if rng := referenceRangeFor(test.ReferenceRanges, sex, age); rng != nil {
+				result.ReferenceLow, result.ReferenceHigh = rng.Low, rng.High
+				result.Flag = flagResult(*result.Value, rng)
+			}
+		}
+		if err := tx.Create(result).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if order.CollectedAt == nil {
+			order.CollectedAt = &now
+		}
+		order.Status = "resulted"
+		order.ResultedAt = &now
+		if err := tx.Model(&order).Select("status", "collected_at", "resulted_at", "updated_at").Updates(&order).Error; err != nil {
+			return err
+		}
+		order.LabTest = test
+		order.Result = result
+		return recordAudit(tx, uuid.Nil, "lab_result.recorded", "lab_order", order.ID, map[string]any{
+			"api_key_id": apiKeyID,
+			"flag":       result.Flag,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func lockLabOrder(tx *gorm.DB, orderID uuid.UUID, order *models.LabOrder) error {
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(order, "id = ?", orderID).Error
+}
+
+// patientSexAndAge returns what the patient record holds for choosing a
+// reference range. Without a date of birth only ranges for all ages apply.
+func patientSexAndAge(patient *models.Patient) (string, *int) {
+	age, ok := patient.AgeAt(time.Now())
+	if !ok {
+		return patient.Sex, nil
+	}
+	return patient.Sex, &age
+}
+
+// referenceRangeFor picks the most specific range matching the patient. A
+// range for the patient's sex beats one for any sex, and a range with an age
+// band beats an open one. Ranges that need a sex or age the patient record
+// lacks are skipped.
+func referenceRangeFor(ranges []models.LabReferenceRange, sex string, age *int) *models.LabReferenceRange {
+	var best *models.LabReferenceRange
+	bestScore := -1
+	for i := range ranges {
+		rng := &ranges[i]
+		score := 0
+		if rng.Sex != "any" {
+			if rng.Sex != sex {
+				continue
+			}
+			score += 2
+		}
+		if rng.AgeMinYears != nil || rng.AgeMaxYears != nil {
+			if age == nil {
+				continue
+			}
+			if rng.AgeMinYears != nil && *age < *rng.AgeMinYears {
+				continue
+			}
+			if rng.AgeMaxYears != nil && *age >= *rng.AgeMaxYears {
+				continue
+			}
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = rng, score
+		}
+	}
+	return best
+}
+
+func flagResult(value float64, rng *models.LabReferenceRange) string {
+	switch {
+	case rng.Low != nil && value < *rng.Low:
+		return "low"
+	case rng.High != nil && value > *rng.High:
+		return "high"
+	default:
+		return "normal"
+	}
+}
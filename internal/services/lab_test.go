@@ -0,0 +1,47 @@
+package services
+
+import (
+	"testing"
+
+	"hospital/internal/models"
+)
+
+func TestReferenceRangeFor(t *testing.T) {
+	years := func(n int) *int { return &n }
+	ranges := []models.LabReferenceRange{
+		{Sex: "any"},
+		{Sex: "female"},
+		{Sex: "any", AgeMaxYears: years(18)},
+		{Sex: "female", AgeMinYears: years(18), AgeMaxYears: years(65)},
+		{Sex: "male", AgeMinYears: years(65)},
+	}
+
+	tests := []struct {
+		name string
+		sex  string
+		age  *int
+		want int // Index into ranges
+	}{
+		{"sex and age band beat everything", "female", years(30), 3},
+		{"sex beats an age band for any sex", "female", years(10), 1},
+		{"age band for any sex", "male", years(10), 2},
+		{"age minimum is inclusive", "female", years(18), 3},
+		{"age maximum is exclusive", "female", years(65), 1},
+		{"open range when nothing specific matches", "male", years(40), 0},
+		{"male age band", "male", years(70), 4},
+		{"unknown age skips age bands", "female", nil, 1},
+		{"unknown sex only matches any", "unknown", nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := referenceRangeFor(ranges, tt.sex, tt.age)
+			if got != &ranges[tt.want] {
+				t.Errorf("picked %+v, want %+v", got, ranges[tt.want])
+			}
+		})
+	}
+
+	if got := referenceRangeFor([]models.LabReferenceRange{{Sex: "male"}}, "female", years(30)); got != nil {
+		t.Errorf("picked %+v when no range matches, want nil", got)
+	}
+}
@@ -1 +1,255 @@
 package services
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"hospital/internal/config"
+	"hospital/internal/database"
+	"hospital/internal/models"
+)
+
+// defaultMRNFormat applies when patients.mrn_format is not set.
+const defaultMRNFormat = "MRN-{YYYY}-{SEQ:6}"
+
+var (
+	patientSexes = map[string]bool{"male": true, "female": true, "other": true, "unknown": true}
+	bloodGroups  = map[string]bool{"A+": true, "A-": true, "B+": true, "B-": true, "AB+": true, "AB-": true, "O+": true, "O-": true}
+
+	mrnSequencePattern = regexp.MustCompile(`\{SEQ(?::(\d+))?\}`)
+	languageTagPattern = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+	countryCodePattern = regexp.MustCompile(`^[A-Z]{2}$`)
+)
+
+// normalizePatient tidies the demographic fields of a patient and checks
+// them. It composes the one-line address from the structured address when
+// one is given.
+func normalizePatient(patient *models.Patient) error {
+	patient.Name = strings.TrimSpace(patient.Name)
+	patient.Email = strings.TrimSpace(patient.Email)
+	patient.Phone = strings.TrimSpace(patient.Phone)
+	patient.NationalID = strings.TrimSpace(patient.NationalID)
+	patient.InsuranceNumber = strings.TrimSpace(patient.InsuranceNumber)
+	patient.BloodGroup = strings.ToUpper(strings.TrimSpace(patient.BloodGroup))
+	patient.Country = strings.ToUpper(strings.TrimSpace(patient.Country))
+	patient.Sex = strings.ToLower(strings.TrimSpace(patient.Sex))
+	if patient.Sex == "" {
+		patient.Sex = "unknown"
+	}
+
+	if patient.AddressLine1 != "" {
+		parts := []string{patient.AddressLine1, patient.AddressLine2, patient.City,
+			strings.TrimSpace(patient.State + " " + patient.PostalCode), patient.Country}
+		var address []string
+		for _, part := range parts {
+			if part = strings.TrimSpace(part); part != "" {
+				address = append(address, part)
+			}
+		}
+		patient.Address = strings.Join(address, ", ")
+	}
+	patient.Address = strings.TrimSpace(patient.Address)
+
+	if patient.Name == "" || patient.Email == "" || patient.Phone == "" || patient.Address == "" {
+		return errors.New("name, email, phone, and address are required fields")
+	}
+	if _, err := mail.ParseAddress(patient.Email); err != nil {
+		return errors.New("email is not a valid address")
+	}
+	if patient.DateOfBirth != nil {
+		today := models.NewDate(time.Now())
+		if patient.DateOfBirth.After(today.Time) {
+			return errors.New("date_of_birth cannot be in the future")
+		}
+		if age, _ := patient.AgeAt(today.Time); age > 130 {
+			return errors.New("date_of_birth is implausibly far in the past")
+		}
+	}
+	if !patientSexes[patient.Sex] {
+		return errors.New("sex must be one of male, female, other or unknown")
+	}
+	if patient.BloodGroup != "" && !bloodGroups[patient.BloodGroup] {
+		return errors.New("blood_group must be one of A+, A-, B+, B-, AB+, AB-, O+ or O-")
+	}
+	if patient.PreferredLanguage != "" && !languageTagPattern.MatchString(patient.PreferredLanguage) {
+		return errors.New("preferred_language must be a language tag such as en or pt-BR")
+	}
+	if patient.Country != "" && !countryCodePattern.MatchString(patient.Country) {
+		return errors.New("country must be a two-letter ISO 3166 code")
+	}
+	return nil
+}
+
+// registerPatient checks and saves a new patient with the next MRN, and makes
+// the doctor the primary care-team member.
+func registerPatient(tx *gorm.DB, mrnFormat string, patient *models.Patient, primaryDoctorID uuid.UUID) error {
+	if err := normalizePatient(patient); err != nil {
+		return err
+	}
+	if patient.DateOfBirth == nil {
+		return errors.New("date_of_birth is required")
+	}
+
+	// Check if patient with email already exists, meaning error should be nil,
+	var existingPatient models.Patient
+	if err := tx.Where("email = ?", patient.Email).First(&existingPatient).Error; err == nil {
+		return errors.New("patient with this email already exists")
+	}
+	if err := checkPatientIdentifiers(tx, patient); err != nil {
+		return err
+	}
+	mrn, err := nextMRN(tx, mrnFormat, time.Now())
+	if err != nil {
+		return err
+	}
+	patient.ID = uuid.Nil
+	patient.MRN = mrn
+	patient.Version = 1
+	if err := tx.Omit(clause.Associations).Create(patient).Error; err != nil {
+		return err
+	}
+	member := models.CareTeamMember{
+		PatientID:     patient.ID,
+		DoctorID:      primaryDoctorID,
+		Role:          "primary",
+		EffectiveFrom: patient.CreatedAt,
+	}
+	if err := tx.Create(&member).Error; err != nil {
+		return err
+	}
+	patient.CareTeam = []models.CareTeamMember{member}
+	return nil
+}
+
+// updatePatientRecord checks and saves new details for an existing patient,
+// keeping the MRN and registration time. Patients registered before the date
+// of birth was recorded may still be updated without one, but a recorded date
+// can't be removed.
+func updatePatientRecord(tx *gorm.DB, existing, patient *models.Patient) error {
+	if err := normalizePatient(patient); err != nil {
+		return err
+	}
+	if patient.DateOfBirth == nil && existing.DateOfBirth != nil {
+		return errors.New("date_of_birth is required")
+	}
+
+	// Check if email is being changed and if new email already exists
+	if patient.Email != existing.Email {
+		var emailCheck models.Patient
+		if err := tx.Where("email = ? AND id != ?", patient.Email, existing.ID).First(&emailCheck).Error; err == nil {
+			return errors.New("patient with this email already exists")
+		}
+	}
+
+	// Update patient
+	patient.ID = existing.ID // Ensure ID doesn't change
+	patient.MRN = existing.MRN
+	patient.CreatedAt = existing.CreatedAt
+	if err := checkPatientIdentifiers(tx, patient); err != nil {
+		return err
+	}
+
+	// The care team is managed through its own endpoints
+	if err := tx.Omit(clause.Associations).Save(patient).Error; err != nil {
+		return err
+	}
+	// The database bumped the version
+	return tx.Model(&models.Patient{}).Where("id = ?", patient.ID).Select("version").Scan(&patient.Version).Error
+}
+
+// checkPatientIdentifiers rejects a national ID that another patient already
+// has, which usually means the patient is registered twice.
+func checkPatientIdentifiers(tx *gorm.DB, patient *models.Patient) error {
+	if patient.NationalID == "" {
+		return nil
+	}
+	var count int64
+	query := tx.Model(&models.Patient{}).Where("national_id = ?", patient.NationalID)
+	if patient.ID != uuid.Nil {
+		query = query.Where("id <> ?", patient.ID)
+	}
+	if err := query.Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("patient with this national ID already exists")
+	}
+	return nil
+}
+
+// normalizeMRN returns an MRN as it is stored, for lookups.
+func normalizeMRN(mrn string) string {
+	return strings.ToUpper(strings.TrimSpace(mrn))
+}
+
+// nextMRN issues the next medical record number for a patient registered at
+// the given time. The sequence row is locked until tx commits, so concurrent
+// registrations get distinct numbers.
+func nextMRN(tx *gorm.DB, format string, registeredAt time.Time) (string, error) {
+	if format == "" {
+		format = defaultMRNFormat
+	}
+	matches := mrnSequencePattern.FindAllStringSubmatchIndex(format, -1)
+	if len(matches) != 1 {
+		return "", fmt.Errorf("MRN format %q must contain exactly one {SEQ} placeholder", format)
+	}
+	width := 6
+	if match := matches[0]; match[2] >= 0 {
+		width, _ = strconv.Atoi(format[match[2]:match[3]])
+	}
+
+	// Lookups go through normalizeMRN, so issue numbers in upper case too
+	year := strconv.Itoa(registeredAt.Year())
+	rendered := strings.ToUpper(strings.NewReplacer("{YYYY}", year, "{YY}", year[len(year)-2:]).Replace(format))
+	seq := mrnSequencePattern.FindStringIndex(rendered)
+	prefix := rendered[:seq[0]] + "#" + rendered[seq[1]:]
+
+	var value int64
+	if err := tx.Raw(`INSERT INTO mrn_sequences (prefix, last_value) VALUES (?, 1)
+		ON CONFLICT (prefix) DO UPDATE SET last_value = mrn_sequences.last_value + 1
+		RETURNING last_value`, prefix).Scan(&value).Error; err != nil {
+		return "", err
+	}
+	return rendered[:seq[0]] + fmt.Sprintf("%0*d", width, value) + rendered[seq[1]:], nil
+}
+
+// AssignMissingMRNs gives every patient registered before medical record
+// numbers existed an MRN from their registration year, then makes the column
+// mandatory. It does nothing once all patients have one.
+func AssignMissingMRNs(db *database.DB, cfg config.Config) error {
+	for {
+		var patients []models.Patient
+		if err := db.Conn.Select("id", "created_at").Where("mrn IS NULL OR mrn = ''").
+			Order("created_at").Limit(500).Find(&patients).Error; err != nil {
+			return err
+		}
+		if len(patients) == 0 {
+			break
+		}
+		err := db.Conn.Transaction(func(tx *gorm.DB) error {
+			for _, patient := range patients {
+				mrn, err := nextMRN(tx, cfg.PatientConfig.MRNFormat, patient.CreatedAt)
+				if err != nil {
+					return err
+				}
+				if err := tx.Model(&models.Patient{}).Where("id = ?", patient.ID).Update("mrn", mrn).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return db.Conn.Exec("ALTER TABLE patients ALTER COLUMN mrn SET NOT NULL").Error
+}
@@ -0,0 +1,252 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"hospital/internal/config"
+	"hospital/internal/database"
+	"hospital/internal/models"
+	"hospital/internal/storage"
+)
+
+// defaultMaxUploadBytes applies when documents.max_upload_bytes is not set.
+const defaultMaxUploadBytes = 20 << 20
+
+// defaultAllowedTypes applies when documents.allowed_types is not set.
+var defaultAllowedTypes = []string{"application/pdf", "image/jpeg", "image/png", "image/tiff"}
+
+// ErrDocumentTooLarge is returned when an upload exceeds the configured size limit.
+var ErrDocumentTooLarge = errors.New("document exceeds the maximum upload size")
+
+// PatientDocumentService stores files attached to patients. Callers check the
+// user's access to the patient first.
+type PatientDocumentService interface {
+	UploadDocument(userID, patientID uuid.UUID, upload *DocumentUpload) (*models.PatientDocument, error)
+	GetDocuments(patientID uuid.UUID, category string) ([]models.PatientDocument, error)
+	GetDocument(patientID, documentID uuid.UUID) (*models.PatientDocument, error)
+	OpenDocument(document *models.PatientDocument) (io.ReadCloser, error)
+	DeleteDocument(userID, patientID, documentID uuid.UUID) error
+	MaxUploadBytes() int64
+}
+
+// DocumentUpload is a file received from a client. Size is the length the
+// client announced; the stored size is what was actually read.
+type DocumentUpload struct {
+	Category string
+	Title    string
+	FileName string
+	Size     int64
+	Content  io.Reader
+}
+
+type patientDocumentService struct {
+	db    *database.DB
+	cfg   config.Config
+	store storage.BlobStore
+}
+
+func NewPatientDocumentService(db *database.DB, cfg config.Config, store storage.BlobStore) PatientDocumentService {
+	return &patientDocumentService{
+		db:    db,
+		cfg:   cfg,
+		store: store,
+	}
+}
+
+func (s *patientDocumentService) MaxUploadBytes() int64 {
+	if s.cfg.DocumentConfig.MaxUploadBytes > 0 {
+		return s.cfg.DocumentConfig.MaxUploadBytes
+	}
+	return defaultMaxUploadBytes
+}
+
+func (s *patientDocumentService) UploadDocument(userID, patientID uuid.UUID, upload *DocumentUpload) (*models.PatientDocument, error) {
+	if upload.Category == "" {
+		upload.Category = "other"
+	}
+	if !models.PatientDocumentCategories[upload.Category] {
+		return nil, errors.New("category must be one of referral_letter, consent_form, external_report, insurance_card or other")
+	}
+	fileName := path.Base(strings.ReplaceAll(upload.FileName, "\\", "/"))
+	if fileName == "." || fileName == "/" {
+		return nil, errors.New("file name is required")
+	}
+	if upload.Title = strings.TrimSpace(upload.Title); upload.Title == "" {
+		upload.Title = fileName
+	}
+	limit := s.MaxUploadBytes()
+	if upload.Size > limit {
+		return nil, ErrDocumentTooLarge
+	}
+
+	var patient models.Patient
+	if err := s.db.Conn.Select("id").First(&patient, "id = ?", patientID).Error; err != nil {
+		return nil, err
+	}
+
+	// The type is decided from the leading bytes, never from the file name or
+	// the type the client sent.
+	content := bufio.NewReaderSize(upload.Content, 512)
+	head, err := content.Peek(512)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if len(head) == 0 {
+		return nil, errors.New("file is empty")
+	}
+	contentType := sniffContentType(head)
+	if !s.allowedType(contentType) {
+		return nil, fmt.Errorf("file type %s is not allowed", contentType)
+	}
+
+	document := models.PatientDocument{
+		ID:          uuid.New(),
+		PatientID:   patientID,
+		UploadedBy:  userID,
+		Category:    upload.Category,
+		Title:       upload.Title,
+		FileName:    fileName,
+		ContentType: contentType,
+	}
+	document.StorageKey = "patients/" + patientID.String() + "/documents/" + document.ID.String()
+
+	// Read one byte past the limit so an oversized body is detected even when
+	// the announced size was wrong.
+	hash := sha256.New()
+	counted := &countingReader{r: io.LimitReader(content, limit+1)}
+	body := io.TeeReader(counted, hash)
+	ctx := context.Background()
+	if err := s.store.Put(ctx, document.StorageKey, body, -1, contentType); err != nil {
+		return nil, err
+	}
+	if counted.n > limit {
+		s.deleteBlob(document.StorageKey)
+		return nil, ErrDocumentTooLarge
+	}
+	document.Size = counted.n
+	document.SHA256 = hex.EncodeToString(hash.Sum(nil))
+
+	err = s.db.Conn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&document).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, userID, "patient_document.uploaded", "patient_document", document.ID, map[string]any{
+			"patient_id": patientID,
+			"sha256":     document.SHA256,
+		})
+	})
+	if err != nil {
+		s.deleteBlob(document.StorageKey)
+		return nil, err
+	}
+	return &document, nil
+}
+
+func (s *patientDocumentService) GetDocuments(patientID uuid.UUID, category string) ([]models.PatientDocument, error) {
+	query := s.db.Conn.Where("patient_id = ?", patientID)
+	if category != "" {
+		if !models.PatientDocumentCategories[category] {
+			return nil, errors.New("category must be one of referral_letter, consent_form, external_report, insurance_card or other")
+		}
+		query = query.Where("category = ?", category)
+	}
+
+	var documents []models.PatientDocument
+	if err := query.Order("created_at DESC").Find(&documents).Error; err != nil {
+		return nil, err
+	}
+	return documents, nil
+}
+
+func (s *patientDocumentService) GetDocument(patientID, documentID uuid.UUID) (*models.PatientDocument, error) {
+	var document models.PatientDocument
+	if err := s.db.Conn.First(&document, "id = ? AND patient_id = ?", documentID, patientID).Error; err != nil {
+		return nil, err
+	}
+	return &document, nil
+}
+
+func (s *patientDocumentService) OpenDocument(document *models.PatientDocument) (io.ReadCloser, error) {
+	return s.store.Get(context.Background(), document.StorageKey)
+}
+
+// DeleteDocument removes the record and then the stored file. A file that
+// cannot be removed is only logged, since the record is already gone.
+func (s *patientDocumentService) DeleteDocument(userID, patientID, documentID uuid.UUID) error {
+	var document models.PatientDocument
+	err := s.db.Conn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&document, "id = ? AND patient_id = ?", documentID, patientID).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&document).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, userID, "patient_document.deleted", "patient_document", document.ID, map[string]any{
+			"patient_id": patientID,
+			"file_name":  document.FileName,
+			"sha256":     document.SHA256,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	s.deleteBlob(document.StorageKey)
+	return nil
+}
+
+func (s *patientDocumentService) allowedType(contentType string) bool {
+	allowedTypes := s.cfg.DocumentConfig.AllowedTypes
+	if len(allowedTypes) == 0 {
+		allowedTypes = defaultAllowedTypes
+	}
+	for _, allowed := range allowedTypes {
+		if allowed == contentType {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *patientDocumentService) deleteBlob(key string) {
+	if err := s.store.Delete(context.Background(), key); err != nil {
+		slog.Error("Failed to delete blob", "key", key, "error", err.Error())
+	}
+}
+
+// sniffContentType detects the MIME type of a file from its first bytes. It
+// adds TIFF, common for scanned documents, which net/http does not detect.
+func sniffContentType(head []byte) string {
+	if bytes.HasPrefix(head, []byte("II*\x00")) || bytes.HasPrefix(head, []byte("MM\x00*")) {
+		return "image/tiff"
+	}
+	contentType := http.DetectContentType(head)
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = contentType[:i]
+	}
+	return contentType
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
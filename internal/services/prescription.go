@@ -0,0 +1,403 @@
+package services
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"hospital/internal/models"
+)
+
+func (s *doctorService) SearchFormulary(query string, limit int) ([]models.FormularyItem, error) {
+	var items []models.FormularyItem
+	db := s.db.Conn.Where("active = ?", true)
+	if query = strings.TrimSpace(query); query != "" {
+		pattern := "%" + escapeLike(strings.ToLower(query)) + "%"
+		db = db.Where("generic_name LIKE ? OR LOWER(brand_names::text) LIKE ?", pattern, pattern)
+	}
+	if err := db.Order("generic_name, strength").Limit(limit).Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (s *doctorService) GetPrescription(doctorID, prescriptionID uuid.UUID) (*models.Prescription, error) {
+	var prescription models.Prescription
+	if err := s.db.Conn.Preload("FormularyItem").Preload("Doctor").
+		First(&prescription, "id = ?", prescriptionID).Error; err != nil {
+		return nil, err
+	}
+	if _, err := s.findPatientForDoctor(doctorID, prescription.PatientID); err != nil {
+		return nil, err
+	}
+	return &prescription, nil
+}
+
+// UpdatePrescription changes a single active prescription. Only the prescribing
+// doctor may edit it; the previous state stays available in the version history.
+func (s *doctorService) UpdatePrescription(doctorID, prescriptionID uuid.UUID, input *models.Prescription) (*models.Prescription, error) {
+	existing, err := s.GetPrescription(doctorID, prescriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if existing.DoctorID != doctorID {
+		return nil, errors.New("only the prescribing doctor can edit this prescription")
+	}
+	if existing.Status != "active" {
+		return nil, errors.New("only active prescriptions can be edited")
+	}
+
+	updated := *existing
+	updated.FormularyItemID = input.FormularyItemID
+	updated.FormularyItem = nil
+	updated.FreeText = input.FreeText
+	updated.Medication = input.Medication
+	updated.Dosage = input.Dosage
+	updated.Instructions = input.Instructions
+	updated.Dose = input.Dose
+	updated.DoseUnit = input.DoseUnit
+	updated.Frequency = input.Frequency
+	updated.Route = input.Route
+	updated.DurationDays = input.DurationDays
+	updated.Quantity = input.Quantity
+	updated.RefillsAllowed = input.RefillsAllowed
+	updated.RefillIntervalDays = input.RefillIntervalDays
+	updated.AllergyOverride = input.AllergyOverride
+	updated.AllergyOverrideReason = input.AllergyOverrideReason
+	updated.InteractionOverride = input.InteractionOverride
+	updated.InteractionOverrideReason = input.InteractionOverrideReason
+	updated.Version = existing.Version + 1
+
+	err = s.db.Conn.Transaction(func(tx *gorm.DB) error {
+		if err := s.preparePrescription(tx, &updated); err != nil {
+			return err
+		}
+		check, err := s.checkPrescriptionSafety(tx, &updated, updated.ID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Omit(clause.Associations).Save(&updated).Error; err != nil {
+			return err
+		}
+		if err := recordPrescriptionVersion(tx, &updated, "updated", doctorID); err != nil {
+			return err
+		}
+		return auditOverrides(tx, &updated, check)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DiscontinuePrescription stops an active prescription. Any doctor with access
+// to the patient may discontinue it.
+func (s *doctorService) DiscontinuePrescription(doctorID, prescriptionID uuid.UUID, reason string) (*models.Prescription, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, errors.New("a discontinuation reason is required")
+	}
+	return s.closePrescription(doctorID, prescriptionID, "discontinued", reason)
+}
+
+// CompletePrescription marks an active prescription as having run its course.
+func (s *doctorService) CompletePrescription(doctorID, prescriptionID uuid.UUID) (*models.Prescription, error) {
+	return s.closePrescription(doctorID, prescriptionID, "completed", "")
+}
+
+func (s *doctorService) closePrescription(doctorID, prescriptionID uuid.UUID, status, reason string) (*models.Prescription, error) {
+	prescription, err := s.GetPrescription(doctorID, prescriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if prescription.Status != "active" {
+		return nil, errors.New("prescription is already " + prescription.Status)
+	}
+
+	now := time.Now()
+	prescription.Status = status
+	prescription.EndedAt = &now
+	prescription.DiscontinuedReason = reason
+	prescription.Version++
+
+	err = s.db.Conn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Save(prescription).Error; err != nil {
+			return err
+		}
+		return recordPrescriptionVersion(tx, prescription, status, doctorID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return prescription, nil
+}
+
+func (s *doctorService) GetPrescriptionVersions(doctorID, prescriptionID uuid.UUID) ([]models.PrescriptionVersion, error) {
+	if _, err := s.GetPrescription(doctorID, prescriptionID); err != nil {
+		return nil, err
+	}
+
+	var versions []models.PrescriptionVersion
+	if err := s.db.Conn.Where("prescription_id = ?", prescriptionID).Order("version").Find(&versions).Error; err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// verificationAlphabet is Crockford's base32, which leaves out the letters
+// easily misread on paper.
+const verificationAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// newVerificationCode returns a random 12 character code (60 bits) for
+// printing on a prescription.
+func newVerificationCode() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = verificationAlphabet[int(b[i])%len(verificationAlphabet)]
+	}
+	return string(b), nil
+}
+
+// prescriptionSet returns the prescription together with the others printed
+// on the same paper. A prescription that was never printed stands alone.
+func prescriptionSet(tx *gorm.DB, prescription *models.Prescription) ([]models.Prescription, error) {
+	set := []models.Prescription{*prescription}
+	if prescription.PrintSetID == nil {
+		return set, nil
+	}
+
+	var others []models.Prescription
+	if err := tx.Where("print_set_id = ? AND id != ?", *prescription.PrintSetID, prescription.ID).
+		Order("created_at").Find(&others).Error; err != nil {
+		return nil, err
+	}
+	return append(set, others...), nil
+}
+
+// printPrescriptionSet returns the set to print with the prescription. The
+// first time it is printed, the other prescriptions the same doctor wrote for
+// the patient that day join it unless they are already on a paper, and the
+// set is saved so reprints, verification and dispensing see the same rows.
+func printPrescriptionSet(tx *gorm.DB, prescription *models.Prescription) ([]models.Prescription, error) {
+	if prescription.PrintSetID != nil {
+		return prescriptionSet(tx, prescription)
+	}
+
+	issued := prescription.CreatedAt
+	startOfDay := time.Date(issued.Year(), issued.Month(), issued.Day(), 0, 0, 0, 0, issued.Location())
+
+	// Lock in ID order so two papers printed at once for the same day can't
+	// deadlock; the one that waits no longer sees the rows the other took.
+	var candidates []models.Prescription
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("print_set_id IS NULL AND (id = ? OR (patient_id = ? AND doctor_id = ? AND status != ? AND dispensed_at IS NULL AND created_at >= ? AND created_at < ?))",
+			prescription.ID, prescription.PatientID, prescription.DoctorID, "discontinued", startOfDay, startOfDay.Add(24*time.Hour)).
+		Order("id").Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+
+	setID := uuid.New()
+	ids := make([]uuid.UUID, 0, len(candidates))
+	included := false
+	for _, p := range candidates {
+		ids = append(ids, p.ID)
+		included = included || p.ID == prescription.ID
+	}
+	if !included {
+		// Printed concurrently as part of another paper
+		if err := tx.Select("print_set_id").First(prescription, "id = ?", prescription.ID).Error; err != nil {
+			return nil, err
+		}
+		return prescriptionSet(tx, prescription)
+	}
+	if err := tx.Model(&models.Prescription{}).Where("id IN ?", ids).Update("print_set_id", setID).Error; err != nil {
+		return nil, err
+	}
+	prescription.PrintSetID = &setID
+	return prescriptionSet(tx, prescription)
+}
+
+// recordPrescriptionVersion appends the current state of a prescription to its
+// version history.
+func recordPrescriptionVersion(tx *gorm.DB, p *models.Prescription, changeType string, changedBy uuid.UUID) error {
+	return tx.Create(&models.PrescriptionVersion{
+		PrescriptionID:     p.ID,
+		Version:            p.Version,
+		ChangeType:         changeType,
+		ChangedBy:          changedBy,
+		Medication:         p.Medication,
+		Dosage:             p.Dosage,
+		Instructions:       p.Instructions,
+		FormularyItemID:    p.FormularyItemID,
+		FreeText:           p.FreeText,
+		Dose:               p.Dose,
+		DoseUnit:           p.DoseUnit,
+		Frequency:          p.Frequency,
+		Route:              p.Route,
+		DurationDays:       p.DurationDays,
+		Quantity:           p.Quantity,
+		Status:             p.Status,
+		DiscontinuedReason: p.DiscontinuedReason,
+	}).Error
+}
+
+// preparePrescription validates the structured dosing of a prescription and
+// fills Medication and Dosage from it.
+func (s *doctorService) preparePrescription(tx *gorm.DB, prescription *models.Prescription) error {
+	if err := s.resolveMedication(tx, prescription); err != nil {
+		return err
+	}
+
+	prescription.DoseUnit = strings.ToLower(strings.TrimSpace(prescription.DoseUnit))
+	prescription.Frequency = strings.ToUpper(strings.TrimSpace(prescription.Frequency))
+	prescription.Route = strings.ToLower(strings.TrimSpace(prescription.Route))
+
+	structured := prescription.Dose != 0 || prescription.DoseUnit != "" || prescription.Frequency != ""
+	if !prescription.FreeText || structured {
+		if prescription.Dose <= 0 {
+			return errors.New("dose must be greater than zero")
+		}
+		if !models.DoseUnits[prescription.DoseUnit] {
+			return errors.New("dose_unit is not a recognised unit")
+		}
+		if _, ok := models.PrescriptionFrequencies[prescription.Frequency]; !ok {
+			return errors.New("frequency is not a recognised frequency code")
+		}
+	}
+	if prescription.DurationDays < 0 || prescription.Quantity < 0 {
+		return errors.New("duration_days and quantity cannot be negative")
+	}
+	if prescription.RefillsAllowed < 0 || prescription.RefillIntervalDays < 0 {
+		return errors.New("refills_allowed and refill_interval_days cannot be negative")
+	}
+
+	if structured {
+		prescription.Dosage = formatDosage(prescription)
+	} else if strings.TrimSpace(prescription.Dosage) == "" {
+		return errors.New("dosage is required when no structured dose is given")
+	}
+	return nil
+}
+
+// resolveMedication fills Medication from the referenced formulary item.
+// Prescriptions must reference an active formulary item unless FreeText is set.
+func (s *doctorService) resolveMedication(tx *gorm.DB, prescription *models.Prescription) error {
+	if prescription.FreeText {
+		prescription.FormularyItemID = nil
+		prescription.FormularyItem = nil
+		prescription.Medication = strings.TrimSpace(prescription.Medication)
+		if prescription.Medication == "" {
+			return errors.New("medication is required for free text prescriptions")
+		}
+		return nil
+	}
+
+	if prescription.FormularyItemID == nil {
+		return errors.New("formulary_item_id is required unless free_text is set")
+	}
+	var item models.FormularyItem
+	if err := tx.First(&item, "id = ? AND active = ?", *prescription.FormularyItemID, true).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("formulary item not found")
+		}
+		return err
+	}
+	prescription.FormularyItem = &item
+	prescription.Medication = item.DisplayName()
+	if prescription.Route == "" {
+		prescription.Route = item.Route
+	}
+	return nil
+}
+
+// checkPrescriptionSafety runs the allergy and interaction checks for a
+// prescription about to be saved. Matching allergies and severe interactions
+// are refused unless the doctor overrode them with a reason. excludeID leaves
+// out a prescription that is being replaced.
+func (s *doctorService) checkPrescriptionSafety(tx *gorm.DB, prescription *models.Prescription, excludeID uuid.UUID) (*PrescriptionCheck, error) {
+	allergies, err := s.checkAllergies(tx, prescription.PatientID, prescription.Medication)
+	if err != nil {
+		return nil, err
+	}
+	if len(allergies) > 0 {
+		if !prescription.AllergyOverride {
+			return nil, &AllergyConflictError{Conflicts: allergies}
+		}
+		if strings.TrimSpace(prescription.AllergyOverrideReason) == "" {
+			return nil, errors.New("allergy_override_reason is required when overriding an allergy")
+		}
+	} else {
+		// Nothing was overridden, so don't keep a stray override on record
+		prescription.AllergyOverride = false
+		prescription.AllergyOverrideReason = ""
+	}
+
+	interactions, err := s.checkInteractions(tx, prescription.PatientID, prescription, excludeID)
+	if err != nil {
+		return nil, err
+	}
+	if hasSevereInteraction(interactions) {
+		if !prescription.InteractionOverride {
+			return nil, &InteractionConflictError{Interactions: interactions}
+		}
+		if strings.TrimSpace(prescription.InteractionOverrideReason) == "" {
+			return nil, errors.New("interaction_override_reason is required when overriding an interaction")
+		}
+	} else {
+		prescription.InteractionOverride = false
+		prescription.InteractionOverrideReason = ""
+	}
+
+	return &PrescriptionCheck{
+		Medication:   prescription.Medication,
+		Allergies:    allergies,
+		Interactions: interactions,
+	}, nil
+}
+
+// auditOverrides records every safety check the doctor overrode for a saved
+// prescription.
+func auditOverrides(tx *gorm.DB, prescription *models.Prescription, check *PrescriptionCheck) error {
+	if prescription.AllergyOverride {
+		if err := recordAudit(tx, prescription.DoctorID, "prescription.allergy_override", "prescription", prescription.ID, map[string]any{
+			"medication": prescription.Medication,
+			"reason":     prescription.AllergyOverrideReason,
+			"conflicts":  check.Allergies,
+		}); err != nil {
+			return err
+		}
+	}
+	if prescription.InteractionOverride {
+		if err := recordAudit(tx, prescription.DoctorID, "prescription.interaction_override", "prescription", prescription.ID, map[string]any{
+			"medication":   prescription.Medication,
+			"reason":       prescription.InteractionOverrideReason,
+			"interactions": check.Interactions,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// formatDosage renders the structured dose as a sentence, e.g.
+// "1 tablet oral twice daily for 7 days".
+func formatDosage(p *models.Prescription) string {
+	parts := []string{strconv.FormatFloat(p.Dose, 'f', -1, 64), p.DoseUnit}
+	if p.Route != "" {
+		parts = append(parts, p.Route)
+	}
+	parts = append(parts, models.PrescriptionFrequencies[p.Frequency])
+	if p.DurationDays > 0 {
+		parts = append(parts, fmt.Sprintf("for %d days", p.DurationDays))
+	}
+	return strings.Join(parts, " ")
+}
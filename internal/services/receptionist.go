@@ -14,9 +14,10 @@ import (
 // ReceptionistServiceInterface defines the contract for receptionist operations
 type ReceptionistServiceInterface interface {
 	// Patient operations
-	CreatePatient(patient *models.Patient) error
+	CreatePatient(patient *models.Patient, primaryDoctorID uuid.UUID) error
 	GetPatients(page, limit int) ([]models.Patient, int64, error)
 	GetPatient(patientID uuid.UUID) (*models.Patient, error)
+	GetPatientByMRN(mrn string) (*models.Patient, error)
 	UpdatePatient(patientID uuid.UUID, patient *models.Patient) error
 	DeletePatient(patientID uuid.UUID) error
 
@@ -26,9 +27,25 @@ type ReceptionistServiceInterface interface {
 	CreateAppointment(appointment *models.Appointment) error
 	GetAppointments(page, limit int) ([]models.Appointment, int64, error)
 	GetAppointment(appointmentID uuid.UUID) (*models.Appointment, error)
-	UpdateAppointment(patientID uuid.UUID, appointmentID uuid.UUID, parsedTime time.Time, status string, notes string) (*models.Appointment, error)
+	UpdateAppointment(receptionistID, patientID uuid.UUID, appointmentID uuid.UUID, parsedTime time.Time, status string, notes string) (*models.Appointment, error)
 	DeleteAppointment(appointmentID uuid.UUID) error
 	GetAllAppointments() ([]models.Appointment, error)
+
+	// Refill operations
+	GetPatientPrescriptions(patientID uuid.UUID) ([]models.Prescription, error)
+	CreateRefillRequest(receptionistID, patientID, prescriptionID uuid.UUID, notes string) (*models.RefillRequest, error)
+	GetRefillRequests(patientID uuid.UUID) ([]models.RefillRequest, error)
+
+	// Care team operations
+	GetCareTeam(patientID uuid.UUID, includeEnded bool) ([]models.CareTeamMember, error)
+	AddCareTeamMember(receptionistID, patientID uuid.UUID, member *models.CareTeamMember) error
+	EndCareTeamMember(receptionistID, patientID, memberID uuid.UUID, endAt time.Time) (*models.CareTeamMember, error)
+
+	// Contact operations
+	GetContacts(patientID uuid.UUID) ([]models.PatientContact, error)
+	CreateContact(patientID uuid.UUID, contact *models.PatientContact) error
+	UpdateContact(patientID, contactID uuid.UUID, contact *models.PatientContact) (*models.PatientContact, error)
+	DeleteContact(patientID, contactID uuid.UUID) error
 }
 
 // ReceptionistService implements ReceptionistServiceInterface
@@ -47,22 +64,11 @@ func NewReceptionistService(db *database.DB, cfg config.Config) ReceptionistServ
 
 // Patient Operations
 
-func (s *ReceptionistService) CreatePatient(patient *models.Patient) error {
-	if patient.Name == "" || patient.Email == "" || patient.Phone == "" || patient.Address == "" {
-		return errors.New("name, email, phone, and address are required fields")
-	}
-
-	// Check if patient with email already exists, meaning error should be nil,
-	var existingPatient models.Patient
-	if err := s.db.Conn.Where("email = ?", patient.Email).First(&existingPatient).Error; err == nil {
-		return errors.New("patient with this email already exists")
-	}
-
-	if err := s.db.Conn.Create(patient).Error; err != nil {
-		return err
-	}
-
-	return nil
+// CreatePatient registers a patient with the given doctor as primary care-team member.
+func (s *ReceptionistService) CreatePatient(patient *models.Patient, primaryDoctorID uuid.UUID) error {
+	return s.db.Conn.Transaction(func(tx *gorm.DB) error {
+		return registerPatient(tx, s.cfg.PatientConfig.MRNFormat, patient, primaryDoctorID)
+	})
 }
 
 func (s *ReceptionistService) GetDoctor() (models.User, error) {
@@ -86,7 +92,7 @@ func (s *ReceptionistService) GetPatients(page, limit int) ([]models.Patient, in
 	offset := (page - 1) * limit
 
 	// Get patients with pagination and preload doctor information
-	if err := s.db.Conn.Preload("User").
+	if err := s.db.Conn.Scopes(preloadCareTeam).
 		Offset(offset).
 		Limit(limit).
 		Find(&patients).Error; err != nil {
@@ -99,7 +105,7 @@ func (s *ReceptionistService) GetPatients(page, limit int) ([]models.Patient, in
 func (s *ReceptionistService) GetPatient(patientID uuid.UUID) (*models.Patient, error) {
 	var patient models.Patient
 
-	if err := s.db.Conn.Preload("User").
+	if err := s.db.Conn.Scopes(preloadCareTeam, preloadContacts).
 		Where("id = ?", patientID).
 		First(&patient).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -111,6 +117,22 @@ func (s *ReceptionistService) GetPatient(patientID uuid.UUID) (*models.Patient,
 	return &patient, nil
 }
 
+// GetPatientByMRN looks up a patient by medical record number.
+func (s *ReceptionistService) GetPatientByMRN(mrn string) (*models.Patient, error) {
+	var patient models.Patient
+
+	if err := s.db.Conn.Scopes(preloadCareTeam, preloadContacts).
+		Where("mrn = ?", normalizeMRN(mrn)).
+		First(&patient).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("patient not found")
+		}
+		return nil, err
+	}
+
+	return &patient, nil
+}
+
 func (s *ReceptionistService) UpdatePatient(patientID uuid.UUID, patient *models.Patient) error {
 	// Check if patient exists
 	var existingPatient models.Patient
@@ -121,49 +143,59 @@ func (s *ReceptionistService) UpdatePatient(patientID uuid.UUID, patient *models
 		return err
 	}
 
-	// Check if email is being changed and if new email already exists
-	if patient.Email != existingPatient.Email {
-		var emailCheck models.Patient
-		if err := s.db.Conn.Where("email = ? AND id != ?", patient.Email, patientID).First(&emailCheck).Error; err == nil {
-			return errors.New("patient with this email already exists")
-		}
-	}
-
-	// Update patient
-	patient.ID = patientID // Ensure ID doesn't change
-
-	patient.UserID = existingPatient.UserID
-	if err := s.db.Conn.Save(patient).Error; err != nil {
-		return err
-	}
-
-	return nil
+	return s.db.Conn.Transaction(func(tx *gorm.DB) error {
+		return updatePatientRecord(tx, &existingPatient, patient)
+	})
 }
 
+// DeletePatient removes a patient together with their care team and
+// contacts. Clinical records, documents and billing still block the delete
+// through their foreign keys.
 func (s *ReceptionistService) DeletePatient(patientID uuid.UUID) error {
-	result := s.db.Conn.Delete(&models.Patient{}, patientID)
-	if result.Error != nil {
-		return result.Error
-	}
+	return s.db.Conn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("patient_id = ?", patientID).Delete(&models.CareTeamMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("patient_id = ?", patientID).Delete(&models.PatientContact{}).Error; err != nil {
+			return err
+		}
 
-	if result.RowsAffected == 0 {
-		return errors.New("patient not found")
-	}
+		result := tx.Delete(&models.Patient{}, patientID)
+		if result.Error != nil {
+			return result.Error
+		}
 
-	return nil
+		if result.RowsAffected == 0 {
+			return errors.New("patient not found")
+		}
+
+		return nil
+	})
 }
 
 // Appointment Operations
 
 func (s *ReceptionistService) CreateAppointment(appointment *models.Appointment) error {
+	return bookAppointment(s.db.Conn, appointment)
+}
+
+// bookAppointment checks and saves a new appointment. The doctor must be free
+// at the time.
+func bookAppointment(tx *gorm.DB, appointment *models.Appointment) error {
 	// Validate required fields
 	if appointment.PatientID == uuid.Nil || appointment.DoctorID == uuid.Nil {
 		return errors.New("patient_id and doctor_id are required")
 	}
+	if appointment.Type == "" {
+		appointment.Type = "consultation"
+	}
+	if !models.AppointmentTypes[appointment.Type] {
+		return errors.New("type must be one of consultation, follow_up, procedure or emergency")
+	}
 
 	// Check if patient exists
 	var patient models.Patient
-	if err := s.db.Conn.Where("id = ?", appointment.PatientID).First(&patient).Error; err != nil {
+	if err := tx.Where("id = ?", appointment.PatientID).First(&patient).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return errors.New("patient not found")
 		}
@@ -172,7 +204,7 @@ func (s *ReceptionistService) CreateAppointment(appointment *models.Appointment)
 
 	// Check if doctor exists and has correct role
 	var doctor models.User
-	if err := s.db.Conn.Where("id = ? AND role = ?", appointment.DoctorID, "doctor").First(&doctor).Error; err != nil {
+	if err := tx.Where("id = ? AND role = ?", appointment.DoctorID, "doctor").First(&doctor).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return errors.New("doctor not found")
 		}
@@ -181,13 +213,14 @@ func (s *ReceptionistService) CreateAppointment(appointment *models.Appointment)
 
 	// Check for conflicting appointments (same doctor, same time)
 	var existingAppointment models.Appointment
-	if err := s.db.Conn.Where("doctor_id = ? AND appointment_date = ? AND status != ?",
+	if err := tx.Where("doctor_id = ? AND appointment_date = ? AND status != ?",
 		appointment.DoctorID, appointment.AppointmentDate, "cancelled").
 		First(&existingAppointment).Error; err == nil {
 		return errors.New("doctor already has an appointment at this time")
 	}
 
-	if err := s.db.Conn.Create(appointment).Error; err != nil {
+	appointment.Version = 1
+	if err := tx.Create(appointment).Error; err != nil {
 		return err
 	}
 
@@ -265,7 +298,10 @@ func (s *ReceptionistService) GetAppointment(appointmentID uuid.UUID) (*models.A
 
 //		return nil
 //	}
-func (s *ReceptionistService) UpdateAppointment(patientID, appointmentID uuid.UUID, date time.Time, status, notes string) (*models.Appointment, error) {
+
+// UpdateAppointment reschedules an appointment or changes its status.
+// Completing it captures the appointment fee as a charge in the same transaction.
+func (s *ReceptionistService) UpdateAppointment(receptionistID, patientID, appointmentID uuid.UUID, date time.Time, status, notes string) (*models.Appointment, error) {
 	var existing models.Appointment
 	if err := s.db.Conn.Where("id = ? AND patient_id = ?", appointmentID, patientID).First(&existing).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -274,25 +310,45 @@ func (s *ReceptionistService) UpdateAppointment(patientID, appointmentID uuid.UU
 		return nil, err
 	}
 
+	err := s.db.Conn.Transaction(func(tx *gorm.DB) error {
+		return rescheduleAppointment(tx, receptionistID, &existing, date, status, notes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &existing, nil
+}
+
+// rescheduleAppointment moves an appointment or changes its status. Completing
+// it captures the appointment fee as a charge.
+func rescheduleAppointment(tx *gorm.DB, userID uuid.UUID, existing *models.Appointment, date time.Time, status, notes string) error {
 	// Check for doctor conflict if date changed
 	if !existing.AppointmentDate.Equal(date) {
 		var conflict models.Appointment
-		if err := s.db.Conn.Where("doctor_id = ? AND appointment_date = ? AND id != ? AND status != ?",
-			existing.DoctorID, date, appointmentID, "cancelled").First(&conflict).Error; err == nil {
-			return nil, errors.New("doctor already has an appointment at this time")
+		if err := tx.Where("doctor_id = ? AND appointment_date = ? AND id != ? AND status != ?",
+			existing.DoctorID, date, existing.ID, "cancelled").First(&conflict).Error; err == nil {
+			return errors.New("doctor already has an appointment at this time")
 		}
 	}
 
 	// Update fields
+	completed := existing.Status != "completed" && status == "completed"
 	existing.AppointmentDate = date
 	existing.Status = status
 	existing.Notes = notes
 
-	if err := s.db.Conn.Save(&existing).Error; err != nil {
-		return nil, err
+	if err := tx.Save(existing).Error; err != nil {
+		return err
 	}
-
-	return &existing, nil
+	// The database bumped the version
+	if err := tx.Model(&models.Appointment{}).Where("id = ?", existing.ID).Select("version").Scan(&existing.Version).Error; err != nil {
+		return err
+	}
+	if completed {
+		return captureAppointmentCharges(tx, userID, existing)
+	}
+	return nil
 }
 
 func (s *ReceptionistService) GetAllAppointments() ([]models.Appointment, error) {
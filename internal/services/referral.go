@@ -0,0 +1,287 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"hospital/internal/models"
+)
+
+var referralUrgencies = map[string]bool{"routine": true, "urgent": true, "emergency": true}
+
+// CreateReferral refers a patient of the doctor to a colleague or an external
+// provider. Documents already attached to the patient can be sent along.
+func (s *doctorService) CreateReferral(doctorID, patientID uuid.UUID, referral *models.Referral, documentIDs []uuid.UUID) error {
+	if _, err := s.findPatientForDoctor(doctorID, patientID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("patient not found")
+		}
+		return err
+	}
+
+	if referral.Reason = strings.TrimSpace(referral.Reason); referral.Reason == "" {
+		return errors.New("reason is required")
+	}
+	if referral.Urgency == "" {
+		referral.Urgency = "routine"
+	}
+	if !referralUrgencies[referral.Urgency] {
+		return errors.New("urgency must be one of routine, urgent or emergency")
+	}
+
+	if referral.IsInternal() {
+		if *referral.TargetDoctorID == doctorID {
+			return errors.New("cannot refer a patient to yourself")
+		}
+		var target models.User
+		if err := s.db.Conn.First(&target, "id = ? AND role = ?", *referral.TargetDoctorID, "doctor").Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("target doctor not found")
+			}
+			return err
+		}
+		referral.ExternalProvider = ""
+		referral.ExternalOrganization = ""
+		referral.ExternalSpecialty = ""
+		referral.ExternalPhone = ""
+		referral.ExternalEmail = ""
+	} else if strings.TrimSpace(referral.ExternalProvider) == "" {
+		return errors.New("either target_doctor_id or external_provider is required")
+	}
+
+	var documents []models.PatientDocument
+	if len(documentIDs) > 0 {
+		if err := s.db.Conn.Where("id IN ? AND patient_id = ?", documentIDs, patientID).Find(&documents).Error; err != nil {
+			return err
+		}
+		if len(documents) != len(documentIDs) {
+			return errors.New("documents must be attached to this patient")
+		}
+	}
+
+	referral.ID = uuid.Nil
+	referral.PatientID = patientID
+	referral.ReferringDoctorID = doctorID
+	referral.Status = "pending"
+	referral.DeclineReason = ""
+	referral.RespondedAt = nil
+	referral.AppointmentID = nil
+	return s.db.Conn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(referral).Error; err != nil {
+			return err
+		}
+		if len(documents) > 0 {
+			if err := tx.Model(referral).Association("Documents").Append(documents); err != nil {
+				return err
+			}
+		}
+		referral.Documents = documents
+		return recordAudit(tx, doctorID, "referral.created", "referral", referral.ID, map[string]any{
+			"patient_id":       patientID,
+			"target_doctor_id": referral.TargetDoctorID,
+		})
+	})
+}
+
+// GetReferrals lists the doctor's referrals. Direction "outgoing" lists those
+// the doctor wrote, "incoming" those addressed to the doctor, and an empty
+// direction both.
+func (s *doctorService) GetReferrals(doctorID uuid.UUID, direction, status string) ([]models.Referral, error) {
+	query := s.db.Conn.Preload("Patient").Preload("ReferringDoctor").Preload("TargetDoctor")
+	switch direction {
+	case "":
+		query = query.Where("referring_doctor_id = ? OR target_doctor_id = ?", doctorID, doctorID)
+	case "outgoing":
+		query = query.Where("referring_doctor_id = ?", doctorID)
+	case "incoming":
+		query = query.Where("target_doctor_id = ?", doctorID)
+	default:
+		return nil, errors.New("direction must be one of incoming or outgoing")
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var referrals []models.Referral
+	if err := query.Order("created_at DESC").Find(&referrals).Error; err != nil {
+		return nil, err
+	}
+	return referrals, nil
+}
+
+// GetReferral returns a referral written by or addressed to the doctor.
+func (s *doctorService) GetReferral(doctorID, referralID uuid.UUID) (*models.Referral, error) {
+	var referral models.Referral
+	if err := s.db.Conn.Preload("Patient").Preload("ReferringDoctor").Preload("TargetDoctor").
+		Preload("Appointment").Preload("Documents").
+		Where("referring_doctor_id = ? OR target_doctor_id = ?", doctorID, doctorID).
+		First(&referral, "referrals.id = ?", referralID).Error; err != nil {
+		return nil, err
+	}
+	return &referral, nil
+}
+
+// AcceptReferral accepts an internal referral addressed to the doctor, which
+// gives the doctor access to the patient's records. The patient's care team
+// stays unchanged.
+func (s *doctorService) AcceptReferral(doctorID, referralID uuid.UUID) (*models.Referral, error) {
+	return s.respondToReferral(doctorID, referralID, "accepted", "")
+}
+
+func (s *doctorService) DeclineReferral(doctorID, referralID uuid.UUID, reason string) (*models.Referral, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, errors.New("reason is required")
+	}
+	return s.respondToReferral(doctorID, referralID, "declined", reason)
+}
+
+func (s *doctorService) respondToReferral(doctorID, referralID uuid.UUID, status, reason string) (*models.Referral, error) {
+	err := s.db.Conn.Transaction(func(tx *gorm.DB) error {
+		var referral models.Referral
+		if err := lockReferral(tx, referralID, &referral); err != nil {
+			return err
+		}
+		if referral.TargetDoctorID == nil || *referral.TargetDoctorID != doctorID {
+			return gorm.ErrRecordNotFound
+		}
+		if referral.Status != "pending" {
+			return errors.New("referral has already been " + referral.Status)
+		}
+
+		now := time.Now()
+		if err := tx.Model(&referral).Updates(map[string]interface{}{
+			"status":         status,
+			"decline_reason": reason,
+			"responded_at":   now,
+		}).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, doctorID, "referral."+status, "referral", referral.ID, map[string]any{
+			"patient_id": referral.PatientID,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetReferral(doctorID, referralID)
+}
+
+// CancelReferral withdraws a pending referral the doctor wrote.
+func (s *doctorService) CancelReferral(doctorID, referralID uuid.UUID) (*models.Referral, error) {
+	err := s.db.Conn.Transaction(func(tx *gorm.DB) error {
+		var referral models.Referral
+		if err := lockReferral(tx, referralID, &referral); err != nil {
+			return err
+		}
+		if referral.ReferringDoctorID != doctorID {
+			return gorm.ErrRecordNotFound
+		}
+		if referral.Status != "pending" {
+			return errors.New("only pending referrals can be cancelled")
+		}
+		if err := tx.Model(&referral).Update("status", "cancelled").Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, doctorID, "referral.cancelled", "referral", referral.ID, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetReferral(doctorID, referralID)
+}
+
+// CompleteReferral closes a referral once the patient has been seen. Internal
+// referrals are completed by the target doctor after accepting them; external
+// ones by the referring doctor, since the provider has no access to the system.
+func (s *doctorService) CompleteReferral(doctorID, referralID uuid.UUID) (*models.Referral, error) {
+	err := s.db.Conn.Transaction(func(tx *gorm.DB) error {
+		var referral models.Referral
+		if err := lockReferral(tx, referralID, &referral); err != nil {
+			return err
+		}
+		if referral.IsInternal() {
+			if *referral.TargetDoctorID != doctorID {
+				return gorm.ErrRecordNotFound
+			}
+			if referral.Status != "accepted" {
+				return errors.New("only accepted referrals can be completed")
+			}
+		} else {
+			if referral.ReferringDoctorID != doctorID {
+				return gorm.ErrRecordNotFound
+			}
+			if referral.Status != "pending" {
+				return errors.New("referral has already been " + referral.Status)
+			}
+		}
+		if err := tx.Model(&referral).Update("status", "completed").Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, doctorID, "referral.completed", "referral", referral.ID, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetReferral(doctorID, referralID)
+}
+
+// BookReferralAppointment books the patient's first appointment with the
+// target doctor of an accepted internal referral. Either doctor on the
+// referral may book it.
+func (s *doctorService) BookReferralAppointment(doctorID, referralID uuid.UUID, date time.Time, notes string) (*models.Appointment, error) {
+	var appointment models.Appointment
+	err := s.db.Conn.Transaction(func(tx *gorm.DB) error {
+		var referral models.Referral
+		if err := lockReferral(tx, referralID, &referral); err != nil {
+			return err
+		}
+		if referral.ReferringDoctorID != doctorID && (referral.TargetDoctorID == nil || *referral.TargetDoctorID != doctorID) {
+			return gorm.ErrRecordNotFound
+		}
+		if !referral.IsInternal() {
+			return errors.New("appointments can only be booked for internal referrals")
+		}
+		if referral.Status != "accepted" {
+			return errors.New("referral must be accepted before booking")
+		}
+		if referral.AppointmentID != nil {
+			return errors.New("an appointment has already been booked for this referral")
+		}
+		if date.Before(time.Now()) {
+			return errors.New("appointment date must be in the future")
+		}
+
+		if notes == "" {
+			notes = "Referral: " + referral.Reason
+		}
+		appointment = models.Appointment{
+			PatientID:       referral.PatientID,
+			DoctorID:        *referral.TargetDoctorID,
+			AppointmentDate: date,
+			Status:          "scheduled",
+			Notes:           notes,
+		}
+		if err := bookAppointment(tx, &appointment); err != nil {
+			return err
+		}
+		if err := tx.Model(&referral).Update("appointment_id", appointment.ID).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, doctorID, "referral.appointment_booked", "referral", referral.ID, map[string]any{
+			"appointment_id": appointment.ID,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &appointment, nil
+}
+
+func lockReferral(tx *gorm.DB, referralID uuid.UUID, referral *models.Referral) error {
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(referral, "id = ?", referralID).Error
+}
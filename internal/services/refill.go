@@ -0,0 +1,237 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"hospital/internal/models"
+)
+
+// Receptionist side
+
+func (s *ReceptionistService) GetPatientPrescriptions(patientID uuid.UUID) ([]models.Prescription, error) {
+	var prescriptions []models.Prescription
+	if err := s.db.Conn.Preload("Doctor").Where("patient_id = ?", patientID).
+		Order("created_at DESC").Find(&prescriptions).Error; err != nil {
+		return nil, err
+	}
+	return prescriptions, nil
+}
+
+func (s *ReceptionistService) CreateRefillRequest(receptionistID, patientID, prescriptionID uuid.UUID, notes string) (*models.RefillRequest, error) {
+	var request models.RefillRequest
+	err := s.db.Conn.Transaction(func(tx *gorm.DB) error {
+		original, err := refillOriginal(tx, patientID, prescriptionID)
+		if err != nil {
+			return err
+		}
+
+		var pending int64
+		if err := tx.Model(&models.RefillRequest{}).
+			Where("prescription_id = ? AND status = ?", original.ID, "pending").
+			Count(&pending).Error; err != nil {
+			return err
+		}
+		if pending > 0 {
+			return errors.New("a refill request for this prescription is already pending")
+		}
+		if _, err := nextRefillNumber(tx, original); err != nil {
+			return err
+		}
+
+		request = models.RefillRequest{
+			PrescriptionID: original.ID,
+			PatientID:      patientID,
+			DoctorID:       original.DoctorID,
+			RequestedBy:    receptionistID,
+			Status:         "pending",
+			Notes:          notes,
+		}
+		return tx.Create(&request).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+func (s *ReceptionistService) GetRefillRequests(patientID uuid.UUID) ([]models.RefillRequest, error) {
+	var requests []models.RefillRequest
+	if err := s.db.Conn.Preload("Prescription").Where("patient_id = ?", patientID).
+		Order("created_at DESC").Find(&requests).Error; err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+// Doctor side
+
+// GetPendingRefills is the doctor's refill inbox: pending requests for
+// prescriptions the doctor wrote, oldest first.
+func (s *doctorService) GetPendingRefills(doctorID uuid.UUID) ([]models.RefillRequest, error) {
+	var requests []models.RefillRequest
+	if err := s.db.Conn.Preload("Prescription").Preload("Patient").
+		Where("doctor_id = ? AND status = ?", doctorID, "pending").
+		Order("created_at").Find(&requests).Error; err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+// ApproveRefill issues the next refill as a new prescription linked to the
+// original one.
+func (s *doctorService) ApproveRefill(doctorID, refillID uuid.UUID) (*models.RefillRequest, error) {
+	var request models.RefillRequest
+	err := s.db.Conn.Transaction(func(tx *gorm.DB) error {
+		if err := pendingRefill(tx, doctorID, refillID, &request); err != nil {
+			return err
+		}
+
+		// Lock the original so concurrent approvals count each other's refills
+		var original models.Prescription
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("FormularyItem").
+			First(&original, "id = ?", request.PrescriptionID).Error; err != nil {
+			return err
+		}
+		number, err := nextRefillNumber(tx, &original)
+		if err != nil {
+			return err
+		}
+		code, err := newVerificationCode()
+		if err != nil {
+			return err
+		}
+
+		refill := original
+		refill.ID = uuid.Nil
+		refill.Status = "active"
+		refill.Version = 1
+		refill.VerificationCode = code
+		refill.DispensedAt = nil
+		refill.DispensedBy = nil
+		refill.EndedAt = nil
+		refill.DiscontinuedReason = ""
+		refill.RefillsAllowed = 0
+		refill.RefillIntervalDays = 0
+		refill.RefillOfID = &original.ID
+		refill.RefillNumber = number
+		refill.CreatedAt = time.Time{}
+		refill.UpdatedAt = time.Time{}
+
+		// The original's overrides carry over, but anything new since it was
+		// written still blocks the refill.
+		check, err := s.checkPrescriptionSafety(tx, &refill, original.ID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Omit(clause.Associations).Create(&refill).Error; err != nil {
+			return err
+		}
+		if err := recordPrescriptionVersion(tx, &refill, "created", doctorID); err != nil {
+			return err
+		}
+		if err := auditOverrides(tx, &refill, check); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		request.Status = "approved"
+		request.ReviewedAt = &now
+		request.ResultingPrescriptionID = &refill.ID
+		return tx.Omit(clause.Associations).Save(&request).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+func (s *doctorService) DenyRefill(doctorID, refillID uuid.UUID, reason string) (*models.RefillRequest, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, errors.New("a reason is required to deny a refill")
+	}
+
+	var request models.RefillRequest
+	err := s.db.Conn.Transaction(func(tx *gorm.DB) error {
+		if err := pendingRefill(tx, doctorID, refillID, &request); err != nil {
+			return err
+		}
+		now := time.Now()
+		request.Status = "denied"
+		request.DenialReason = reason
+		request.ReviewedAt = &now
+		return tx.Omit(clause.Associations).Save(&request).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// pendingRefill loads and locks a pending refill request addressed to the doctor.
+func pendingRefill(tx *gorm.DB, doctorID, refillID uuid.UUID, request *models.RefillRequest) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(request, "id = ? AND doctor_id = ?", refillID, doctorID).Error; err != nil {
+		return err
+	}
+	if request.Status != "pending" {
+		return errors.New("refill request has already been " + request.Status)
+	}
+	return nil
+}
+
+// refillOriginal resolves the prescription a refill is requested for to the
+// original prescription of its refill chain, locked until tx commits.
+func refillOriginal(tx *gorm.DB, patientID, prescriptionID uuid.UUID) (*models.Prescription, error) {
+	var prescription models.Prescription
+	if err := tx.First(&prescription, "id = ? AND patient_id = ?", prescriptionID, patientID).Error; err != nil {
+		return nil, err
+	}
+	originalID := prescription.ID
+	if prescription.RefillOfID != nil {
+		originalID = *prescription.RefillOfID
+	}
+
+	var original models.Prescription
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&original, "id = ?", originalID).Error; err != nil {
+		return nil, err
+	}
+	return &original, nil
+}
+
+// nextRefillNumber returns the number of the next refill of the original
+// prescription, or an error if no further refill may be issued yet.
+func nextRefillNumber(tx *gorm.DB, original *models.Prescription) (int, error) {
+	if original.Status == "discontinued" {
+		return 0, errors.New("prescription has been discontinued")
+	}
+	if original.RefillsAllowed == 0 {
+		return 0, errors.New("prescription does not allow refills")
+	}
+
+	var issued []models.Prescription
+	if err := tx.Where("refill_of_id = ?", original.ID).Order("created_at DESC").Find(&issued).Error; err != nil {
+		return 0, err
+	}
+	if len(issued) >= original.RefillsAllowed {
+		return 0, fmt.Errorf("all %d refills allowed for this prescription have been used", original.RefillsAllowed)
+	}
+
+	if original.RefillIntervalDays > 0 {
+		last := original.CreatedAt
+		if len(issued) > 0 {
+			last = issued[0].CreatedAt
+		}
+		due := last.AddDate(0, 0, original.RefillIntervalDays)
+		if time.Now().Before(due) {
+			return 0, fmt.Errorf("next refill is not due until %s", due.Format("02/01/2006"))
+		}
+	}
+	return len(issued) + 1, nil
+}
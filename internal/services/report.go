@@ -0,0 +1,247 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/google/uuid"
+
+	"hospital/internal/config"
+	"hospital/internal/database"
+	"hospital/internal/models"
+)
+
+// ReportService produces the financial reports. Every report is aggregated in
+// SQL over its date range so no report loads individual invoices or payments.
+// Date ranges are inclusive and days follow the database session's time zone.
+type ReportService interface {
+	Revenue(period string, from, to models.Date) ([]RevenueRow, error)
+	RevenueByDoctor(from, to models.Date) ([]DoctorRevenueRow, error)
+	ReceivablesAging(asOf models.Date) ([]AgingRow, error)
+}
+
+// Revenue reports list every period in their range, so the range is capped.
+const (
+	maxReportDays  = 366
+	maxReportYears = 10
+)
+
+// reportPeriods maps the supported revenue periods to how they are labelled.
+var reportPeriods = map[string]string{"day": "YYYY-MM-DD", "month": "YYYY-MM"}
+
+// RevenueRow is one day or month of revenue. Billed amounts come from the
+// invoices issued in the period, collected amounts from the payments received.
+type RevenueRow struct {
+	Period    string `json:"period"`
+	Invoices  int64  `json:"invoices"`
+	Subtotal  int64  `json:"subtotal"`
+	Tax       int64  `json:"tax"`
+	Billed    int64  `json:"billed"`
+	Cash      int64  `json:"cash"`
+	Card      int64  `json:"card"`
+	Insurance int64  `json:"insurance"`
+	Collected int64  `json:"collected"`
+	Refunded  int64  `json:"refunded"`
+	Net       int64  `json:"net"` // Collected minus refunded
+}
+
+func (RevenueRow) CSVHeader() []string {
+	return []string{"period", "invoices", "subtotal", "tax", "billed", "cash", "card", "insurance", "collected", "refunded", "net"}
+}
+
+func (r RevenueRow) CSVRecord() []string {
+	return []string{r.Period, strconv.FormatInt(r.Invoices, 10), formatAmount(r.Subtotal), formatAmount(r.Tax),
+		formatAmount(r.Billed), formatAmount(r.Cash), formatAmount(r.Card), formatAmount(r.Insurance),
+		formatAmount(r.Collected), formatAmount(r.Refunded), formatAmount(r.Net)}
+}
+
+// DoctorRevenueRow is what was billed for one doctor's encounters. Charges not
+// tied to an appointment are reported without a doctor.
+type DoctorRevenueRow struct {
+	DoctorID   *uuid.UUID `json:"doctor_id,omitempty"`
+	DoctorName string     `json:"doctor_name"`
+	Encounters int64      `json:"encounters"`
+	Invoices   int64      `json:"invoices"`
+	Subtotal   int64      `json:"subtotal"`
+	Tax        int64      `json:"tax"`
+	Billed     int64      `json:"billed"`
+	// Collected shares each invoice's net payments across its lines in
+	// proportion to their totals
+	Collected int64 `json:"collected"`
+}
+
+func (DoctorRevenueRow) CSVHeader() []string {
+	return []string{"doctor_id", "doctor_name", "encounters", "invoices", "subtotal", "tax", "billed", "collected"}
+}
+
+func (r DoctorRevenueRow) CSVRecord() []string {
+	doctorID := ""
+	if r.DoctorID != nil {
+		doctorID = r.DoctorID.String()
+	}
+	return []string{doctorID, r.DoctorName, strconv.FormatInt(r.Encounters, 10), strconv.FormatInt(r.Invoices, 10),
+		formatAmount(r.Subtotal), formatAmount(r.Tax), formatAmount(r.Billed), formatAmount(r.Collected)}
+}
+
+// AgingRow is one patient's outstanding balance split by how long it is
+// overdue. Invoices not yet due are current.
+type AgingRow struct {
+	PatientID   uuid.UUID `json:"patient_id"`
+	PatientName string    `json:"patient_name"`
+	MRN         string    `gorm:"column:mrn" json:"mrn"`
+	Invoices    int64     `json:"invoices"`
+	Current     int64     `json:"current"`
+	Days1To30   int64     `gorm:"column:days_1_30" json:"days_1_30"`
+	Days31To60  int64     `gorm:"column:days_31_60" json:"days_31_60"`
+	Days61To90  int64     `gorm:"column:days_61_90" json:"days_61_90"`
+	Over90      int64     `gorm:"column:over_90" json:"over_90"`
+	Total       int64     `json:"total"`
+}
+
+func (AgingRow) CSVHeader() []string {
+	return []string{"patient_id", "patient_name", "mrn", "invoices", "current", "days_1_30", "days_31_60", "days_61_90", "over_90", "total"}
+}
+
+func (r AgingRow) CSVRecord() []string {
+	return []string{r.PatientID.String(), r.PatientName, r.MRN, strconv.FormatInt(r.Invoices, 10),
+		formatAmount(r.Current), formatAmount(r.Days1To30), formatAmount(r.Days31To60),
+		formatAmount(r.Days61To90), formatAmount(r.Over90), formatAmount(r.Total)}
+}
+
+type reportService struct {
+	db  *database.DB
+	cfg config.Config
+}
+
+func NewReportService(db *database.DB, cfg config.Config) ReportService {
+	return &reportService{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// Revenue reports billing and collections for every day or month from from
+// through to, including periods without any activity.
+func (s *reportService) Revenue(period string, from, to models.Date) ([]RevenueRow, error) {
+	label, ok := reportPeriods[period]
+	if !ok {
+		return nil, errors.New("period must be day or month")
+	}
+	if to.Before(from.Time) {
+		return nil, errors.New("to must not be before from")
+	}
+	if period == "month" {
+		// Whole months, so the first and last rows aren't partial
+		from = models.NewDate(from.AddDate(0, 0, 1-from.Day()))
+		to = models.NewDate(to.AddDate(0, 1, -to.Day()))
+		if to.After(from.AddDate(maxReportYears, 0, 0)) {
+			return nil, fmt.Errorf("monthly revenue covers at most %d years", maxReportYears)
+		}
+	} else if to.After(from.AddDate(0, 0, maxReportDays-1)) {
+		return nil, fmt.Errorf("daily revenue covers at most %d days", maxReportDays)
+	}
+
+	// period is one of the fixed keys of reportPeriods, so it is safe to use
+	// as date_trunc's unit
+	rows := []RevenueRow{}
+	err := s.db.Conn.Raw(`WITH periods AS (
+			SELECT generate_series(date_trunc('`+period+`', CAST(@from AS date)), CAST(@to AS date), interval '1 `+period+`')::date AS period
+		), billed AS (
+			SELECT date_trunc('`+period+`', issued_at)::date AS period, COUNT(*) AS invoices,
+				SUM(subtotal) AS subtotal, SUM(tax) AS tax, SUM(total) AS total
+			FROM invoices
+			WHERE status <> 'void' AND issued_at >= CAST(@from AS date) AND issued_at < CAST(@to AS date) + 1
+			GROUP BY 1
+		), collected AS (
+			SELECT date_trunc('`+period+`', received_at)::date AS period,
+				SUM(amount) FILTER (WHERE method = 'cash') AS cash,
+				SUM(amount) FILTER (WHERE method = 'card') AS card,
+				SUM(amount) FILTER (WHERE method = 'insurance') AS insurance,
+				SUM(amount) AS total
+			FROM payments
+			WHERE received_at >= CAST(@from AS date) AND received_at < CAST(@to AS date) + 1
+			GROUP BY 1
+		), refunded AS (
+			SELECT date_trunc('`+period+`', refunded_at)::date AS period, SUM(amount) AS total
+			FROM refunds
+			WHERE refunded_at >= CAST(@from AS date) AND refunded_at < CAST(@to AS date) + 1
+			GROUP BY 1
+		)
+		SELECT to_char(p.period, @label) AS period,
+			COALESCE(b.invoices, 0) AS invoices,
+			COALESCE(b.subtotal, 0) AS subtotal,
+			COALESCE(b.tax, 0) AS tax,
+			COALESCE(b.total, 0) AS billed,
+			COALESCE(c.cash, 0) AS cash,
+			COALESCE(c.card, 0) AS card,
+			COALESCE(c.insurance, 0) AS insurance,
+			COALESCE(c.total, 0) AS collected,
+			COALESCE(r.total, 0) AS refunded,
+			COALESCE(c.total, 0) - COALESCE(r.total, 0) AS net
+		FROM periods p
+		LEFT JOIN billed b ON b.period = p.period
+		LEFT JOIN collected c ON c.period = p.period
+		LEFT JOIN refunded r ON r.period = p.period
+		ORDER BY p.period`,
+		map[string]any{"from": from, "to": to, "label": label}).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// RevenueByDoctor reports what was billed for each doctor's encounters on
+// invoices issued from from through to.
+func (s *reportService) RevenueByDoctor(from, to models.Date) ([]DoctorRevenueRow, error) {
+	if to.Before(from.Time) {
+		return nil, errors.New("to must not be before from")
+	}
+
+	rows := []DoctorRevenueRow{}
+	err := s.db.Conn.Raw(`SELECT a.doctor_id, COALESCE(u.name, 'No doctor') AS doctor_name,
+			COUNT(DISTINCT a.id) AS encounters,
+			COUNT(DISTINCT i.id) AS invoices,
+			SUM(l.subtotal) AS subtotal,
+			SUM(l.tax) AS tax,
+			SUM(l.total) AS billed,
+			COALESCE(ROUND(SUM(l.total::numeric * i.amount_paid / NULLIF(i.total, 0))), 0)::bigint AS collected
+		FROM invoice_lines l
+		JOIN invoices i ON i.id = l.invoice_id
+		LEFT JOIN charges c ON c.id = l.charge_id
+		LEFT JOIN appointments a ON a.id = c.appointment_id
+		LEFT JOIN users u ON u.id = a.doctor_id
+		WHERE i.status <> 'void' AND i.issued_at >= CAST(@from AS date) AND i.issued_at < CAST(@to AS date) + 1
+		GROUP BY a.doctor_id, u.name
+		ORDER BY billed DESC`,
+		map[string]any{"from": from, "to": to}).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// ReceivablesAging buckets every patient's unpaid invoice balances by how many
+// days past due they are on asOf. Balances are as they stand now.
+func (s *reportService) ReceivablesAging(asOf models.Date) ([]AgingRow, error) {
+	rows := []AgingRow{}
+	err := s.db.Conn.Raw(`SELECT i.patient_id, p.name AS patient_name, p.mrn,
+			COUNT(*) AS invoices,
+			COALESCE(SUM(i.total - i.amount_paid) FILTER (WHERE i.due_date >= CAST(@as_of AS date)), 0) AS current,
+			COALESCE(SUM(i.total - i.amount_paid) FILTER (WHERE CAST(@as_of AS date) - i.due_date BETWEEN 1 AND 30), 0) AS days_1_30,
+			COALESCE(SUM(i.total - i.amount_paid) FILTER (WHERE CAST(@as_of AS date) - i.due_date BETWEEN 31 AND 60), 0) AS days_31_60,
+			COALESCE(SUM(i.total - i.amount_paid) FILTER (WHERE CAST(@as_of AS date) - i.due_date BETWEEN 61 AND 90), 0) AS days_61_90,
+			COALESCE(SUM(i.total - i.amount_paid) FILTER (WHERE CAST(@as_of AS date) - i.due_date > 90), 0) AS over_90,
+			SUM(i.total - i.amount_paid) AS total
+		FROM invoices i
+		JOIN patients p ON p.id = i.patient_id
+		WHERE i.status IN ('issued', 'partially_paid') AND i.total > i.amount_paid
+			AND i.issued_at < CAST(@as_of AS date) + 1
+		GROUP BY i.patient_id, p.name, p.mrn
+		ORDER BY total DESC`,
+		map[string]any{"as_of": asOf}).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
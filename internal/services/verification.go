@@ -0,0 +1,187 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"hospital/internal/config"
+	"hospital/internal/database"
+	"hospital/internal/models"
+)
+
+// VerificationService answers pharmacy checks of paper prescriptions. Its
+// responses carry no patient details.
+type VerificationService interface {
+	VerifyPrescription(code string) (*PrescriptionVerification, error)
+	DispensePrescription(code string, apiKeyID uuid.UUID) (*PrescriptionVerification, error)
+}
+
+// PrescriptionVerification is the public confirmation that a printed
+// prescription is genuine.
+type PrescriptionVerification struct {
+	Code          string                   `json:"code"`
+	Doctor        string                   `json:"doctor"`
+	LicenseNumber string                   `json:"license_number,omitempty"`
+	IssuedAt      time.Time                `json:"issued_at"`
+	Medications   []VerificationMedication `json:"medications"`
+	Dispensed     bool                     `json:"dispensed"`
+	DispensedAt   *time.Time               `json:"dispensed_at,omitempty"`
+}
+
+type VerificationMedication struct {
+	Medication string  `json:"medication"`
+	Dosage     string  `json:"dosage"`
+	Quantity   float64 `json:"quantity,omitempty"`
+	Status     string  `json:"status"`
+}
+
+type verificationService struct {
+	db  *database.DB
+	cfg config.Config
+}
+
+func NewVerificationService(db *database.DB, cfg config.Config) VerificationService {
+	return &verificationService{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+func (s *verificationService) VerifyPrescription(code string) (*PrescriptionVerification, error) {
+	prescription, err := s.findByCode(s.db.Conn, code)
+	if err != nil {
+		return nil, err
+	}
+	set, err := prescriptionSet(s.db.Conn, prescription)
+	if err != nil {
+		return nil, err
+	}
+	return newPrescriptionVerification(prescription, set), nil
+}
+
+// DispensePrescription marks every prescription printed on the same paper as
+// dispensed, so it cannot be filled a second time.
+func (s *verificationService) DispensePrescription(code string, apiKeyID uuid.UUID) (*PrescriptionVerification, error) {
+	var verification *PrescriptionVerification
+	err := s.db.Conn.Transaction(func(tx *gorm.DB) error {
+		prescription, err := s.findByCode(tx, code)
+		if err != nil {
+			return err
+		}
+		if prescription.DispensedAt != nil {
+			return errors.New("prescription has already been dispensed")
+		}
+		if prescription.Status == "discontinued" {
+			return errors.New("prescription has been discontinued")
+		}
+
+		set, err := prescriptionSet(tx, prescription)
+		if err != nil {
+			return err
+		}
+		ids := make([]uuid.UUID, 0, len(set))
+		for _, p := range set {
+			if p.DispensedAt == nil && p.Status != "discontinued" {
+				ids = append(ids, p.ID)
+			}
+		}
+
+		now := time.Now()
+		// A concurrent dispense of the same paper may have won the race since
+		// the set was read; only succeed if every row is still ours to mark.
+		result := tx.Model(&models.Prescription{}).Where("id IN ? AND dispensed_at IS NULL", ids).
+			Updates(map[string]interface{}{"dispensed_at": now, "dispensed_by": apiKeyID})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != int64(len(ids)) {
+			return errors.New("prescription has already been dispensed")
+		}
+		for i := range set {
+			if set[i].DispensedAt == nil && set[i].Status != "discontinued" {
+				set[i].DispensedAt = &now
+			}
+		}
+		prescription.DispensedAt = &now
+
+		if err := recordAudit(tx, uuid.Nil, "prescription.dispensed", "prescription", prescription.ID, map[string]any{
+			"api_key_id":    apiKeyID,
+			"prescriptions": ids,
+		}); err != nil {
+			return err
+		}
+		verification = newPrescriptionVerification(prescription, set)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return verification, nil
+}
+
+func (s *verificationService) findByCode(tx *gorm.DB, code string) (*models.Prescription, error) {
+	var prescription models.Prescription
+	err := tx.Preload("Doctor").
+		First(&prescription, "verification_code = ?", strings.ToUpper(strings.TrimSpace(code))).Error
+	if err != nil {
+		return nil, err
+	}
+	return &prescription, nil
+}
+
+func newPrescriptionVerification(prescription *models.Prescription, set []models.Prescription) *PrescriptionVerification {
+	verification := &PrescriptionVerification{
+		Code:          prescription.VerificationCode,
+		Doctor:        prescription.Doctor.Name,
+		LicenseNumber: prescription.Doctor.LicenseNumber,
+		IssuedAt:      prescription.CreatedAt,
+		Dispensed:     prescription.DispensedAt != nil,
+		DispensedAt:   prescription.DispensedAt,
+		Medications:   make([]VerificationMedication, 0, len(set)),
+	}
+	for _, p := range set {
+		verification.Medications = append(verification.Medications, VerificationMedication{
+			Medication: p.Medication,
+			Dosage:     p.Dosage,
+			Quantity:   p.Quantity,
+			Status:     p.Status,
+		})
+	}
+	return verification
+}
+
+// AssignMissingVerificationCodes gives every prescription written before
+// verification codes existed a code from newVerificationCode. It does nothing
+// once all prescriptions have one.
+func AssignMissingVerificationCodes(db *database.DB) error {
+	for {
+		var prescriptions []models.Prescription
+		if err := db.Conn.Select("id").Where("verification_code IS NULL OR verification_code = ''").
+			Order("created_at").Limit(500).Find(&prescriptions).Error; err != nil {
+			return err
+		}
+		if len(prescriptions) == 0 {
+			return nil
+		}
+		err := db.Conn.Transaction(func(tx *gorm.DB) error {
+			for _, prescription := range prescriptions {
+				code, err := newVerificationCode()
+				if err != nil {
+					return err
+				}
+				if err := tx.Model(&models.Prescription{}).Where("id = ?", prescription.ID).
+					Update("verification_code", code).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"hospital/internal/config"
+	"hospital/internal/database"
+	"hospital/internal/models"
+)
+
+// VitalsService records vital signs for nurses and doctors. Callers check
+// patient access before calling it.
+type VitalsService interface {
+	RecordVitals(recordedBy, patientID uuid.UUID, input *VitalsInput) (*models.Vitals, map[string]string, error)
+	GetVitalSeries(patientID uuid.UUID, typeName string, from, to time.Time) ([]VitalSeries, error)
+}
+
+// VitalsInput is a set of measurements in the units they were taken in.
+type VitalsInput struct {
+	AppointmentID   *uuid.UUID `json:"appointment_id"`
+	RecordedAt      *time.Time `json:"recorded_at"`
+	SystolicBP      *float64   `json:"systolic_bp"`
+	DiastolicBP     *float64   `json:"diastolic_bp"`
+	HeartRate       *float64   `json:"heart_rate"`
+	Temperature     *float64   `json:"temperature"`
+	TemperatureUnit string     `json:"temperature_unit"` // C (default) or F
+	SpO2            *float64   `json:"spo2"`
+	RespiratoryRate *float64   `json:"respiratory_rate"`
+	Height          *float64   `json:"height"`
+	HeightUnit      string     `json:"height_unit"` // cm (default), m or in
+	Weight          *float64   `json:"weight"`
+	WeightUnit      string     `json:"weight_unit"` // kg (default) or lb
+	Notes           string     `json:"notes"`
+}
+
+// VitalSeries is the time series of one vital sign, ready for charting.
+type VitalSeries struct {
+	Type       string       `json:"type"`
+	Unit       string       `json:"unit"`
+	NormalLow  float64      `json:"normal_low"`
+	NormalHigh float64      `json:"normal_high"`
+	Points     []VitalPoint `json:"points"`
+}
+
+type VitalPoint struct {
+	VitalsID   uuid.UUID `json:"vitals_id"`
+	RecordedAt time.Time `json:"recorded_at"`
+	Value      float64   `json:"value"`
+	Flag       string    `json:"flag,omitempty"` // low or high when outside the normal range
+}
+
+// vitalType describes one measurement. Values outside min..max are physically
+// implausible and rejected; values outside the adult normal range are flagged.
+type vitalType struct {
+	name       string
+	unit       string
+	value      func(v *models.Vitals) *float64
+	min, max   float64
+	normalLow  float64
+	normalHigh float64
+}
+
+var vitalTypes = []vitalType{
+	{"systolic_bp", "mmHg", func(v *models.Vitals) *float64 { return v.SystolicBP }, 40, 300, 90, 139},
+	{"diastolic_bp", "mmHg", func(v *models.Vitals) *float64 { return v.DiastolicBP }, 20, 200, 60, 89},
+	{"heart_rate", "beats/min", func(v *models.Vitals) *float64 { return v.HeartRate }, 20, 300, 60, 100},
+	{"temperature", "°C", func(v *models.Vitals) *float64 { return v.TemperatureC }, 25, 45, 36.1, 37.8},
+	{"spo2", "%", func(v *models.Vitals) *float64 { return v.SpO2 }, 50, 100, 95, 100},
+	{"respiratory_rate", "breaths/min", func(v *models.Vitals) *float64 { return v.RespiratoryRate }, 4, 80, 12, 20},
+	{"height", "cm", func(v *models.Vitals) *float64 { return v.HeightCm }, 20, 260, 0, math.MaxFloat64},
+	{"weight", "kg", func(v *models.Vitals) *float64 { return v.WeightKg }, 0.3, 500, 0, math.MaxFloat64},
+	{"bmi", "kg/m²", func(v *models.Vitals) *float64 { return v.BMI }, 5, 150, 18.5, 24.9},
+}
+
+type vitalsService struct {
+	db  *database.DB
+	cfg config.Config
+}
+
+func NewVitalsService(db *database.DB, cfg config.Config) VitalsService {
+	return &vitalsService{
+		db:  db,
+		cfg: cfg,
+	}
+}
+
+// RecordVitals normalises the measurements to metric units, validates them and
+// stores them. It returns the low/high flags of the saved values by type.
+func (s *vitalsService) RecordVitals(recordedBy, patientID uuid.UUID, input *VitalsInput) (*models.Vitals, map[string]string, error) {
+	var patient models.Patient
+	if err := s.db.Conn.First(&patient, "id = ?", patientID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, errors.New("patient not found")
+		}
+		return nil, nil, err
+	}
+	if input.AppointmentID != nil {
+		var appointment models.Appointment
+		if err := s.db.Conn.First(&appointment, "id = ? AND patient_id = ?", *input.AppointmentID, patientID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, nil, errors.New("appointment not found for this patient")
+			}
+			return nil, nil, err
+		}
+	}
+
+	vitals := models.Vitals{
+		PatientID:       patientID,
+		AppointmentID:   input.AppointmentID,
+		RecordedBy:      recordedBy,
+		RecordedAt:      time.Now(),
+		SystolicBP:      input.SystolicBP,
+		DiastolicBP:     input.DiastolicBP,
+		HeartRate:       input.HeartRate,
+		SpO2:            input.SpO2,
+		RespiratoryRate: input.RespiratoryRate,
+		Notes:           input.Notes,
+	}
+	if input.RecordedAt != nil {
+		if input.RecordedAt.After(time.Now().Add(5 * time.Minute)) {
+			return nil, nil, errors.New("recorded_at cannot be in the future")
+		}
+		vitals.RecordedAt = *input.RecordedAt
+	}
+
+	var err error
+	if vitals.TemperatureC, err = normalizeUnit(input.Temperature, input.TemperatureUnit, "c", map[string]func(float64) float64{
+		"c": func(v float64) float64 { return v },
+		"f": func(v float64) float64 { return (v - 32) * 5 / 9 },
+	}); err != nil {
+		return nil, nil, fmt.Errorf("temperature_unit: %w", err)
+	}
+	if vitals.HeightCm, err = normalizeUnit(input.Height, input.HeightUnit, "cm", map[string]func(float64) float64{
+		"cm": func(v float64) float64 { return v },
+		"m":  func(v float64) float64 { return v * 100 },
+		"in": func(v float64) float64 { return v * 2.54 },
+	}); err != nil {
+		return nil, nil, fmt.Errorf("height_unit: %w", err)
+	}
+	if vitals.WeightKg, err = normalizeUnit(input.Weight, input.WeightUnit, "kg", map[string]func(float64) float64{
+		"kg": func(v float64) float64 { return v },
+		"lb": func(v float64) float64 { return v * 0.45359237 },
+	}); err != nil {
+		return nil, nil, fmt.Errorf("weight_unit: %w", err)
+	}
+
+	// BMI needs a height; adults rarely have it re-measured, so fall back to the latest one on file
+	if vitals.WeightKg != nil {
+		height := vitals.HeightCm
+		if height == nil {
+			var previous models.Vitals
+			if err := s.db.Conn.Where("patient_id = ? AND height_cm IS NOT NULL", patientID).
+				Order("recorded_at DESC").First(&previous).Error; err == nil {
+				height = previous.HeightCm
+			}
+		}
+		if height != nil {
+			meters := *height / 100
+			bmi := math.Round(*vitals.WeightKg/(meters*meters)*10) / 10
+			vitals.BMI = &bmi
+		}
+	}
+
+	empty := true
+	for _, t := range vitalTypes {
+		v := t.value(&vitals)
+		if v == nil {
+			continue
+		}
+		empty = false
+		if *v < t.min || *v > t.max {
+			return nil, nil, fmt.Errorf("%s of %g %s is outside the possible range %g-%g", t.name, *v, t.unit, t.min, t.max)
+		}
+	}
+	if empty {
+		return nil, nil, errors.New("at least one measurement is required")
+	}
+	if vitals.SystolicBP != nil && vitals.DiastolicBP != nil && *vitals.DiastolicBP >= *vitals.SystolicBP {
+		return nil, nil, errors.New("diastolic_bp must be lower than systolic_bp")
+	}
+
+	if err := s.db.Conn.Create(&vitals).Error; err != nil {
+		return nil, nil, err
+	}
+
+	flags := make(map[string]string)
+	for _, t := range vitalTypes {
+		if v := t.value(&vitals); v != nil {
+			if flag := t.flag(*v); flag != "" {
+				flags[t.name] = flag
+			}
+		}
+	}
+	return &vitals, flags, nil
+}
+
+// GetVitalSeries returns one series per vital sign, or only the requested one,
+// for measurements taken between from and to.
+func (s *vitalsService) GetVitalSeries(patientID uuid.UUID, typeName string, from, to time.Time) ([]VitalSeries, error) {
+	types := vitalTypes
+	if typeName != "" {
+		types = nil
+		for _, t := range vitalTypes {
+			if t.name == typeName {
+				types = []vitalType{t}
+				break
+			}
+		}
+		if types == nil {
+			return nil, errors.New("unknown vital sign type " + typeName)
+		}
+	}
+
+	var records []models.Vitals
+	if err := s.db.Conn.Where("patient_id = ? AND recorded_at >= ? AND recorded_at <= ?", patientID, from, to).
+		Order("recorded_at").Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	series := make([]VitalSeries, 0, len(types))
+	for _, t := range types {
+		ts := VitalSeries{Type: t.name, Unit: t.unit, NormalLow: t.normalLow, NormalHigh: t.normalHigh, Points: []VitalPoint{}}
+		if t.normalHigh == math.MaxFloat64 {
+			ts.NormalHigh = 0 // no normal range
+		}
+		for i := range records {
+			if v := t.value(&records[i]); v != nil {
+				ts.Points = append(ts.Points, VitalPoint{
+					VitalsID:   records[i].ID,
+					RecordedAt: records[i].RecordedAt,
+					Value:      *v,
+					Flag:       t.flag(*v),
+				})
+			}
+		}
+		series = append(series, ts)
+	}
+	return series, nil
+}
+
+func (t vitalType) flag(v float64) string {
+	switch {
+	case v < t.normalLow:
+		return "low"
+	case v > t.normalHigh:
+		return "high"
+	}
+	return ""
+}
+
+// normalizeUnit converts a measurement to the storage unit. An empty unit means
+// the value is already in the default unit.
+func normalizeUnit(value *float64, unit, defaultUnit string, conversions map[string]func(float64) float64) (*float64, error) {
+	if value == nil {
+		return nil, nil
+	}
+	if unit == "" {
+		unit = defaultUnit
+	}
+	convert, ok := conversions[strings.ToLower(unit)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported unit %q", unit)
+	}
+	v := math.Round(convert(*value)*100) / 100
+	return &v, nil
+}